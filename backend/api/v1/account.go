@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UnbindAccount Unlink a login provider from the current user
+// @Summary Unlink a login provider
+// @Description Remove a bound GitHub/Google/email login from the current user. The last remaining login method cannot be removed.
+// @Tags Profile
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.UnbindAccountRequest true "Provider to unlink"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /api/v1/user/unbind [post]
+func UnbindAccount(c *gin.Context) {
+	var req request.UnbindAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	if userUUID == "" {
+		response.FailWithMessage("Not logged in or unauthorized", c)
+		return
+	}
+
+	if err := service.UnbindAccount(userUUID, service.AuthProvider(req.Provider)); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("Unlinked successfully", c)
+}
+
+// MergeAccount Merge another account into the current user
+// @Summary Merge accounts
+// @Description When binding returns duplicate_bind, the response carries a merge_token proving ownership of both accounts.
+// @Description Posting it here moves posts, comments, follows, favorites, chats and match history into the current account and deletes the other one.
+// @Description If the other account has two-factor authentication enabled, totp_code (or a recovery code) for that account is required.
+// @Tags Profile
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.MergeAccountRequest true "Merge token"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /api/v1/user/merge [post]
+func MergeAccount(c *gin.Context) {
+	var req request.MergeAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	if userUUID == "" {
+		response.FailWithMessage("Not logged in or unauthorized", c)
+		return
+	}
+
+	if err := service.MergeAccount(userUUID, req.MergeToken, req.TOTPCode); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("Accounts merged successfully", c)
+}
@@ -0,0 +1,236 @@
+package v1
+
+import (
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminSearchUsers Look up users
+// @Summary Look up users by UUID, username or email (requires user:read)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.AdminUserSearchRequest true "Keyword + pagination"
+// @Success 200 {object} response.Response{data=response.AdminUserListResponse}
+// @Failure 403 {object} response.Response
+// @Router /api/v1/admin/users/search [post]
+func AdminSearchUsers(c *gin.Context) {
+	var req request.AdminUserSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+
+	list, total, err := service.AdminSearchUsers(req.Keyword, req.PageNum, req.PageSize)
+	if err != nil {
+		response.FailWithMessage("Failed to search users: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(response.AdminUserListResponse{
+		Total: int(total),
+		List:  list,
+	}, c)
+}
+
+// AdminGetUser Get user detail
+// @Summary Get a user's detail and content statistics (requires user:read)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param uuid path string true "User UUID"
+// @Success 200 {object} response.Response{data=response.AdminUserDetail}
+// @Failure 403 {object} response.Response
+// @Router /api/v1/admin/users/{uuid} [get]
+func AdminGetUser(c *gin.Context) {
+	detail, err := service.AdminGetUser(c.Param("uuid"))
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(detail, c)
+}
+
+// AdminBanUser Ban a user
+// @Summary Ban a user (requires user:ban)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.AdminBanRequest true "User UUID + reason"
+// @Success 200 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /api/v1/admin/users/ban [post]
+func AdminBanUser(c *gin.Context) {
+	var req request.AdminBanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	actorUUID := c.MustGet("uuid").(string)
+
+	if err := service.AdminBanUser(actorUUID, req.UUID, req.Reason); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("User banned", c)
+}
+
+// AdminUnbanUser Unban a user
+// @Summary Unban a user (requires user:ban)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.AdminUnbanRequest true "User UUID"
+// @Success 200 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /api/v1/admin/users/unban [post]
+func AdminUnbanUser(c *gin.Context) {
+	var req request.AdminUnbanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	actorUUID := c.MustGet("uuid").(string)
+
+	if err := service.AdminUnbanUser(actorUUID, req.UUID); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("User unbanned", c)
+}
+
+// AdminSetUserRole Change a user's role
+// @Summary Change a user's role and extra permissions (requires user:role)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.AdminSetRoleRequest true "User UUID + role + permissions"
+// @Success 200 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /api/v1/admin/users/role [post]
+func AdminSetUserRole(c *gin.Context) {
+	var req request.AdminSetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	actorUUID := c.MustGet("uuid").(string)
+
+	if err := service.AdminSetUserRole(actorUUID, req.UUID, req.Role, req.Permissions); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("Role updated", c)
+}
+
+// AdminTakedownPost Take down a post
+// @Summary Take down a post (requires content:moderate)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.AdminTakedownPostRequest true "Post ID + reason"
+// @Success 200 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /api/v1/admin/posts/takedown [post]
+func AdminTakedownPost(c *gin.Context) {
+	var req request.AdminTakedownPostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	actorUUID := c.MustGet("uuid").(string)
+
+	if err := service.AdminTakedownPost(actorUUID, req.PostID, req.Reason); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("Post taken down", c)
+}
+
+// AdminTakedownComment Take down a comment
+// @Summary Take down a comment and its replies (requires content:moderate)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.AdminTakedownCommentRequest true "Comment ID + reason"
+// @Success 200 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /api/v1/admin/comments/takedown [post]
+func AdminTakedownComment(c *gin.Context) {
+	var req request.AdminTakedownCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	actorUUID := c.MustGet("uuid").(string)
+
+	if err := service.AdminTakedownComment(actorUUID, req.CommentID, req.Reason); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("Comment taken down", c)
+}
+
+// AdminModerationLogs List moderation logs
+// @Summary List moderation logs (requires user:read)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.AdminLogListRequest true "Pagination"
+// @Success 200 {object} response.Response{data=response.ModerationLogListResponse}
+// @Failure 403 {object} response.Response
+// @Router /api/v1/admin/logs [post]
+func AdminModerationLogs(c *gin.Context) {
+	var req request.AdminLogListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+
+	list, total, err := service.AdminListModerationLogs(req.PageNum, req.PageSize)
+	if err != nil {
+		response.FailWithMessage("Failed to retrieve logs: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(response.ModerationLogListResponse{
+		Total: int(total),
+		List:  list,
+	}, c)
+}
+
+// AdminGetConfig Inspect runtime config
+// @Summary Inspect runtime config with secrets masked (requires config:read)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /api/v1/admin/config [get]
+func AdminGetConfig(c *gin.Context) {
+	response.OkWithData(service.AdminGetConfig(), c)
+}
+
+// AdminRebuildSearchIndex Rebuild the search index
+// @Summary Rebuild the full-text search index from the database (requires search:reindex)
+// @Tags Admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /api/v1/admin/search/reindex [post]
+func AdminRebuildSearchIndex(c *gin.Context) {
+	if err := service.RebuildSearchIndex(); err != nil {
+		response.FailWithMessage("Failed to rebuild search index: "+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("Search index rebuilt", c)
+}
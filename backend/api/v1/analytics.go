@@ -0,0 +1,35 @@
+package v1
+
+import (
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthorAnalytics Author analytics dashboard
+// @Summary Daily views, likes, favorites, comments and follower growth across the current user's posts
+// @Description Backed by daily rollups refreshed every hour, so today's numbers may lag by up to an hour.
+// @Tags User
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.AuthorAnalyticsRequest true "Window in days + number of top posts"
+// @Success 200 {object} response.Response{data=response.AuthorAnalytics}
+// @Router /api/v1/user/analytics [post]
+func AuthorAnalytics(c *gin.Context) {
+	var req request.AuthorAnalyticsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	result, err := service.GetAuthorAnalytics(userUUID, req.Days, req.Top)
+	if err != nil {
+		response.FailWithMessage("Failed to load analytics: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(result, c)
+}
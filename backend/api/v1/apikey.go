@@ -0,0 +1,79 @@
+package v1
+
+import (
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAPIKey Create a personal access token
+// @Summary Create a personal API key
+// @Description Creates a scoped token for scripts and bots. Send it as "Authorization: Bearer oh_...".
+// @Description Available scopes: posts:read, posts:write, chat:read, chat:write, profile:read, follows:write, match:read.
+// @Description The plaintext key is only returned once.
+// @Tags Profile
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.CreateAPIKeyRequest true "Name, scopes, rate limit, expiry"
+// @Success 200 {object} response.Response{data=response.APIKeyCreateResponse}
+// @Router /api/v1/user/apikeys/create [post]
+func CreateAPIKey(c *gin.Context) {
+	var req request.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	result, err := service.CreateAPIKey(userUUID, req.Name, req.Scopes, req.RateLimit, req.ExpiresInDays)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(result, c)
+}
+
+// ListAPIKeys List personal access tokens
+// @Summary List personal API keys
+// @Tags Profile
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} response.Response{data=[]response.APIKeyInfo}
+// @Router /api/v1/user/apikeys [get]
+func ListAPIKeys(c *gin.Context) {
+	userUUID := c.MustGet("uuid").(string)
+
+	list, err := service.ListAPIKeys(userUUID)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(list, c)
+}
+
+// RevokeAPIKey Revoke a personal access token
+// @Summary Revoke a personal API key
+// @Tags Profile
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.RevokeAPIKeyRequest true "Key ID"
+// @Success 200 {object} response.Response
+// @Router /api/v1/user/apikeys/revoke [post]
+func RevokeAPIKey(c *gin.Context) {
+	var req request.RevokeAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	if err := service.RevokeAPIKey(userUUID, req.ID); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("API key revoked", c)
+}
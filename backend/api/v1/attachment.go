@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UploadAttachment Upload a post attachment
+// @Summary Upload an image, PDF or dataset archive to attach to a post
+// @Description Limits: image 10MB (jpg/png/gif/webp), pdf 30MB, archive 100MB (zip/gz/tgz/tar/7z/csv/tsv).
+// @Description Pass the returned id in the attachments field when creating or updating a post.
+// @Description At most 30 files / 500MB per user per hour. PDF page count and thumbnail are filled in shortly after upload.
+// @Description Uploaded files carry no url; use /api/v1/posts/download to get a short-lived link.
+// @Tags Media File
+// @Security ApiKeyAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "File"
+// @Param kind formData string true "image / pdf / archive"
+// @Success 200 {object} response.Response{data=response.AttachmentInfo}
+// @Router /api/v1/media/attachment [post]
+func UploadAttachment(c *gin.Context) {
+	var req request.UploadAttachmentRequest
+	if err := c.ShouldBind(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		response.FailWithMessage("Failed to read uploaded file", c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	info, err := service.UploadAttachment(userUUID, req.Kind, file)
+	if err != nil {
+		response.FailWithMessage("Upload failed: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(info, c)
+}
+
+// DownloadAttachment Download a post attachment
+// @Summary Get the URL of an attachment and count the download
+// @Description Uploaded files return a signed URL valid for 10 minutes; links return their URL.
+// @Tags Posts
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.AttachmentDownloadRequest true "Attachment ID"
+// @Success 200 {object} response.Response{data=response.AttachmentDownloadResponse}
+// @Router /api/v1/posts/download [post]
+func DownloadAttachment(c *gin.Context) {
+	var req request.AttachmentDownloadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	url, err := service.DownloadAttachment(userUUID, req.AttachmentID)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(response.AttachmentDownloadResponse{URL: url}, c)
+}
@@ -107,7 +107,7 @@ func UnlikeComment(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param data body request.ListCommentRequest true "帖子ID + 分页参数 + 排序"
-// @Success 200 {object} response.Response{data=[]response.CommentInfo}
+// @Success 200 {object} response.Response{data=response.CommentListResponse}
 // @Failure 400 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Security ApiKeyAuth
@@ -126,15 +126,15 @@ func ListComments(c *gin.Context) {
 		return
 	}
 
-	list, total, err := service.ListComments(req.PostID, req.PageNum, req.PageSize, req.SortBy, userUUID)
+	list, info, err := service.ListComments(req.PostID, req.CursorPage, req.SortBy, userUUID)
 	if err != nil {
 		response.FailWithMessage("Failed to retrieve comments: "+err.Error(), c)
 		return
 	}
 
-	response.OkWithData(gin.H{
-		"total": total,
-		"list":  list,
+	response.OkWithData(response.CommentListResponse{
+		PageInfo: info,
+		List:     list,
 	}, c)
 }
 
@@ -145,7 +145,7 @@ func ListComments(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param data body request.ListReplyRequest true "comment_id + 分页参数"
-// @Success 200 {object} response.Response{data=[]response.CommentInfo}
+// @Success 200 {object} response.Response{data=response.CommentListResponse}
 // @Failure 400 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Security ApiKeyAuth
@@ -164,14 +164,14 @@ func ListReplies(c *gin.Context) {
 		return
 	}
 
-	list, total, err := service.ListChildComments(req.CommentID, req.PageNum, req.PageSize, userUUID)
+	list, info, err := service.ListChildComments(req.CommentID, req.CursorPage, userUUID)
 	if err != nil {
 		response.FailWithMessage("Failed to retrieve replies: "+err.Error(), c)
 		return
 	}
 
-	response.OkWithData(gin.H{
-		"total": total,
-		"list":  list,
+	response.OkWithData(response.CommentListResponse{
+		PageInfo: info,
+		List:     list,
 	}, c)
 }
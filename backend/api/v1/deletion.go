@@ -0,0 +1,96 @@
+package v1
+
+import (
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/service"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportUserData Download all personal data
+// @Summary Export personal data
+// @Description Downloads a zip archive with profile, posts, comments, likes, favorites, follows, chat history, match history, login methods and API keys as JSON files.
+// @Tags Profile
+// @Security ApiKeyAuth
+// @Produce application/zip
+// @Success 200 {file} file "openhouse-export-<date>.zip"
+// @Router /api/v1/user/export [get]
+func ExportUserData(c *gin.Context) {
+	userUUID := c.MustGet("uuid").(string)
+
+	data, err := service.ExportUserData(userUUID)
+	if err != nil {
+		response.FailWithMessage("Failed to export data: "+err.Error(), c)
+		return
+	}
+	filename := fmt.Sprintf("openhouse-export-%s.zip", time.Now().Format("20060102"))
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, "application/zip", data)
+}
+
+// DeleteAccount Request account deletion
+// @Summary Request account deletion
+// @Description Schedules the account for permanent deletion after a grace period (14 days by default). The request can be cancelled until then.
+// @Description On deletion, own posts are removed, comments on other posts are anonymised, and likes, favorites, follows, chats, match history and login methods are deleted.
+// @Tags Profile
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.DeleteAccountRequest true "TOTP code if two-factor authentication is enabled"
+// @Success 200 {object} response.Response
+// @Router /api/v1/user/delete [post]
+func DeleteAccount(c *gin.Context) {
+	var req request.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	scheduledAt, err := service.RequestAccountDeletion(userUUID, req.TOTPCode)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(gin.H{"deletion_scheduled_at": scheduledAt}, "Account scheduled for deletion", c)
+}
+
+// CancelDeleteAccount Cancel account deletion
+// @Summary Cancel a pending account deletion
+// @Tags Profile
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} response.Response
+// @Router /api/v1/user/delete/cancel [post]
+func CancelDeleteAccount(c *gin.Context) {
+	userUUID := c.MustGet("uuid").(string)
+
+	if err := service.CancelAccountDeletion(userUUID); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("Account deletion cancelled", c)
+}
+
+// DeleteAccountStatus Get pending deletion
+// @Summary Get account deletion status
+// @Description deletion_scheduled_at is null when no deletion is pending.
+// @Tags Profile
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} response.Response
+// @Router /api/v1/user/delete/status [get]
+func DeleteAccountStatus(c *gin.Context) {
+	userUUID := c.MustGet("uuid").(string)
+
+	scheduledAt, err := service.GetAccountDeletionStatus(userUUID)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(gin.H{"deletion_scheduled_at": scheduledAt}, c)
+}
@@ -0,0 +1,111 @@
+package v1
+
+import (
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SaveDraft Save a draft
+// @Summary Create or autosave a draft (post_id empty creates a new one)
+// @Description Drafts and scheduled posts are only visible to their author and stay out of lists, feeds and search.
+// @Tags Posts
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.SaveDraftRequest true "Draft content"
+// @Success 200 {object} response.Response{data=response.DraftSavedResponse}
+// @Router /api/v1/posts/draft/save [post]
+func SaveDraft(c *gin.Context) {
+	var req request.SaveDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	result, err := service.SaveDraft(userUUID, req)
+	if err != nil {
+		response.FailWithMessage("Failed to save draft: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(result, c)
+}
+
+// ListDrafts List my drafts
+// @Summary List the current user's drafts and scheduled posts
+// @Tags Posts
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.ListDraftRequest true "Pagination"
+// @Success 200 {object} response.Response{data=response.PostListResponse}
+// @Router /api/v1/posts/drafts [post]
+func ListDrafts(c *gin.Context) {
+	var req request.ListDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	list, info, err := service.ListDrafts(userUUID, req.CursorPage)
+	if err != nil {
+		response.FailWithMessage("Failed to retrieve drafts: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(response.PostListResponse{
+		PageInfo: info,
+		List:     list,
+	}, c)
+}
+
+// PublishPost Publish a draft
+// @Summary Publish a draft now, or schedule it when publish_at is in the future
+// @Tags Posts
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.PublishPostRequest true "Post ID + optional publish time"
+// @Success 200 {object} response.Response
+// @Router /api/v1/posts/publish [post]
+func PublishPost(c *gin.Context) {
+	var req request.PublishPostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	if err := service.PublishPost(userUUID, req.PostID, req.PublishAt); err != nil {
+		response.FailWithMessage("Failed to publish post: "+err.Error(), c)
+		return
+	}
+	response.OkWithMessage("Post published", c)
+}
+
+// CancelSchedule Cancel a scheduled post
+// @Summary Cancel scheduled publishing and turn the post back into a draft
+// @Tags Posts
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.CancelScheduleRequest true "Post ID"
+// @Success 200 {object} response.Response
+// @Router /api/v1/posts/schedule/cancel [post]
+func CancelSchedule(c *gin.Context) {
+	var req request.CancelScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	if err := service.CancelSchedule(userUUID, req.PostID); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("Schedule cancelled", c)
+}
@@ -80,8 +80,10 @@ func UnfollowUser(c *gin.Context) {
 // @Tags User
 // @Accept json
 // @Produce json
+// @Description Page-number requests (page_num set, no cursor) return a bare array as before; cursor requests return an object with list and page info
 // @Param data body request.FollowListRequest true "Pagination"
 // @Success 200 {object} response.Response{data=[]response.FollowedUserInfo}
+// @Success 200 {object} response.Response{data=response.FollowListResponse}
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Security ApiKeyAuth
@@ -98,12 +100,12 @@ func FollowedList(c *gin.Context) {
 		response.FailWithMessage("Not logged in or unauthorized", c)
 		return
 	}
-	list, _, err := service.ListFollowedUsers(userUUID, req.PageNum, req.PageSize)
+	list, info, err := service.ListFollowedUsers(userUUID, req.CursorPage)
 	if err != nil {
 		response.FailWithMessage("Failed to retrieve list: "+err.Error(), c)
 		return
 	}
-	response.OkWithData(list, c)
+	writeFollowList(list, info, req.CursorPage, c)
 }
 
 // FollowersList Get my followers list
@@ -111,8 +113,10 @@ func FollowedList(c *gin.Context) {
 // @Tags User
 // @Accept json
 // @Produce json
+// @Description Page-number requests (page_num set, no cursor) return a bare array as before; cursor requests return an object with list and page info
 // @Param data body request.FollowListRequest true "Pagination"
 // @Success 200 {object} response.Response{data=[]response.FollowedUserInfo}
+// @Success 200 {object} response.Response{data=response.FollowListResponse}
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Security ApiKeyAuth
@@ -129,12 +133,24 @@ func FollowersList(c *gin.Context) {
 		response.FailWithMessage("Not logged in or unauthorized", c)
 		return
 	}
-	list, _, err := service.ListFollowers(userUUID, req.PageNum, req.PageSize)
+	list, info, err := service.ListFollowers(userUUID, req.CursorPage)
 	if err != nil {
 		response.FailWithMessage("Failed to retrieve followers: "+err.Error(), c)
 		return
 	}
-	response.OkWithData(list, c)
+	writeFollowList(list, info, req.CursorPage, c)
+}
+
+// writeFollowList 页码分页保持原来的数组格式, 兼容旧客户端; 游标分页返回列表和分页信息
+func writeFollowList(list []response.FollowedUserInfo, info response.PageInfo, page request.CursorPage, c *gin.Context) {
+	if !page.UseCursor() {
+		response.OkWithData(list, c)
+		return
+	}
+	response.OkWithData(response.FollowListResponse{
+		PageInfo: info,
+		List:     list,
+	}, c)
 }
 
 // FollowCount Get the count of my following and followers
@@ -221,13 +237,13 @@ func FollowedPosts(c *gin.Context) {
 		return
 	}
 
-	list, total, err := service.ListFollowedPosts(userUUID, req.PageNum, req.PageSize, req.SortOrder)
+	list, info, err := service.ListFollowedPosts(userUUID, req.CursorPage, req.SortOrder)
 	if err != nil {
 		response.FailWithMessage("Failed to retrieve posts: "+err.Error(), c)
 		return
 	}
 	response.OkWithData(response.PostListResponse{
-		Total: int(total),
-		List:  list,
+		PageInfo: info,
+		List:     list,
 	}, c)
 }
@@ -7,13 +7,14 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// MatchTrigger Trigger daily match (for testing purposes)
-// @Summary Trigger daily match (testing API)
-// @Tags Match
+// MatchTrigger Trigger daily match for all users
+// @Summary Trigger daily match (requires match:trigger)
+// @Tags Admin
+// @Security ApiKeyAuth
 // @Accept json
 // @Produce json
 // @Success 200 {object} response.Response
-// @Router /api/v1/match/trigger [post]
+// @Router /api/v1/admin/match/trigger [post]
 func MatchTrigger(c *gin.Context) {
 	err := service.TriggerDailyMatch()
 	if err != nil {
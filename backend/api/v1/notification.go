@@ -0,0 +1,58 @@
+package v1
+
+import (
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListNotifications List my notifications
+// @Summary List my notifications (currently @mentions in posts and comments), newest first, with the unread count
+// @Tags Notifications
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.ListNotificationsRequest true "Pagination + unread_only"
+// @Success 200 {object} response.Response{data=response.NotificationListResponse}
+// @Router /api/v1/notifications/list [post]
+func ListNotifications(c *gin.Context) {
+	var req request.ListNotificationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	result, err := service.ListNotifications(userUUID, req)
+	if err != nil {
+		response.FailWithMessage("Failed to retrieve notifications: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(result, c)
+}
+
+// ReadNotifications Mark notifications as read
+// @Summary Mark the given notifications as read; an empty ids list marks all of them
+// @Tags Notifications
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.ReadNotificationsRequest true "Notification IDs"
+// @Success 200 {object} response.Response
+// @Router /api/v1/notifications/read [post]
+func ReadNotifications(c *gin.Context) {
+	var req request.ReadNotificationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	if err := service.ReadNotifications(userUUID, req.IDs); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.Ok(c)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -70,6 +71,7 @@ func SendVerifyEmail(c *gin.Context) {
 // @Summary 邮箱验证码验证
 // @Description 验证邮箱验证码是否正确,如果正确则登录或注册用户
 // @Description 如果用户已经注册，则绑定邮箱到当前用户
+// @Description 如果账号开启了两步验证, 返回 two_factor_required 和 pre_auth_token, 需调用 /auth/2fa/verify 完成登录
 // @Tags Auth
 // @Accept json
 // @Produce json
@@ -107,7 +109,8 @@ func EmailLogin(c *gin.Context) {
 		fmt.Println("Registered user binding email, UUID:", userUUIDStr)
 		bindresult, err := service.BindAccount(authInput, userUUIDStr)
 		if err != nil {
-			response.FailWithMessage(err.Error(), c)
+			// duplicate_bind 时带回 merge_token, 前端可据此发起账号合并
+			response.FailWithDetailed(bindresult, err.Error(), c)
 			return
 		}
 		response.OkWithData(bindresult, c)
@@ -122,6 +125,27 @@ func EmailLogin(c *gin.Context) {
 	response.OkWithData(result, c)
 }
 
+// bindRedirectURL 绑定完成后跳转的前端地址, duplicate_bind 时附带 merge_token
+func bindRedirectURL(bindresult service.BindAccountResult) string {
+	// redirectURL := fmt.Sprintf("http://localhost:5173/bind_success?result=%s", bindresult.Result)
+	redirectURL := fmt.Sprintf("https://openhouse.horik.cn/bind_success?result=%s", bindresult.Result)
+	if bindresult.MergeToken != "" {
+		redirectURL += "&merge_token=" + url.QueryEscape(bindresult.MergeToken)
+	}
+	return redirectURL
+}
+
+// loginRedirectURL 第三方登录完成后跳转的前端地址
+// 开启了两步验证的账号只携带 pre_auth_token, 前端需调用 /auth/2fa/verify 换取正式 token
+func loginRedirectURL(result service.AuthResult) string {
+	base := "http://localhost:5173/oauth_success"
+	// base := "https://openhouse.horik.cn/oauth_success"
+	if result.TwoFactorRequired {
+		return base + "?two_factor_required=true&pre_auth_token=" + url.QueryEscape(result.PreAuthToken)
+	}
+	return fmt.Sprintf("%s?token=%s", base, result.Token)
+}
+
 // GitHubCallback GitHub login callback
 // @Summary GitHub登录回调, 前端不调用该API
 // @Description 用户在GitHub登录后，GitHub会回调该接口，并传递code参数
@@ -152,8 +176,7 @@ func GitHubCallback(c *gin.Context) {
 		fmt.Println("Registered user binding GitHub, UUID:", userUUIDStr)
 		bindresult, _ := service.BindAccount(authInput, userUUIDStr)
 		fmt.Println("bindresult", bindresult)
-		// redirectURL := fmt.Sprintf("http://localhost:5173/bind_success?result=%s", bindresult.Result)
-		redirectURL := fmt.Sprintf("https://openhouse.horik.cn/bind_success?result=%s", bindresult.Result)
+		redirectURL := bindRedirectURL(bindresult)
 		c.Redirect(http.StatusFound, redirectURL)
 		return
 	}
@@ -164,9 +187,7 @@ func GitHubCallback(c *gin.Context) {
 		return
 	}
 
-	redirectURL := fmt.Sprintf("http://localhost:5173/oauth_success?token=%s", result.Token)
-	// redirectURL := fmt.Sprintf("https://openhouse.horik.cn/oauth_success?token=%s", result.Token)
-	c.Redirect(http.StatusFound, redirectURL)
+	c.Redirect(http.StatusFound, loginRedirectURL(result))
 }
 
 // GoogleCallback Google login callback
@@ -199,8 +220,7 @@ func GoogleCallback(c *gin.Context) {
 	if userUUIDStr, ok := userUUID.(string); ok {
 		fmt.Println("Registered user binding Google, UUID:", userUUIDStr)
 		bindresult, _ := service.BindAccount(authInput, userUUIDStr)
-		// redirectURL := fmt.Sprintf("http://localhost:5173/bind_success?result=%s", bindresult.Result)
-		redirectURL := fmt.Sprintf("https://openhouse.horik.cn/bind_success?result=%s", bindresult.Result)
+		redirectURL := bindRedirectURL(bindresult)
 		c.Redirect(http.StatusFound, redirectURL)
 		return
 	}
@@ -211,8 +231,5 @@ func GoogleCallback(c *gin.Context) {
 		return
 	}
 
-	redirectURL := fmt.Sprintf("http://localhost:5173/oauth_success?token=%s", result.Token)
-	// redirectURL := fmt.Sprintf("https://openhouse.horik.cn/oauth_success?token=%s", result.Token)
-
-	c.Redirect(http.StatusFound, redirectURL)
+	c.Redirect(http.StatusFound, loginRedirectURL(result))
 }
@@ -0,0 +1,35 @@
+package v1
+
+import (
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VotePoll Vote in a post's poll
+// @Summary Vote in the poll attached to a post; voting again replaces the previous choice
+// @Description Single-choice polls accept exactly one option. Votes are rejected after the poll closes.
+// @Tags Posts
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.PollVoteRequest true "Post ID + chosen options"
+// @Success 200 {object} response.Response{data=response.PollInfo}
+// @Router /api/v1/posts/poll/vote [post]
+func VotePoll(c *gin.Context) {
+	var req request.PollVoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	poll, err := service.VotePoll(userUUID, req.PostID, req.OptionIDs)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(poll, c)
+}
@@ -26,7 +26,7 @@ func CreatePost(c *gin.Context) {
 		return
 	}
 
-	post, err := service.CreatePost(userUUID, req.Title, req.Content, req.ImageURLs)
+	post, err := service.CreatePost(userUUID, req.Title, req.Content, req.ImageURLs, req.Attachments, req.Poll, req.Tags, req.Visibility, req.PublishAt)
 	if err != nil {
 		response.FailWithMessage("Failed to create post: "+err.Error(), c)
 		return
@@ -58,9 +58,9 @@ func UpdatePost(c *gin.Context) {
 	response.OkWithMessage("Post updated successfully", c)
 }
 
-// ListPosts Get a list of posts
+// ListPosts Get a list of posts, mode selects fresh / hot / personalized ordering
 func ListPosts(c *gin.Context) {
-	var req request.ListPostRequest
+	var req request.ListFeedRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.FailWithMessage("Invalid request parameters: "+err.Error(), c)
 		return
@@ -73,15 +73,15 @@ func ListPosts(c *gin.Context) {
 		return
 	}
 
-	list, total, err := service.ListPosts(req.PageNum, req.PageSize, req.SortOrder, userUUID)
+	list, info, err := service.ListFeed(req.Mode, req.CursorPage, req.SortOrder, userUUID)
 	if err != nil {
 		response.FailWithMessage("Failed to retrieve posts: "+err.Error(), c)
 		return
 	}
 
 	resp := response.PostListResponse{
-		Total: int(total),
-		List:  list,
+		PageInfo: info,
+		List:     list,
 	}
 	response.OkWithData(resp, c)
 }
@@ -101,15 +101,15 @@ func ListMyPosts(c *gin.Context) {
 		return
 	}
 
-	list, total, err := service.ListUserPosts(userUUID, req.PageNum, req.PageSize, req.SortOrder, userUUID)
+	list, info, err := service.ListUserPosts(userUUID, req.CursorPage, req.SortOrder, userUUID)
 	if err != nil {
 		response.FailWithMessage("Failed to retrieve posts: "+err.Error(), c)
 		return
 	}
 
 	response.OkWithData(response.PostListResponse{
-		Total: int(total),
-		List:  list,
+		PageInfo: info,
+		List:     list,
 	}, c)
 }
 
@@ -202,15 +202,15 @@ func FavoriteList(c *gin.Context) {
 		return
 	}
 
-	list, total, err := service.ListFavoritePosts(userUUID, req.PageNum, req.PageSize, req.SortOrder)
+	list, info, err := service.ListFavoritePosts(userUUID, req.CursorPage, req.SortOrder)
 	if err != nil {
 		response.FailWithMessage("获取收藏失败："+err.Error(), c)
 		return
 	}
 
 	response.OkWithData(response.PostListResponse{
-		Total: int(total),
-		List:  list,
+		PageInfo: info,
+		List:     list,
 	}, c)
 }
 
@@ -319,3 +319,29 @@ func PostDetail(c *gin.Context) {
 
 	response.OkWithData(detail, c)
 }
+
+// PostViewStats 帖子浏览统计
+// @Summary 帖子浏览统计（仅作者）
+// @Description 累计浏览数、最近 days 天的独立访客数和每日浏览; 同一用户 30 分钟内重复浏览只计一次
+// @Tags 帖子 Posts
+// @Accept json
+// @Produce json
+// @Param data body request.PostViewStatsRequest true "帖子ID + 天数"
+// @Success 200 {object} response.Response{data=response.PostViewStats}
+// @Security ApiKeyAuth
+// @Router /api/v1/posts/views/stats [post]
+func PostViewStats(c *gin.Context) {
+	var req request.PostViewStatsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	stats, err := service.GetPostViewStats(req.PostID, userUUID, req.Days)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(stats, c)
+}
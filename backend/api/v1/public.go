@@ -0,0 +1,149 @@
+package v1
+
+import (
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/service"
+	"OpenHouse/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 公开只读接口: 不登录也能访问, 用于分享帖子链接
+// 未登录时只返回公开帖子, 并省略是否点赞、是否关注等个人字段; 带上 token 时与登录接口的结果一致
+
+// PublicListPosts Public post feed
+// @Summary List posts without logging in (fresh or hot; personalized falls back to hot when anonymous)
+// @Tags Public
+// @Accept json
+// @Produce json
+// @Param data body request.ListFeedRequest true "Pagination, sort order and feed mode"
+// @Success 200 {object} response.Response{data=response.PostListResponse}
+// @Router /api/v1/public/posts [post]
+func PublicListPosts(c *gin.Context) {
+	var req request.ListFeedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.GetString("uuid")
+
+	list, info, err := service.ListFeed(req.Mode, req.CursorPage, req.SortOrder, userUUID)
+	if err != nil {
+		response.FailWithMessage("Failed to retrieve posts: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(response.PostListResponse{
+		PageInfo: info,
+		List:     list,
+	}, c)
+}
+
+// PublicUserPosts Public profile posts
+// @Summary List a user's posts without logging in
+// @Tags Public
+// @Accept json
+// @Produce json
+// @Param data body request.UserPostsRequest true "User UUID + pagination"
+// @Success 200 {object} response.Response{data=response.PostListResponse}
+// @Router /api/v1/public/user/posts [post]
+func PublicUserPosts(c *gin.Context) {
+	var req request.UserPostsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.GetString("uuid")
+
+	list, info, err := service.ListUserPosts(req.UUID, req.CursorPage, req.SortOrder, userUUID)
+	if err != nil {
+		response.FailWithMessage("Failed to retrieve posts: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(response.PostListResponse{
+		PageInfo: info,
+		List:     list,
+	}, c)
+}
+
+// PublicPostDetail Public post detail
+// @Summary Get a post without logging in; anonymous views are deduplicated by IP and user agent
+// @Tags Public
+// @Accept json
+// @Produce json
+// @Param data body request.PostDetailRequest true "Post ID"
+// @Success 200 {object} response.Response{data=response.PostDetailResponse}
+// @Router /api/v1/public/posts/detail [post]
+func PublicPostDetail(c *gin.Context) {
+	var req request.PostDetailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.GetString("uuid")
+	viewerKey := userUUID
+	if viewerKey == "" {
+		viewerKey = utils.AnonymousViewerKey(c.ClientIP(), c.Request.UserAgent())
+	}
+
+	detail, err := service.GetPostDetail(req.PostID, userUUID, viewerKey)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(detail, c)
+}
+
+// PublicComments Public comment list
+// @Summary List top-level comments of a post without logging in
+// @Tags Public
+// @Accept json
+// @Produce json
+// @Param data body request.ListCommentRequest true "Post ID + pagination + sort"
+// @Success 200 {object} response.Response{data=response.CommentListResponse}
+// @Router /api/v1/public/comments [post]
+func PublicComments(c *gin.Context) {
+	var req request.ListCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.GetString("uuid")
+
+	list, info, err := service.ListComments(req.PostID, req.CursorPage, req.SortBy, userUUID)
+	if err != nil {
+		response.FailWithMessage("Failed to retrieve comments: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(response.CommentListResponse{
+		PageInfo: info,
+		List:     list,
+	}, c)
+}
+
+// PublicReplies Public replies
+// @Summary List replies to a comment without logging in
+// @Tags Public
+// @Accept json
+// @Produce json
+// @Param data body request.ListReplyRequest true "comment_id + pagination"
+// @Success 200 {object} response.Response{data=response.CommentListResponse}
+// @Router /api/v1/public/replies [post]
+func PublicReplies(c *gin.Context) {
+	var req request.ListReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.GetString("uuid")
+
+	list, info, err := service.ListChildComments(req.CommentID, req.CursorPage, userUUID)
+	if err != nil {
+		response.FailWithMessage("Failed to retrieve replies: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(response.CommentListResponse{
+		PageInfo: info,
+		List:     list,
+	}, c)
+}
@@ -0,0 +1,60 @@
+package v1
+
+import (
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/service"
+	"OpenHouse/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RepostPost Repost or quote a post
+// @Summary Repost a post to your followers, or publish a quote post when content is given
+// @Description Plain reposts appear in followers' feeds and on your profile only. Reposting a plain repost reposts its original.
+// @Tags Posts
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.RepostRequest true "Post ID + optional quote text"
+// @Success 200 {object} response.Response{data=response.PostInfo}
+// @Router /api/v1/posts/repost [post]
+func RepostPost(c *gin.Context) {
+	var req request.RepostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	post, err := service.RepostPost(userUUID, req.PostID, req.Content, req.Tags)
+	if err != nil {
+		response.FailWithMessage("Failed to repost: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(utils.ConvertPostModelWithUser(post, userUUID), c)
+}
+
+// UndoRepost Undo a plain repost
+// @Summary Remove your plain repost of a post (quote posts are deleted like normal posts)
+// @Tags Posts
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.UndoRepostRequest true "ID of the reposted post"
+// @Success 200 {object} response.Response
+// @Router /api/v1/posts/unrepost [post]
+func UndoRepost(c *gin.Context) {
+	var req request.UndoRepostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	if err := service.UndoRepost(userUUID, req.PostID); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("Repost removed", c)
+}
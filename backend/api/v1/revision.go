@@ -0,0 +1,60 @@
+package v1
+
+import (
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PostRevisions List the revision history of a post
+// @Summary List every stored version of a post, newest first
+// @Description Posts published before revisions were recorded report a single version.
+// @Tags Posts
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.PostRevisionsRequest true "Post ID"
+// @Success 200 {object} response.Response{data=[]response.PostRevisionInfo}
+// @Router /api/v1/posts/revisions [post]
+func PostRevisions(c *gin.Context) {
+	var req request.PostRevisionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	list, err := service.ListPostRevisions(req.PostID, userUUID)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(list, c)
+}
+
+// PostRevisionDiff Diff two revisions of a post
+// @Summary Line-based diff of the title and content between two versions of a post
+// @Tags Posts
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.RevisionDiffRequest true "Post ID + versions"
+// @Success 200 {object} response.Response{data=response.RevisionDiff}
+// @Router /api/v1/posts/revisions/diff [post]
+func PostRevisionDiff(c *gin.Context) {
+	var req request.RevisionDiffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	diff, err := service.DiffPostRevisions(req.PostID, req.From, req.To, userUUID)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(diff, c)
+}
@@ -0,0 +1,91 @@
+package v1
+
+import (
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/search"
+	"OpenHouse/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Search Full-text search
+// @Summary Search posts, users and comments
+// @Description Searches post title/content, user name/research area/tags and comment text, ranked by relevance.
+// @Description Optional filters: types (post/user/comment), author_uuid, start_date/end_date (YYYY-MM-DD), tag.
+// @Description Matched keywords in title and snippet are wrapped in <em>; everything else is HTML-escaped.
+// @Tags Search
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.SearchRequest true "Keyword, filters and pagination"
+// @Success 200 {object} response.Response{data=response.SearchResponse}
+// @Router /api/v1/search [post]
+func Search(c *gin.Context) {
+	var req request.SearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+
+	q := search.Query{
+		Keyword:    req.Keyword,
+		Types:      req.Types,
+		AuthorUUID: req.AuthorUUID,
+		Tag:        req.Tag,
+		PageNum:    req.PageNum,
+		PageSize:   req.PageSize,
+	}
+	if req.StartDate != "" {
+		from, _ := time.ParseInLocation("2006-01-02", req.StartDate, time.Local)
+		q.From = &from
+	}
+	if req.EndDate != "" {
+		to, _ := time.ParseInLocation("2006-01-02", req.EndDate, time.Local)
+		to = to.Add(24*time.Hour - time.Nanosecond)
+		q.To = &to
+	}
+
+	userUUID := c.MustGet("uuid").(string)
+
+	list, total, err := service.Search(q, userUUID)
+	if err != nil {
+		response.FailWithMessage("Search failed: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(response.SearchResponse{
+		Total: int(total),
+		List:  list,
+	}, c)
+}
+
+// SemanticSearch Semantic post search
+// @Summary Search posts by meaning
+// @Description Embeds the query and returns the posts whose embeddings are closest by cosine similarity,
+// @Description so conceptually related posts are found even without shared keywords.
+// @Tags Search
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.SemanticSearchRequest true "Query text + limit"
+// @Success 200 {object} response.Response{data=[]response.SimilarPostInfo}
+// @Router /api/v1/search/semantic [post]
+func SemanticSearch(c *gin.Context) {
+	var req request.SemanticSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 10
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	list, err := service.SemanticSearch(req.Query, req.Limit, userUUID)
+	if err != nil {
+		response.FailWithMessage("Search failed: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(list, c)
+}
@@ -1,7 +1,9 @@
 package v1
 
 import (
+	"OpenHouse/model/request"
 	"OpenHouse/model/response"
+	"OpenHouse/service"
 	"strings"
 
 	swot "github.com/Mathpix/swot"
@@ -51,3 +53,66 @@ func CheckEmailDomain(c *gin.Context) {
 	}
 	response.FailWithMessage("Email does not belong to an academic institution", c)
 }
+
+// SendAcademicVerify Send an academic verification code to an institutional email
+// @Summary     Send academic verification code
+// @Description Send a 6-digit code to an institutional email address recognized by swot. The code expires in 10 minutes.
+// @Tags        Profile
+// @Security    ApiKeyAuth
+// @Accept      json
+// @Produce     json
+// @Param       data body request.AcademicVerifySendRequest true "Institutional email"
+// @Success     200 {object} response.Response
+// @Failure     400 {object} response.Response
+// @Router      /api/v1/user/verify/academic/send [post]
+func SendAcademicVerify(c *gin.Context) {
+	var req request.AcademicVerifySendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	if userUUID == "" {
+		response.FailWithMessage("Not logged in or unauthorized", c)
+		return
+	}
+
+	if err := service.SendAcademicVerifyCode(userUUID, req.Email); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("Verification code sent", c)
+}
+
+// ConfirmAcademicVerify Confirm the academic verification code
+// @Summary     Confirm academic verification
+// @Description On success the current user becomes verified and the institution name is stored on the profile.
+// @Tags        Profile
+// @Security    ApiKeyAuth
+// @Accept      json
+// @Produce     json
+// @Param       data body request.AcademicVerifyConfirmRequest true "Institutional email + code"
+// @Success     200 {object} response.Response{data=response.CheckEmailDomainResponse}
+// @Failure     400 {object} response.Response
+// @Router      /api/v1/user/verify/academic/confirm [post]
+func ConfirmAcademicVerify(c *gin.Context) {
+	var req request.AcademicVerifyConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	if userUUID == "" {
+		response.FailWithMessage("Not logged in or unauthorized", c)
+		return
+	}
+
+	school, err := service.ConfirmAcademicVerify(userUUID, req.Email, req.Code)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(response.CheckEmailDomainResponse{School: school}, c)
+}
@@ -0,0 +1,89 @@
+package v1
+
+import (
+	"OpenHouse/model/response"
+	"OpenHouse/service"
+	"OpenHouse/utils"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 订阅源接口不需要登录, 供站外的 RSS 阅读器订阅; ?format= 可选 atom（默认）、rss、json
+
+// UserFeed
+// @Summary Atom / RSS / JSON Feed of a user's public posts
+// @Tags Feed
+// @Produce xml
+// @Produce json
+// @Param uuid path string true "User UUID"
+// @Param format query string false "atom (default), rss or json"
+// @Success 200 {string} string "Feed document"
+// @Router /api/v1/feeds/user/{uuid} [get]
+func UserFeed(c *gin.Context) {
+	feed, err := service.UserFeed(c.Param("uuid"))
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	writeFeed(c, feed)
+}
+
+// TagFeed
+// @Summary Atom / RSS / JSON Feed of public posts with a tag
+// @Tags Feed
+// @Produce xml
+// @Produce json
+// @Param name path string true "Tag name"
+// @Param format query string false "atom (default), rss or json"
+// @Success 200 {string} string "Feed document"
+// @Router /api/v1/feeds/tag/{name} [get]
+func TagFeed(c *gin.Context) {
+	feed, err := service.TagFeed(c.Param("name"))
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	writeFeed(c, feed)
+}
+
+// HotFeed
+// @Summary Atom / RSS / JSON Feed of the global hot feed
+// @Tags Feed
+// @Produce xml
+// @Produce json
+// @Param format query string false "atom (default), rss or json"
+// @Success 200 {string} string "Feed document"
+// @Router /api/v1/feeds/hot [get]
+func HotFeed(c *gin.Context) {
+	feed, err := service.HotFeed()
+	if err != nil {
+		response.FailWithMessage("Failed to retrieve posts: "+err.Error(), c)
+		return
+	}
+	writeFeed(c, feed)
+}
+
+// writeFeed 按 format 输出订阅源, 订阅地址由配置的 API 地址和当前路径拼成, 不取请求头中的 Host
+func writeFeed(c *gin.Context, feed utils.Feed) {
+	api, err := service.FeedAPIURL()
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	format := c.Query("format")
+	feed.FeedURL = api + c.Request.URL.Path
+	if format != "" {
+		feed.FeedURL += "?format=" + url.QueryEscape(format)
+	}
+	// 不支持的 format 在这里报错, 不会出现在输出里
+	body, contentType, err := utils.RenderFeed(feed, format)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	// 内容只取决于配置和数据库, 与请求头无关, 阅读器轮询频繁, 允许缓存 5 分钟
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Data(http.StatusOK, contentType, body)
+}
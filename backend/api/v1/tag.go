@@ -0,0 +1,153 @@
+package v1
+
+import (
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TagDetail Get a tag
+// @Summary Get a tag with post, user and follower counts
+// @Description The tag may be given in any case, with or without the leading #.
+// @Tags Tags
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.TagRequest true "Tag name"
+// @Success 200 {object} response.Response{data=response.TagInfo}
+// @Router /api/v1/tags/detail [post]
+func TagDetail(c *gin.Context) {
+	var req request.TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	info, err := service.GetTag(req.Tag, userUUID)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(info, c)
+}
+
+// TagPosts List posts under a tag
+// @Summary List posts carrying a tag (explicit tags and #hashtags)
+// @Tags Tags
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.ListTagPostsRequest true "Tag name + pagination"
+// @Success 200 {object} response.Response{data=response.PostListResponse}
+// @Router /api/v1/tags/posts [post]
+func TagPosts(c *gin.Context) {
+	var req request.ListTagPostsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	list, info, err := service.ListTagPosts(req.Tag, req.CursorPage, req.SortOrder, userUUID)
+	if err != nil {
+		response.FailWithMessage("Failed to retrieve posts: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(response.PostListResponse{
+		PageInfo: info,
+		List:     list,
+	}, c)
+}
+
+// TrendingTags List trending tags
+// @Summary List tags with the most authors posting in the last N days
+// @Tags Tags
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.TrendingTagsRequest true "Time window in days + limit"
+// @Success 200 {object} response.Response{data=[]response.TrendingTag}
+// @Router /api/v1/tags/trending [post]
+func TrendingTags(c *gin.Context) {
+	var req request.TrendingTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+
+	list, err := service.TrendingTags(req.Days, req.Limit)
+	if err != nil {
+		response.FailWithMessage("Failed to retrieve trending tags: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(list, c)
+}
+
+// FollowTag Follow a tag
+// @Summary Follow a tag; its posts then appear in the following feed
+// @Tags Tags
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.TagRequest true "Tag name"
+// @Success 200 {object} response.Response
+// @Router /api/v1/tags/follow [post]
+func FollowTag(c *gin.Context) {
+	var req request.TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	if err := service.FollowTag(userUUID, req.Tag); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("Tag followed", c)
+}
+
+// UnfollowTag Unfollow a tag
+// @Summary Unfollow a tag
+// @Tags Tags
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.TagRequest true "Tag name"
+// @Success 200 {object} response.Response
+// @Router /api/v1/tags/unfollow [post]
+func UnfollowTag(c *gin.Context) {
+	var req request.TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	if err := service.UnfollowTag(userUUID, req.Tag); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("Tag unfollowed", c)
+}
+
+// FollowedTags List tags I follow
+// @Summary List tags the current user follows
+// @Tags Tags
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} response.Response{data=[]response.TagInfo}
+// @Router /api/v1/tags/following [get]
+func FollowedTags(c *gin.Context) {
+	userUUID := c.MustGet("uuid").(string)
+
+	list, err := service.ListFollowedTags(userUUID)
+	if err != nil {
+		response.FailWithMessage("Failed to retrieve tags: "+err.Error(), c)
+		return
+	}
+	response.OkWithData(list, c)
+}
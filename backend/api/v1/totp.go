@@ -0,0 +1,131 @@
+package v1
+
+import (
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupTOTP Start two-factor enrollment
+// @Summary Generate a TOTP secret
+// @Description Returns a new secret and an otpauth:// provisioning URI to render as a QR code.
+// @Description The secret only takes effect after it is confirmed via /user/2fa/enable.
+// @Tags Profile
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} response.Response{data=service.TOTPSetupResult}
+// @Router /api/v1/user/2fa/setup [post]
+func SetupTOTP(c *gin.Context) {
+	userUUID := c.MustGet("uuid").(string)
+
+	result, err := service.SetupTOTP(userUUID)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(result, c)
+}
+
+// EnableTOTP Confirm two-factor enrollment
+// @Summary Enable two-factor authentication
+// @Description Confirms the secret with a code from the authenticator app and returns one-time recovery codes.
+// @Description Recovery codes are only shown once.
+// @Tags Profile
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.TOTPCodeRequest true "Authenticator code"
+// @Success 200 {object} response.Response{data=[]string}
+// @Router /api/v1/user/2fa/enable [post]
+func EnableTOTP(c *gin.Context) {
+	var req request.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	codes, err := service.EnableTOTP(userUUID, req.Code)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithDetailed(codes, "Two-factor authentication enabled", c)
+}
+
+// DisableTOTP Turn off two-factor authentication
+// @Summary Disable two-factor authentication
+// @Tags Profile
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.TOTPCodeRequest true "Authenticator code or recovery code"
+// @Success 200 {object} response.Response
+// @Router /api/v1/user/2fa/disable [post]
+func DisableTOTP(c *gin.Context) {
+	var req request.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	if err := service.DisableTOTP(userUUID, req.Code); err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithMessage("Two-factor authentication disabled", c)
+}
+
+// RegenerateRecoveryCodes Replace recovery codes
+// @Summary Regenerate recovery codes
+// @Description Invalidates all previous recovery codes and returns a new set.
+// @Tags Profile
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param data body request.TOTPCodeRequest true "Authenticator code or recovery code"
+// @Success 200 {object} response.Response{data=[]string}
+// @Router /api/v1/user/2fa/recovery_codes [post]
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var req request.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+	userUUID := c.MustGet("uuid").(string)
+
+	codes, err := service.RegenerateRecoveryCodes(userUUID, req.Code)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(codes, c)
+}
+
+// TwoFactorLogin Second login step
+// @Summary Complete login with a TOTP code
+// @Description When email/OAuth login returns two_factor_required, post the pre_auth_token together with
+// @Description an authenticator code (or a recovery code) to obtain the real login token.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param data body request.TwoFactorLoginRequest true "Pre-auth token + code"
+// @Success 200 {object} response.Response{data=service.AuthResult}
+// @Router /api/v1/auth/2fa/verify [post]
+func TwoFactorLogin(c *gin.Context) {
+	var req request.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.FailWithMessage("Invalid parameters: "+err.Error(), c)
+		return
+	}
+
+	result, err := service.CompleteTwoFactorLogin(req.PreAuthToken, req.Code)
+	if err != nil {
+		response.FailWithMessage(err.Error(), c)
+		return
+	}
+	response.OkWithData(result, c)
+}
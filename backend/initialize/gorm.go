@@ -26,6 +26,8 @@ func InitMySQL() {
 		panic(fmt.Errorf("数据库出问题啦: %s \n", err))
 	}
 	// 迁移
+	dedupeRelations()
+	prepareAcademicEmails()
 	global.DB.AutoMigrate(
 		&database.User{},
 		&database.AuthAccount{},
@@ -38,7 +40,30 @@ func InitMySQL() {
 		&database.CommentLike{},
 		&database.MatchResult{},
 		&database.ChatMessage{},
+		&database.ModerationLog{},
+		&database.RecoveryCode{},
+		&database.APIKey{},
+		&database.SearchDocument{},
+		&database.PostEmbedding{},
+		&database.Tag{},
+		&database.PostTag{},
+		&database.UserTag{},
+		&database.TagFollow{},
+		&database.PostRevision{},
+		&database.Attachment{},
+		&database.PostView{},
+		&database.PostDailyStat{},
+		&database.UserDailyStat{},
+		&database.Poll{},
+		&database.PollOption{},
+		&database.PollVote{},
+		&database.Mention{},
+		&database.Notification{},
 	)
+	// AutoMigrate 不会修改已有列, 早期建表时邮箱列只有 32 个字符, 放不下较长的机构邮箱
+	if err := global.DB.Model(&database.VerifyCode{}).ModifyColumn("email", "varchar(255)").Error; err != nil {
+		panic(fmt.Errorf("修改验证码邮箱列失败: %s", err))
+	}
 	// 检查数据库连接是否存在, 好像没啥用
 	err = global.DB.DB().Ping()
 	if err != nil {
@@ -46,6 +71,48 @@ func InitMySQL() {
 	}
 }
 
+// dedupeRelations 点赞、收藏关系表加唯一索引之前, 清理历史上重复点赞 / 收藏留下的多余记录
+// 每组 (user, 目标) 只保留一条: 优先保留有效记录, 其次保留最早的记录; 计数由定时对账任务修正
+func dedupeRelations() {
+	relations := []struct{ table, key string }{
+		{"user_post_likes", "post_id"},
+		{"user_post_favorites", "post_id"},
+		{"comment_likes", "comment_id"},
+	}
+	for _, r := range relations {
+		if !global.DB.HasTable(r.table) {
+			continue
+		}
+		if err := global.DB.Exec(fmt.Sprintf(`DELETE a FROM %[1]s a JOIN %[1]s b ON a.user_id = b.user_id AND a.%[2]s = b.%[2]s
+			WHERE (a.deleted_at IS NOT NULL AND b.deleted_at IS NULL)
+			OR ((a.deleted_at IS NULL) = (b.deleted_at IS NULL) AND a.id > b.id)`, r.table, r.key)).Error; err != nil {
+			panic(fmt.Errorf("清理 %s 重复记录失败: %s", r.table, err))
+		}
+	}
+}
+
+// prepareAcademicEmails 机构邮箱加唯一索引之前, 未认证账号的空字符串改为 NULL
+// 历史上同一邮箱认证过多个账号的, 只保留最早认证的账号, 其余账号的机构邮箱置空
+func prepareAcademicEmails() {
+	if !global.DB.HasTable(&database.User{}) {
+		return
+	}
+	if err := global.DB.Exec("UPDATE users SET academic_email = NULL WHERE academic_email = ''").Error; err != nil {
+		panic(fmt.Errorf("清理空机构邮箱失败: %s", err))
+	}
+	if err := global.DB.Exec(`UPDATE users a JOIN users b ON a.academic_email = b.academic_email
+		AND (COALESCE(a.verified_at, a.created_at), a.uuid) > (COALESCE(b.verified_at, b.created_at), b.uuid)
+		SET a.academic_email = NULL`).Error; err != nil {
+		panic(fmt.Errorf("清理重复机构邮箱失败: %s", err))
+	}
+	// 原来的普通索引由唯一索引代替
+	if global.DB.Dialect().HasIndex("users", "idx_users_academic_email") {
+		if err := global.DB.Model(&database.User{}).RemoveIndex("idx_users_academic_email").Error; err != nil {
+			panic(fmt.Errorf("删除机构邮箱索引失败: %s", err))
+		}
+	}
+}
+
 func CloseMySQL() {
 	err := global.DB.Close()
 	if err != nil {
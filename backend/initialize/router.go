@@ -4,6 +4,7 @@ import (
 	v1 "OpenHouse/api/v1"
 	"OpenHouse/docs"
 	"OpenHouse/middleware"
+	"OpenHouse/utils"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -34,11 +35,13 @@ func SetupRouter(r *gin.Engine) {
 			auth.GET("/email/academic_check", v1.CheckEmailDomain)
 			auth.GET("/github/callback", v1.GitHubCallback)
 			auth.GET("/google/callback", v1.GoogleCallback)
+			auth.POST("/2fa/verify", v1.TwoFactorLogin) // 两步验证登录第二步
 		}
 
 		media := apiV1.Group("/media").Use(middleware.JWTAuthMiddleware())
 		{
 			media.POST("/upload", v1.UploadFile)
+			media.POST("/attachment", v1.UploadAttachment) // 帖子附件: 图片 / PDF / 数据集
 		}
 
 		userPublic := apiV1.Group("/user")
@@ -46,6 +49,24 @@ func SetupRouter(r *gin.Engine) {
 			userPublic.GET("/:uuid", v1.GetUserInfo) // 获取用户信息
 		}
 
+		// 公开只读接口, 登录可选; 未登录时只能看到公开帖子
+		public := apiV1.Group("/public").Use(middleware.OptionalAuthMiddleware())
+		{
+			public.POST("/posts", v1.PublicListPosts)
+			public.POST("/posts/detail", v1.PublicPostDetail)
+			public.POST("/user/posts", v1.PublicUserPosts)
+			public.POST("/comments", v1.PublicComments)
+			public.POST("/replies", v1.PublicReplies)
+		}
+
+		// 订阅源, 只包含公开帖子, 不需要登录
+		feeds := apiV1.Group("/feeds")
+		{
+			feeds.GET("/hot", v1.HotFeed)
+			feeds.GET("/user/:uuid", v1.UserFeed)
+			feeds.GET("/tag/:name", v1.TagFeed)
+		}
+
 		user := apiV1.Group("/user").Use(middleware.JWTAuthMiddleware())
 		{
 			user.GET("/profile", v1.GetProfile)
@@ -53,6 +74,21 @@ func SetupRouter(r *gin.Engine) {
 			// user.POST("/bind/github", v1.BindGitHub)
 			// user.POST("/bind/google", v1.BindGoogle)
 			// user.POST("/bind/email", v1.BindEmail)
+			user.POST("/unbind", v1.UnbindAccount) // 解绑登录方式
+			user.POST("/merge", v1.MergeAccount)   // 合并 duplicate_bind 的账号
+			user.POST("/verify/academic/send", v1.SendAcademicVerify)
+			user.POST("/verify/academic/confirm", v1.ConfirmAcademicVerify)
+			user.POST("/2fa/setup", v1.SetupTOTP)
+			user.POST("/2fa/enable", v1.EnableTOTP)
+			user.POST("/2fa/disable", v1.DisableTOTP)
+			user.POST("/2fa/recovery_codes", v1.RegenerateRecoveryCodes)
+			user.GET("/apikeys", v1.ListAPIKeys) // 个人访问令牌, 仅能通过登录 token 管理
+			user.POST("/apikeys/create", v1.CreateAPIKey)
+			user.POST("/apikeys/revoke", v1.RevokeAPIKey)
+			user.GET("/export", v1.ExportUserData) // 导出个人数据
+			user.POST("/delete", v1.DeleteAccount) // 申请注销, 宽限期后删除
+			user.POST("/delete/cancel", v1.CancelDeleteAccount)
+			user.GET("/delete/status", v1.DeleteAccountStatus)
 			user.POST("/follow", v1.FollowUser)
 			user.POST("/unfollow", v1.UnfollowUser)
 			user.POST("/following", v1.FollowedList)
@@ -60,6 +96,7 @@ func SetupRouter(r *gin.Engine) {
 			user.POST("/follow/count", v1.FollowCount)
 			user.POST("/follow/status", v1.FollowStatus)
 			user.POST("/following/posts", v1.FollowedPosts)
+			user.POST("/analytics", v1.AuthorAnalytics) // 作者数据看板
 		}
 
 		postsAuth := apiV1.Group("/posts").Use(middleware.JWTAuthMiddleware())
@@ -75,6 +112,17 @@ func SetupRouter(r *gin.Engine) {
 			postsAuth.POST("/unstar", v1.UnstarPost)
 			postsAuth.POST("/list", v1.ListPosts)
 			postsAuth.POST("/detail", v1.PostDetail)
+			postsAuth.POST("/draft/save", v1.SaveDraft) // 草稿自动保存
+			postsAuth.POST("/drafts", v1.ListDrafts)    // 我的草稿 / 定时发布
+			postsAuth.POST("/publish", v1.PublishPost)  // 发布草稿或设置定时发布
+			postsAuth.POST("/schedule/cancel", v1.CancelSchedule)
+			postsAuth.POST("/revisions", v1.PostRevisions)         // 修改历史
+			postsAuth.POST("/revisions/diff", v1.PostRevisionDiff) // 比较两个版本
+			postsAuth.POST("/download", v1.DownloadAttachment)     // 下载附件并计数
+			postsAuth.POST("/views/stats", v1.PostViewStats)       // 浏览统计（作者）
+			postsAuth.POST("/repost", v1.RepostPost)               // 转发 / 引用
+			postsAuth.POST("/unrepost", v1.UndoRepost)
+			postsAuth.POST("/poll/vote", v1.VotePoll)
 		}
 
 		commentsAuth := apiV1.Group("/comments").Use(middleware.JWTAuthMiddleware())
@@ -103,10 +151,44 @@ func SetupRouter(r *gin.Engine) {
 			chat.GET("/history", v1.GetChatHistoryPaged) // 获取历史消息记录
 		}
 
-		// matchTest := apiV1.Group("/match")
-		// {
-		// 	matchTest.POST("/trigger", v1.MatchTrigger) // 直接触发匹配计算
-		// }
+		tags := apiV1.Group("/tags").Use(middleware.JWTAuthMiddleware())
+		{
+			tags.POST("/detail", v1.TagDetail)
+			tags.POST("/posts", v1.TagPosts)        // 标签页帖子列表
+			tags.POST("/trending", v1.TrendingTags) // 热门标签
+			tags.POST("/follow", v1.FollowTag)      // 关注标签, 帖子进入关注流
+			tags.POST("/unfollow", v1.UnfollowTag)
+			tags.GET("/following", v1.FollowedTags)
+		}
+
+		// 站内通知, 目前只有在帖子和评论中被 @
+		notifications := apiV1.Group("/notifications").Use(middleware.JWTAuthMiddleware())
+		{
+			notifications.POST("/list", v1.ListNotifications)
+			notifications.POST("/read", v1.ReadNotifications)
+		}
+
+		searchAuth := apiV1.Group("/search").Use(middleware.JWTAuthMiddleware())
+		{
+			searchAuth.POST("", v1.Search)                  // 全文搜索帖子 / 用户 / 评论
+			searchAuth.POST("/semantic", v1.SemanticSearch) // 按语义相似度搜索帖子
+		}
+
+		// 管理接口, 在 JWT 认证之后按权限点校验
+		admin := apiV1.Group("/admin").Use(middleware.JWTAuthMiddleware())
+		{
+			admin.POST("/users/search", middleware.RequirePermission(utils.PermUserRead), v1.AdminSearchUsers)
+			admin.GET("/users/:uuid", middleware.RequirePermission(utils.PermUserRead), v1.AdminGetUser)
+			admin.POST("/users/ban", middleware.RequirePermission(utils.PermUserBan), v1.AdminBanUser)
+			admin.POST("/users/unban", middleware.RequirePermission(utils.PermUserBan), v1.AdminUnbanUser)
+			admin.POST("/users/role", middleware.RequirePermission(utils.PermUserRole), v1.AdminSetUserRole)
+			admin.POST("/match/trigger", middleware.RequirePermission(utils.PermMatchTrigger), v1.MatchTrigger) // 直接触发全量匹配计算
+			admin.POST("/posts/takedown", middleware.RequirePermission(utils.PermContentModerate), v1.AdminTakedownPost)
+			admin.POST("/comments/takedown", middleware.RequirePermission(utils.PermContentModerate), v1.AdminTakedownComment)
+			admin.POST("/logs", middleware.RequirePermission(utils.PermUserRead), v1.AdminModerationLogs)
+			admin.GET("/config", middleware.RequirePermission(utils.PermConfigRead), v1.AdminGetConfig)
+			admin.POST("/search/reindex", middleware.RequirePermission(utils.PermSearchReindex), v1.AdminRebuildSearchIndex)
+		}
 	}
 }
 
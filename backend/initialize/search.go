@@ -0,0 +1,25 @@
+package initialize
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/search"
+	"OpenHouse/service"
+	"log"
+)
+
+// InitSearch 初始化搜索索引, search.driver 可选 mysql（默认）/ memory
+// memory 索引不持久化, 启动时从数据库全量构建
+func InitSearch() {
+	driver := global.VP.GetString("search.driver")
+	search.Init(driver)
+
+	if driver == "memory" {
+		if err := service.RebuildSearchIndex(); err != nil {
+			log.Println("[Search] 构建搜索索引失败:", err)
+		}
+		return
+	}
+	if err := search.EnsureFulltextIndex(); err != nil {
+		log.Println("[Search] 创建全文索引失败:", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"OpenHouse/global"
 	"OpenHouse/initialize"
 	"OpenHouse/schedule"
+	"OpenHouse/service"
 	"log"
 
 	"github.com/gin-gonic/gin"
@@ -19,6 +20,10 @@ func main() {
 
 	initialize.InitMySQL()
 	defer initialize.CloseMySQL()
+	service.EnsureAdmins()
+	service.SyncUserTags()
+	service.SyncUsernameKeys()
+	initialize.InitSearch()
 
 	initialize.InitMedia()
 	schedule.StartCronJobs()
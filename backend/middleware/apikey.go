@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"OpenHouse/utils"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyWindow 按 API Key 计数的固定窗口限流, 窗口为 1 分钟
+var apiKeyWindow = struct {
+	sync.Mutex
+	start map[uint]time.Time
+	count map[uint]int
+}{start: map[uint]time.Time{}, count: map[uint]int{}}
+
+// allowAPIKeyRequest 判断当前窗口内是否还能继续请求
+func allowAPIKeyRequest(keyID uint, limit int) bool {
+	apiKeyWindow.Lock()
+	defer apiKeyWindow.Unlock()
+
+	now := time.Now()
+	if now.Sub(apiKeyWindow.start[keyID]) >= time.Minute {
+		apiKeyWindow.start[keyID] = now
+		apiKeyWindow.count[keyID] = 0
+	}
+	if apiKeyWindow.count[keyID] >= limit {
+		return false
+	}
+	apiKeyWindow.count[keyID]++
+	return true
+}
+
+// authenticateAPIKey 校验个人访问令牌: 是否存在、未撤销、未过期、权限范围、限流
+// 通过后与 JWT 一样把 uuid 放入上下文, 另外放入 api_key_id 供需要区分的接口使用
+func authenticateAPIKey(c *gin.Context, key string) bool {
+	scope, allowed := utils.RequiredScope(c.Request.Method, c.FullPath())
+	if !allowed {
+		c.AbortWithStatusJSON(403, gin.H{"message": "该接口不支持 API Key 访问"})
+		return false
+	}
+
+	var apiKey database.APIKey
+	if err := global.DB.Where("key_hash = ?", utils.HashAPIKey(key)).First(&apiKey).Error; err != nil {
+		c.AbortWithStatusJSON(401, gin.H{"message": "无效 API Key"})
+		return false
+	}
+	now := time.Now()
+	if apiKey.RevokedAt != nil {
+		c.AbortWithStatusJSON(401, gin.H{"message": "API Key 已撤销"})
+		return false
+	}
+	if apiKey.ExpiresAt != nil && now.After(*apiKey.ExpiresAt) {
+		c.AbortWithStatusJSON(401, gin.H{"message": "API Key 已过期"})
+		return false
+	}
+	if !utils.HasScope(apiKey.Scopes, scope) {
+		c.AbortWithStatusJSON(403, gin.H{"message": "API Key 缺少权限: " + scope})
+		return false
+	}
+	if !checkUserActive(c, apiKey.UserUUID) {
+		return false
+	}
+	if !allowAPIKeyRequest(apiKey.ID, apiKey.RateLimit) {
+		c.AbortWithStatusJSON(429, gin.H{"message": "请求过于频繁"})
+		return false
+	}
+
+	// 最近使用时间精确到分钟即可, 避免每次请求都写库
+	global.DB.Model(&database.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", apiKey.ID, now.Add(-time.Minute)).
+		Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": c.ClientIP()})
+
+	c.Set("uuid", apiKey.UserUUID)
+	c.Set("api_key_id", apiKey.ID)
+	return true
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"OpenHouse/global"
+	"OpenHouse/utils"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -11,6 +12,7 @@ import (
 // JWTAuthMiddleware JWT认证中间件, 用于验证用户的JWT token
 // 如果token有效, 则将用户的uuid放入上下文中
 // 如果token无效, 则返回401状态码
+// 以 oh_ 开头的是个人访问令牌 (API Key), 按接口所需权限范围校验
 func JWTAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString := c.GetHeader("Authorization")
@@ -22,6 +24,13 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 		// 去掉 Bearer 前缀
 		tokenString = strings.TrimPrefix(tokenString, "Bearer ")
 
+		if strings.HasPrefix(tokenString, utils.APIKeyPrefix) {
+			if authenticateAPIKey(c, tokenString) {
+				c.Next()
+			}
+			return
+		}
+
 		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 			return []byte(global.VP.GetString("jwt.secret")), nil
 		})
@@ -36,14 +45,35 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// 提取uuid放到上下文
-		uuid := claims["uuid"].(string)
+		// 提取uuid放到上下文, 合并凭证等非登录 token 没有 uuid
+		uuid, ok := claims["uuid"].(string)
+		if !ok || uuid == "" {
+			c.AbortWithStatusJSON(401, gin.H{"message": "无效token"})
+			return
+		}
+		// 被封禁的用户 token 立即失效
+		if !checkUserActive(c, uuid) {
+			return
+		}
 		c.Set("uuid", uuid)
 
 		c.Next()
 	}
 }
 
+// OptionalAuthMiddleware 可选登录, 用于公开的只读接口
+// 没有 Authorization 头时按匿名访问继续（上下文中不设置 uuid）, 有则和 JWTAuthMiddleware 一样校验
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	auth := JWTAuthMiddleware()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
 // JWTAuthMiddlewareOptional JWT认证中间件, 用于验证用户的JWT token
 // 这种token是可选的, 用户在url中传入token, 例如：http://localhost:8080/api/v1/user?state=xxx
 func JWTAuthMiddlewareOptional() gin.HandlerFunc {
@@ -68,7 +98,11 @@ func JWTAuthMiddlewareOptional() gin.HandlerFunc {
 			return
 		}
 
-		uuid := claims["uuid"].(string)
+		uuid, ok := claims["uuid"].(string)
+		if !ok || uuid == "" {
+			c.AbortWithStatusJSON(401, gin.H{"message": "无效token"})
+			return
+		}
 		c.Set("uuid", uuid)
 
 		c.Next()
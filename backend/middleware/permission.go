@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"OpenHouse/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission 权限校验中间件, 需放在 JWTAuthMiddleware 之后
+// 根据用户角色及单独授予的权限判断是否允许访问, 无权限返回403
+func RequirePermission(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uuid := c.GetString("uuid")
+		if uuid == "" {
+			c.AbortWithStatusJSON(401, gin.H{"message": "未登录"})
+			return
+		}
+
+		var user database.User
+		if err := global.DB.Select("uuid, role, permissions, is_banned").
+			Where("uuid = ?", uuid).First(&user).Error; err != nil {
+			c.AbortWithStatusJSON(401, gin.H{"message": "用户不存在"})
+			return
+		}
+		if user.IsBanned {
+			c.AbortWithStatusJSON(403, gin.H{"message": "账号已被封禁"})
+			return
+		}
+		if !utils.HasPermission(user.Role, user.Permissions, perm) {
+			c.AbortWithStatusJSON(403, gin.H{"message": "没有权限"})
+			return
+		}
+
+		c.Set("role", user.Role)
+		c.Next()
+	}
+}
+
+// checkUserActive 检查用户是否存在且未被封禁, 否则中断请求
+func checkUserActive(c *gin.Context, uuid string) bool {
+	var user database.User
+	if err := global.DB.Select("uuid, is_banned").Where("uuid = ?", uuid).First(&user).Error; err != nil {
+		c.AbortWithStatusJSON(401, gin.H{"message": "用户不存在"})
+		return false
+	}
+	if user.IsBanned {
+		c.AbortWithStatusJSON(403, gin.H{"message": "账号已被封禁"})
+		return false
+	}
+	return true
+}
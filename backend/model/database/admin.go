@@ -0,0 +1,14 @@
+package database
+
+import "time"
+
+// ModerationLog 管理操作记录（封禁、下架、角色变更等）
+type ModerationLog struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ActorUUID  string    `gorm:"type:char(36);index;not null" json:"actor_uuid"` // 操作人
+	Action     string    `gorm:"type:varchar(50);not null" json:"action"`        // ban / unban / set_role / takedown_post / takedown_comment
+	TargetType string    `gorm:"type:varchar(20);not null" json:"target_type"`   // user / post / comment
+	TargetID   string    `gorm:"type:varchar(36);index;not null" json:"target_id"`
+	Reason     string    `gorm:"type:varchar(500)" json:"reason"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package database
+
+// PostDailyStat 帖子每日数据汇总, 由定时任务从浏览、点赞、收藏、评论记录汇总而来
+type PostDailyStat struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	PostID     uint   `gorm:"not null;unique_index:idx_post_daily_stat" json:"post_id"`
+	Date       string `gorm:"type:char(10);not null;unique_index:idx_post_daily_stat;index" json:"date"` // 2006-01-02
+	AuthorUUID string `gorm:"type:char(36);not null;index" json:"author_uuid"`
+	Views      int64  `gorm:"default:0" json:"views"`
+	Likes      int64  `gorm:"default:0" json:"likes"`
+	Favorites  int64  `gorm:"default:0" json:"favorites"`
+	Comments   int64  `gorm:"default:0" json:"comments"` // 含楼中楼回复
+}
+
+// UserDailyStat 用户每日新增 / 流失的关注者
+type UserDailyStat struct {
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	UserUUID      string `gorm:"type:char(36);not null;unique_index:idx_user_daily_stat" json:"user_uuid"`
+	Date          string `gorm:"type:char(10);not null;unique_index:idx_user_daily_stat;index" json:"date"`
+	NewFollowers  int64  `gorm:"default:0" json:"new_followers"`
+	LostFollowers int64  `gorm:"default:0" json:"lost_followers"`
+}
@@ -0,0 +1,23 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// APIKey 个人访问令牌, 供脚本 / 机器人调用接口
+type APIKey struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	UserUUID   string         `gorm:"type:varchar(36);index" json:"user_uuid"`
+	Name       string         `gorm:"type:varchar(50)" json:"name"`
+	Prefix     string         `gorm:"type:varchar(16)" json:"prefix"`                          // 明文前几位, 便于用户辨认
+	KeyHash    string         `gorm:"type:varchar(64);unique_index:idx_api_key_hash" json:"-"` // sha256(明文)
+	Scopes     datatypes.JSON `json:"scopes"`
+	RateLimit  int            `gorm:"default:60" json:"rate_limit"` // 每分钟请求上限
+	LastUsedAt *time.Time     `json:"last_used_at"`
+	LastUsedIP string         `gorm:"type:varchar(64)" json:"last_used_ip"`
+	ExpiresAt  *time.Time     `json:"expires_at"`
+	RevokedAt  *time.Time     `json:"revoked_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
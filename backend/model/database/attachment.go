@@ -0,0 +1,33 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 附件类型
+const (
+	AttachmentImage   = "image"
+	AttachmentPDF     = "pdf"
+	AttachmentArchive = "archive" // 压缩包、CSV 等小型数据集
+	AttachmentLink    = "link"    // 外部链接, 不上传文件
+)
+
+// Attachment 帖子附件; 先上传得到 ID, 发帖 / 修改时再关联到帖子, PostID 为 0 表示尚未关联
+type Attachment struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	UploaderUUID  string         `gorm:"type:char(36);not null;index" json:"uploader_uuid"`
+	PostID        uint           `gorm:"default:0;index" json:"post_id"`
+	Position      int            `gorm:"default:0" json:"position"` // 在帖子中的顺序
+	Kind          string         `gorm:"type:varchar(16);not null" json:"kind"`
+	URL           string         `gorm:"type:varchar(512);not null" json:"url"`
+	FileName      string         `gorm:"type:varchar(255)" json:"file_name"` // 原始文件名, 链接为标题
+	MimeType      string         `gorm:"type:varchar(100)" json:"mime_type"`
+	Bytes         int64          `gorm:"default:0" json:"bytes"`
+	PageCount     int            `gorm:"default:0" json:"page_count"` // PDF 页数, 无法识别时为 0
+	ThumbnailURL  string         `gorm:"type:varchar(512)" json:"thumbnail_url"`
+	DownloadCount int            `gorm:"default:0" json:"download_count"`
+	CreatedAt     time.Time      `json:"created_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
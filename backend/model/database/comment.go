@@ -20,7 +20,7 @@ type PostComment struct {
 
 type CommentLike struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
-	UserID    string         `gorm:"type:char(36);index;not null" json:"user_id"`
-	CommentID uint           `gorm:"index;not null" json:"comment_id"`
+	UserID    string         `gorm:"type:char(36);not null;unique_index:idx_user_comment_like" json:"user_id"`
+	CommentID uint           `gorm:"index;not null;unique_index:idx_user_comment_like" json:"comment_id"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
@@ -0,0 +1,13 @@
+package database
+
+import "time"
+
+// PostEmbedding 帖子的向量表示, 用于语义搜索和相似帖子推荐
+type PostEmbedding struct {
+	PostID      uint      `gorm:"primary_key;auto_increment:false" json:"post_id"`
+	Model       string    `gorm:"type:varchar(64);index" json:"model"` // 生成向量的模型, 不同模型的向量不能比较
+	Dim         int       `json:"dim"`
+	Vector      []byte    `gorm:"type:mediumblob" json:"-"`          // float32 小端序
+	ContentHash string    `gorm:"type:char(64)" json:"content_hash"` // 标题+正文的哈希, 内容未变时跳过重新计算
+	UpdatedAt   time.Time `json:"updated_at"`
+}
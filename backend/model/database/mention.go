@@ -0,0 +1,31 @@
+package database
+
+import "time"
+
+// Mention 帖子或评论正文中的 @提及, CommentID 为 0 表示帖子正文
+// 正文修改后按新内容整体重建
+type Mention struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	PostID    uint      `gorm:"not null;unique_index:idx_mention" json:"post_id"`
+	CommentID uint      `gorm:"not null;default:0;unique_index:idx_mention" json:"comment_id"`
+	UserUUID  string    `gorm:"type:char(36);not null;unique_index:idx_mention;index" json:"user_uuid"` // 被提及的用户
+	Text      string    `gorm:"type:varchar(64);not null" json:"text"`                                  // 正文中的写法, 不含 @
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// 通知类型
+const (
+	NotificationMention = "mention" // 在帖子或评论中被 @
+)
+
+// Notification 站内通知, ActorUUID 为触发通知的用户
+type Notification struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserUUID  string    `gorm:"type:char(36);not null;index:idx_notification_user" json:"user_uuid"`
+	ActorUUID string    `gorm:"type:char(36);not null;index" json:"actor_uuid"`
+	Type      string    `gorm:"type:varchar(16);not null" json:"type"`
+	PostID    uint      `gorm:"not null;index" json:"post_id"`
+	CommentID uint      `gorm:"not null;default:0" json:"comment_id"`
+	IsRead    bool      `gorm:"default:false;index:idx_notification_user" json:"is_read"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,31 @@
+package database
+
+import "time"
+
+// Poll 帖子中的投票, 每个帖子最多一个
+type Poll struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	PostID     uint       `gorm:"not null;unique_index:idx_post_poll" json:"post_id"`
+	Multiple   bool       `gorm:"default:false" json:"multiple"` // 是否多选
+	ClosesAt   *time.Time `json:"closes_at"`                     // 截止时间, 为空表示不截止
+	VoterCount int        `gorm:"default:0" json:"voter_count"`  // 参与投票的人数
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// PollOption 投票选项, VoteCount 随投票在事务中更新
+type PollOption struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	PostID    uint   `gorm:"not null;index" json:"post_id"`
+	Position  int    `gorm:"default:0" json:"position"`
+	Text      string `gorm:"type:varchar(100);not null" json:"text"`
+	VoteCount int    `gorm:"default:0" json:"vote_count"`
+}
+
+// PollVote 用户的投票, 同一用户对同一选项只能投一次; 多选时每个选项一条
+type PollVote struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	PostID    uint      `gorm:"not null;index:idx_poll_voter" json:"post_id"`
+	UserUUID  string    `gorm:"type:char(36);not null;index:idx_poll_voter;unique_index:idx_poll_vote" json:"user_uuid"`
+	OptionID  uint      `gorm:"not null;unique_index:idx_poll_vote" json:"option_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -7,6 +7,22 @@ import (
 	"gorm.io/gorm"
 )
 
+// 帖子状态
+const (
+	PostStatusDraft     = "draft"     // 草稿, 仅作者可见
+	PostStatusScheduled = "scheduled" // 定时发布, 到 PublishAt 后由定时任务发布
+	PostStatusPublished = "published"
+)
+
+// 帖子可见范围, 作者本人始终可见
+const (
+	PostVisibilityPublic    = "public"    // 所有登录用户
+	PostVisibilityFollowers = "followers" // 关注了作者的用户
+	PostVisibilityMutual    = "mutual"    // 与作者互相关注的用户
+	PostVisibilityMatched   = "matched"   // 与作者匹配过的用户
+	PostVisibilityPrivate   = "private"   // 仅作者
+)
+
 type Post struct {
 	ID             uint           `gorm:"primaryKey" json:"id"`
 	Title          string         `gorm:"type:varchar(100);not null" json:"title"`
@@ -18,14 +34,23 @@ type Post struct {
 	FavoriteNumber int            `gorm:"default:0" json:"favorite_number"`
 	ViewNumber     int            `gorm:"default:0" json:"view_number"`
 	CommentNumber  int            `gorm:"default:0" json:"comment_number"`
+	RepostNumber   int            `gorm:"default:0" json:"repost_number"`
+	HotScore       float64        `gorm:"default:0;index" json:"-"` // 热度分, 由定时任务刷新
+	Status         string         `gorm:"type:varchar(16);default:'published';index" json:"status"`
+	PublishAt      *time.Time     `gorm:"index" json:"publish_at"` // 定时发布时间, 仅 scheduled 状态有效
+	EditedAt       *time.Time     `json:"edited_at"`               // 发布后最后一次修改的时间, 未修改过为空
+	Visibility     string         `gorm:"type:varchar(16);default:'public';index" json:"visibility"`
 	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+
+	RepostOfID *uint `gorm:"index" json:"repost_of_id"` // 转发 / 引用的帖子; 正文为空的是纯转发, 否则是引用
 }
 
 // UserPostLike 用户点赞帖子表
 type UserPostLike struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
-	UserID    string         `gorm:"type:char(36);not null;index" json:"user_id"`
-	PostID    uint           `gorm:"not null;index" json:"post_id"`
+	UserID    string         `gorm:"type:char(36);not null;unique_index:idx_user_post_like" json:"user_id"`
+	PostID    uint           `gorm:"not null;index;unique_index:idx_user_post_like" json:"post_id"`
+	CreatedAt *time.Time     `gorm:"index" json:"created_at"` // 点赞时间, 取消后再次点赞时刷新; 早期数据为空
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
@@ -34,13 +59,15 @@ type UserFollow struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
 	UserID    string         `gorm:"type:char(36);not null;index" json:"user_id"`   // 谁关注
 	FollowID  string         `gorm:"type:char(36);not null;index" json:"follow_id"` // 关注谁
+	CreatedAt *time.Time     `gorm:"index" json:"created_at"`                       // 关注时间; 早期数据为空
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // UserPostFavorite 用户收藏帖子表
 type UserPostFavorite struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
-	UserID    string         `gorm:"type:char(36);not null;index" json:"user_id"`
-	PostID    uint           `gorm:"not null;index" json:"post_id"`
+	UserID    string         `gorm:"type:char(36);not null;unique_index:idx_user_post_favorite" json:"user_id"`
+	PostID    uint           `gorm:"not null;index;unique_index:idx_user_post_favorite" json:"post_id"`
+	CreatedAt *time.Time     `gorm:"index" json:"created_at"` // 收藏时间, 取消后再次收藏时刷新; 早期数据为空
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
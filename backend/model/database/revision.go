@@ -0,0 +1,19 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// PostRevision 帖子的历史版本, 每次发布或修改已发布的帖子都保存一份完整内容
+type PostRevision struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	PostID     uint           `gorm:"not null;unique_index:idx_post_revision" json:"post_id"`
+	Version    int            `gorm:"not null;unique_index:idx_post_revision" json:"version"` // 从 1 开始递增
+	Title      string         `gorm:"type:varchar(100)" json:"title"`
+	Content    string         `gorm:"type:text" json:"content"`
+	ImageURLs  datatypes.JSON `gorm:"type:json" json:"image_urls"`
+	EditorUUID string         `gorm:"type:char(36);index" json:"editor_uuid"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package database
+
+import "time"
+
+// SearchDocument 搜索索引表, 帖子 / 用户 / 评论各对应一行
+// title / body / tags 上建有 FULLTEXT(ngram) 索引, 见 initialize.InitSearch
+type SearchDocument struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	DocType    string    `gorm:"type:varchar(20);unique_index:idx_search_doc" json:"doc_type"` // post / user / comment
+	DocID      string    `gorm:"type:varchar(36);unique_index:idx_search_doc" json:"doc_id"`
+	Title      string    `gorm:"type:varchar(255)" json:"title"`
+	Body       string    `gorm:"type:text" json:"body"`
+	Tags       string    `gorm:"type:text" json:"tags"` // 以换行分隔, 首尾各有一个换行, 便于按标签过滤
+	AuthorUUID string    `gorm:"type:char(36);index" json:"author_uuid"`
+	PostID     uint      `gorm:"index" json:"post_id"` // 评论所属帖子
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
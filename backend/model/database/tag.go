@@ -0,0 +1,40 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tag 话题标签, 帖子标签与用户标签共用
+type Tag struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"type:varchar(30);not null" json:"name"`                           // 首次出现时的写法, 用于展示
+	Slug      string    `gorm:"type:varchar(30);not null;unique_index:idx_tag_slug" json:"slug"` // 规范化后的名称（小写、空格换成 -）
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PostTag 帖子与标签的关联, 包括显式标签和正文中的 #话题
+type PostTag struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	PostID    uint      `gorm:"not null;unique_index:idx_post_tag" json:"post_id"`
+	TagID     uint      `gorm:"not null;unique_index:idx_post_tag;index" json:"tag_id"`
+	Explicit  bool      `gorm:"default:false" json:"explicit"` // 作者显式添加的标签; false 表示从 #话题 提取
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserTag 用户资料标签与标签表的关联, 与 User.Tags 同步
+type UserTag struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	UserUUID string `gorm:"type:char(36);not null;unique_index:idx_user_tag" json:"user_uuid"`
+	TagID    uint   `gorm:"not null;unique_index:idx_user_tag;index" json:"tag_id"`
+}
+
+// TagFollow 用户关注的标签
+type TagFollow struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	UserUUID  string         `gorm:"type:char(36);not null;index" json:"user_uuid"`
+	TagID     uint           `gorm:"not null;index" json:"tag_id"`
+	CreatedAt time.Time      `json:"created_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
@@ -0,0 +1,12 @@
+package database
+
+import "time"
+
+// RecoveryCode 两步验证恢复码, 只保存哈希, 每个码只能使用一次
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserUUID  string     `gorm:"type:varchar(36);index" json:"user_uuid"`
+	CodeHash  string     `gorm:"type:varchar(64)" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
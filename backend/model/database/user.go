@@ -7,22 +7,41 @@ import (
 )
 
 type User struct {
-	UUID          string         `gorm:"primaryKey" json:"uuid"`
-	CreatedAt     time.Time      `json:"created_at"`
-	IsVerified    bool           `json:"is_verified"`
-	Username      string         `json:"username"`
-	Email         string         `json:"email"`
-	Gender        string         `json:"gender"`
-	AvatarURL     string         `json:"avatar_url"`
-	IntroShort    string         `json:"intro_short"`
-	IntroLong     string         `json:"intro_long"`
-	Coin          int            `json:"coin"`
-	Tags          datatypes.JSON `json:"tags"`          // Tags存为JSON类型
-	ResearchArea  string         `json:"research_area"` // 研究领域
-	IsEmailBound  bool           `gorm:"default:false" json:"is_email_bound"`
-	IsGitHubBound bool           `gorm:"default:false" json:"is_github_bound"`
-	IsGoogleBound bool           `gorm:"default:false" json:"is_google_bound"`
-	MatchStatus   string         `gorm:"default:'available'" json:"match_status"` // "available" or "matching" or "matched"
+	UUID                string         `gorm:"primaryKey" json:"uuid"`
+	CreatedAt           time.Time      `json:"created_at"`
+	IsVerified          bool           `json:"is_verified"`
+	Username            string         `json:"username"`
+	Email               string         `json:"email"`
+	Gender              string         `json:"gender"`
+	AvatarURL           string         `json:"avatar_url"`
+	IntroShort          string         `json:"intro_short"`
+	IntroLong           string         `json:"intro_long"`
+	Coin                int            `json:"coin"`
+	Tags                datatypes.JSON `json:"tags"`          // Tags存为JSON类型
+	ResearchArea        string         `json:"research_area"` // 研究领域
+	IsEmailBound        bool           `gorm:"default:false" json:"is_email_bound"`
+	IsGitHubBound       bool           `gorm:"default:false" json:"is_github_bound"`
+	IsGoogleBound       bool           `gorm:"default:false" json:"is_google_bound"`
+	MatchStatus         string         `gorm:"default:'available'" json:"match_status"`              // "available" or "matching" or "matched"
+	Institution         string         `json:"institution"`                                          // 学术认证通过后的机构名称
+	AcademicEmail       *string        `gorm:"type:varchar(100);unique_index" json:"academic_email"` // 一个机构邮箱只能认证一个账号, 未认证为空（NULL, 不占用唯一索引）
+	VerifiedAt          *time.Time     `json:"verified_at"`
+	Role                string         `gorm:"type:varchar(20);default:'user'" json:"role"` // user / moderator / admin
+	Permissions         datatypes.JSON `json:"permissions"`                                 // 角色之外单独授予的权限
+	IsBanned            bool           `gorm:"default:false" json:"is_banned"`
+	BanReason           string         `json:"ban_reason"`
+	BannedAt            *time.Time     `json:"banned_at"`
+	TOTPSecret          string         `gorm:"type:varchar(64)" json:"-"` // 两步验证密钥, 不对外返回
+	TOTPEnabled         bool           `gorm:"default:false" json:"totp_enabled"`
+	TOTPLastStep        int64          `json:"-"`                                  // 最近一次通过校验的时间窗口, 防止验证码重放
+	DeletionScheduledAt *time.Time     `gorm:"index" json:"deletion_scheduled_at"` // 申请注销后的彻底删除时间, 为空表示未申请
+
+	// 两步验证码连续输错的次数与锁定截止时间, 登录、关闭两步验证、合并、注销等所有校验共用
+	TOTPFailedAttempts int        `gorm:"default:0" json:"-"`
+	TOTPLockedUntil    *time.Time `json:"-"`
+
+	// 小写的用户名, 保证用户名不区分大小写唯一, @ 提及按它匹配; 与他人重名的早期账号为空, 改名后补上
+	UsernameKey *string `gorm:"type:varchar(64);unique_index" json:"-"`
 }
 
 // AuthAccount 表结构
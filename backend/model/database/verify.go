@@ -4,6 +4,13 @@ import "time"
 
 type VerifyCode struct {
 	Code    string    `gorm:"not null" json:"code"`
-	Email   string    `gorm:"size:32;" json:"email"` //邮箱
+	Email   string    `gorm:"size:255;" json:"email"` //邮箱
 	GenTime time.Time `gorm:"type:datetime" json:"create_time"`
+	Purpose string    `gorm:"size:20;default:''" json:"purpose"` // 空为登录验证码, academic 为学术认证
+
+	// 学术认证验证码绑定申请的账号, 输错次数达到上限后作废
+	UserUUID string `gorm:"type:char(36);index" json:"user_uuid"`
+	Attempts int    `gorm:"default:0" json:"-"`
 }
+
+const VerifyPurposeAcademic = "academic"
@@ -0,0 +1,13 @@
+package database
+
+import "time"
+
+// PostView 帖子浏览记录, 同一访客在去重窗口内的重复浏览只记一次
+// 由定时任务汇总到 Post.ViewNumber, 汇总后 Counted 置为 true
+type PostView struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	PostID    uint      `gorm:"not null;index:idx_post_view_viewer" json:"post_id"`
+	ViewerKey string    `gorm:"type:varchar(64);not null;index:idx_post_view_viewer" json:"viewer_key"` // 登录用户为 UUID, 匿名访客为 anon: + IP/UA 哈希
+	Counted   bool      `gorm:"default:false;index" json:"-"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
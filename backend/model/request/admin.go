@@ -0,0 +1,44 @@
+package request
+
+// AdminUserSearchRequest 按 UUID / 用户名 / 邮箱查询用户
+type AdminUserSearchRequest struct {
+	Keyword  string `json:"keyword"`
+	PageNum  int    `json:"page_num" binding:"required,min=1"`
+	PageSize int    `json:"page_size" binding:"required,min=1,max=50"`
+}
+
+// AdminBanRequest 封禁用户
+type AdminBanRequest struct {
+	UUID   string `json:"uuid" binding:"required"`
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// AdminUnbanRequest 解封用户
+type AdminUnbanRequest struct {
+	UUID string `json:"uuid" binding:"required"`
+}
+
+// AdminSetRoleRequest 修改用户角色及额外权限
+type AdminSetRoleRequest struct {
+	UUID        string   `json:"uuid" binding:"required"`
+	Role        string   `json:"role" binding:"required,oneof=user moderator admin"`
+	Permissions []string `json:"permissions"` // 角色之外单独授予的权限, 例如 match:trigger
+}
+
+// AdminTakedownPostRequest 下架帖子
+type AdminTakedownPostRequest struct {
+	PostID uint   `json:"post_id" binding:"required"`
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// AdminTakedownCommentRequest 下架评论
+type AdminTakedownCommentRequest struct {
+	CommentID uint   `json:"comment_id" binding:"required"`
+	Reason    string `json:"reason" binding:"max=500"`
+}
+
+// AdminLogListRequest 查询管理操作记录
+type AdminLogListRequest struct {
+	PageNum  int `json:"page_num" binding:"required,min=1"`
+	PageSize int `json:"page_size" binding:"required,min=1,max=50"`
+}
@@ -0,0 +1,7 @@
+package request
+
+// AuthorAnalyticsRequest 作者数据看板
+type AuthorAnalyticsRequest struct {
+	Days int `json:"days" binding:"omitempty,min=1,max=90"` // 最近几天, 默认 30
+	Top  int `json:"top" binding:"omitempty,min=1,max=20"`  // 表现最好的帖子数量, 默认 5
+}
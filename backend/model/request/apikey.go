@@ -0,0 +1,14 @@
+package request
+
+// CreateAPIKeyRequest 创建个人访问令牌
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required,max=50"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,required"`     // posts:read / posts:write / chat:read ...
+	RateLimit     int      `json:"rate_limit" binding:"omitempty,min=1,max=600"`      // 每分钟请求上限, 默认 60
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=365"` // 不填则永不过期
+}
+
+// RevokeAPIKeyRequest 撤销个人访问令牌
+type RevokeAPIKeyRequest struct {
+	ID uint `json:"id" binding:"required"`
+}
@@ -0,0 +1,18 @@
+package request
+
+// UploadAttachmentRequest 上传附件（multipart/form-data, 文件字段为 file）
+type UploadAttachmentRequest struct {
+	Kind string `form:"kind" binding:"required,oneof=image pdf archive"`
+}
+
+// AttachmentRef 帖子中的一个附件: 已上传的附件传 attachment_id, 外部链接传 url
+type AttachmentRef struct {
+	AttachmentID uint   `json:"attachment_id"`
+	URL          string `json:"url" binding:"omitempty,url,max=512"`
+	Title        string `json:"title" binding:"max=255"` // 链接标题, 可选
+}
+
+// AttachmentDownloadRequest 下载附件
+type AttachmentDownloadRequest struct {
+	AttachmentID uint `json:"attachment_id" binding:"required"`
+}
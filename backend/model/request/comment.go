@@ -7,10 +7,9 @@ type CreateCommentRequest struct {
 }
 
 type ListCommentRequest struct {
-	PostID   uint   `json:"post_id" binding:"required"`
-	PageNum  int    `json:"page_num" binding:"required,min=1"`
-	PageSize int    `json:"page_size" binding:"required,min=1,max=50"`
-	SortBy   string `json:"sort_by" binding:"omitempty,oneof=time likes"`
+	PostID uint `json:"post_id" binding:"required"`
+	CursorPage
+	SortBy string `json:"sort_by" binding:"omitempty,oneof=time likes"`
 }
 
 type LikeCommentRequest struct {
@@ -18,7 +17,6 @@ type LikeCommentRequest struct {
 }
 
 type ListReplyRequest struct {
-	CommentID uint `json:"comment_id" binding:"required"`             // 父评论 ID
-	PageNum   int  `json:"page_num" binding:"required,min=1"`         // 页码
-	PageSize  int  `json:"page_size" binding:"required,min=1,max=50"` // 每页条数，前端默认每次传 5
+	CommentID  uint `json:"comment_id" binding:"required"` // 父评论 ID
+	CursorPage      // 前端默认每次传 page_size = 5
 }
@@ -6,8 +6,7 @@ type FollowRequest struct {
 }
 
 type FollowListRequest struct {
-	PageNum  int `json:"page_num" binding:"required,min=1"`
-	PageSize int `json:"page_size" binding:"required,min=1,max=50"`
+	CursorPage
 }
 
 // FollowStatusRequest 判断是否关注某用户
@@ -0,0 +1,12 @@
+package request
+
+// ListNotificationsRequest 通知列表, 最新的在前
+type ListNotificationsRequest struct {
+	CursorPage
+	UnreadOnly bool `json:"unread_only"` // 只看未读
+}
+
+// ReadNotificationsRequest 标记通知为已读, ids 为空时全部标记
+type ReadNotificationsRequest struct {
+	IDs []uint `json:"ids"`
+}
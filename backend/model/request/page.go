@@ -0,0 +1,14 @@
+package request
+
+// CursorPage 列表分页参数, 嵌入各列表请求
+// 传 cursor（或不传 page_num）时使用游标分页: 不统计总数, 翻页期间有新内容也不会重复或遗漏; 否则按页码分页
+type CursorPage struct {
+	PageNum  int    `json:"page_num" binding:"omitempty,min=1"`        // 页码
+	PageSize int    `json:"page_size" binding:"required,min=1,max=50"` // 每页条数
+	Cursor   string `json:"cursor"`                                    // 上一页返回的 next_cursor, 首页留空
+}
+
+// UseCursor 是否使用游标分页
+func (p CursorPage) UseCursor() bool {
+	return p.Cursor != "" || p.PageNum == 0
+}
@@ -0,0 +1,16 @@
+package request
+
+import "time"
+
+// PollInput 帖子中的投票, 发帖、修改帖子或保存草稿时传入
+type PollInput struct {
+	Options  []string   `json:"options" binding:"min=2,max=10,dive,required,max=100"` // 2~10 个选项
+	Multiple bool       `json:"multiple"`                                             // 是否多选
+	ClosesAt *time.Time `json:"closes_at"`                                            // 截止时间, 为空表示不截止
+}
+
+// PollVoteRequest 投票, 单选时只能选一个; 再次投票会覆盖之前的选择
+type PollVoteRequest struct {
+	PostID    uint   `json:"post_id" binding:"required"`
+	OptionIDs []uint `json:"option_ids" binding:"required,min=1,max=10"`
+}
@@ -1,27 +1,45 @@
 package request
 
+import "time"
+
 // CreatePostRequest 请求参数
 type CreatePostRequest struct {
-	Title     string   `json:"title" binding:"required,max=100"`    // 帖子标题 100 字符以内
-	Content   string   `json:"content" binding:"required"`          // 帖子内容
-	ImageURLs []string `json:"image_urls" binding:"max=3,dive,url"` // 最多3张图片，每张是合法 URL
+	Title       string          `json:"title" binding:"required,max=100"`       // 帖子标题 100 字符以内
+	Content     string          `json:"content" binding:"required"`             // 帖子内容
+	ImageURLs   []string        `json:"image_urls" binding:"max=3,dive,url"`    // 最多3张图片，每张是合法 URL
+	Attachments []AttachmentRef `json:"attachments" binding:"max=10,dive"`      // 附件: PDF、数据集、图片或链接, 按顺序展示
+	Poll        *PollInput      `json:"poll"`                                   // 可选, 附带投票
+	Tags        []string        `json:"tags" binding:"max=5,dive,min=1,max=30"` // 显式标签, 正文中的 #话题 会自动提取
+	PublishAt   *time.Time      `json:"publish_at"`                             // 可选, 将来的时间表示定时发布
+	// 可见范围, 默认 public
+	Visibility string `json:"visibility" binding:"omitempty,oneof=public followers mutual matched private"`
 }
 
 // UpdatePostRequest 请求参数
 type UpdatePostRequest struct {
-	PostID    uint     `json:"post_id" binding:"required"`          // 帖子 ID
-	Title     string   `json:"title" binding:"required,max=100"`    // 帖子标题 100 字符以内
-	Content   string   `json:"content" binding:"required"`          // 帖子内容
-	ImageURLs []string `json:"image_urls" binding:"max=3,dive,url"` // 最多3张图片，每张是合法 URL
+	PostID      uint            `json:"post_id" binding:"required"`             // 帖子 ID
+	Title       string          `json:"title" binding:"required,max=100"`       // 帖子标题 100 字符以内
+	Content     string          `json:"content" binding:"required"`             // 帖子内容
+	ImageURLs   []string        `json:"image_urls" binding:"max=3,dive,url"`    // 最多3张图片，每张是合法 URL
+	Attachments []AttachmentRef `json:"attachments" binding:"max=10,dive"`      // 不传则保留原有附件, 传空数组表示清空
+	Poll        *PollInput      `json:"poll"`                                   // 不传则保留原有投票; 已有人投票后不能修改
+	Tags        []string        `json:"tags" binding:"max=5,dive,min=1,max=30"` // 显式标签, 正文中的 #话题 会自动提取
+	// 可见范围, 不传则保持不变
+	Visibility string `json:"visibility" binding:"omitempty,oneof=public followers mutual matched private"`
 }
 
 // ListPostRequest 获取帖子列表的请求
 type ListPostRequest struct {
-	PageNum   int    `json:"page_num" binding:"required,min=1"`             // 页码
-	PageSize  int    `json:"page_size" binding:"required,min=1,max=50"`     // 每页条数
+	CursorPage
 	SortOrder string `json:"sort_order" binding:"omitempty,oneof=asc desc"` // 时间排序 asc / desc（默认 desc）
 }
 
+// ListFeedRequest 首页帖子流
+type ListFeedRequest struct {
+	ListPostRequest
+	Mode string `json:"mode" binding:"omitempty,oneof=fresh hot personalized"` // fresh 按时间（默认）/ hot 按热度 / personalized 个性化推荐
+}
+
 // DeletePostRequest 请求参数
 type DeletePostRequest struct {
 	PostID uint `json:"post_id" binding:"required"`
@@ -38,3 +56,44 @@ type LikePostRequest struct {
 type PostDetailRequest struct {
 	PostID uint `json:"post_id" binding:"required"`
 }
+
+// SaveDraftRequest 保存草稿（编辑器自动保存）, post_id 为空时新建草稿
+type SaveDraftRequest struct {
+	PostID      uint            `json:"post_id"`
+	Title       string          `json:"title" binding:"max=100"`
+	Content     string          `json:"content"`
+	ImageURLs   []string        `json:"image_urls" binding:"max=3,dive,url"`
+	Attachments []AttachmentRef `json:"attachments" binding:"max=10,dive"` // 不传则保留原有附件
+	Poll        *PollInput      `json:"poll"`                              // 不传则保留原有投票
+	Tags        []string        `json:"tags" binding:"max=5,dive,min=1,max=30"`
+	// 可见范围, 不传则保持不变（新建时为 public）
+	Visibility string `json:"visibility" binding:"omitempty,oneof=public followers mutual matched private"`
+}
+
+// PublishPostRequest 发布草稿, publish_at 为将来的时间时定时发布
+type PublishPostRequest struct {
+	PostID    uint       `json:"post_id" binding:"required"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// CancelScheduleRequest 取消定时发布, 帖子退回草稿
+type CancelScheduleRequest struct {
+	PostID uint `json:"post_id" binding:"required"`
+}
+
+// ListDraftRequest 我的草稿 / 定时发布列表
+type ListDraftRequest struct {
+	CursorPage
+}
+
+// PostViewStatsRequest 帖子浏览统计
+type PostViewStatsRequest struct {
+	PostID uint `json:"post_id" binding:"required"`
+	Days   int  `json:"days" binding:"omitempty,min=1,max=90"` // 最近几天的每日浏览, 默认 30
+}
+
+// UserPostsRequest 某个用户的帖子列表（个人主页）
+type UserPostsRequest struct {
+	ListPostRequest
+	UUID string `json:"uuid" binding:"required"`
+}
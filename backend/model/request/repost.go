@@ -0,0 +1,13 @@
+package request
+
+// RepostRequest 转发帖子, content 不为空时作为引用发布
+type RepostRequest struct {
+	PostID  uint     `json:"post_id" binding:"required"`
+	Content string   `json:"content" binding:"max=5000"`             // 引用时附带的评论, 为空表示纯转发
+	Tags    []string `json:"tags" binding:"max=5,dive,min=1,max=30"` // 仅引用有效
+}
+
+// UndoRepostRequest 取消纯转发, post_id 为被转发的帖子
+type UndoRepostRequest struct {
+	PostID uint `json:"post_id" binding:"required"`
+}
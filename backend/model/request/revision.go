@@ -0,0 +1,13 @@
+package request
+
+// PostRevisionsRequest 帖子修改历史
+type PostRevisionsRequest struct {
+	PostID uint `json:"post_id" binding:"required"`
+}
+
+// RevisionDiffRequest 比较帖子的两个版本
+type RevisionDiffRequest struct {
+	PostID uint `json:"post_id" binding:"required"`
+	From   int  `json:"from" binding:"required,min=1"` // 旧版本号
+	To     int  `json:"to" binding:"required,min=1"`   // 新版本号
+}
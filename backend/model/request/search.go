@@ -0,0 +1,19 @@
+package request
+
+// SearchRequest 全文搜索
+type SearchRequest struct {
+	Keyword    string   `json:"keyword" binding:"required,max=100"`
+	Types      []string `json:"types" binding:"omitempty,dive,oneof=post user comment"` // 为空表示全部类型
+	AuthorUUID string   `json:"author_uuid"`                                            // 只看某个作者
+	StartDate  string   `json:"start_date" binding:"omitempty,datetime=2006-01-02"`     // 起始日期（含）
+	EndDate    string   `json:"end_date" binding:"omitempty,datetime=2006-01-02"`       // 结束日期（含）
+	Tag        string   `json:"tag" binding:"omitempty,max=30"`
+	PageNum    int      `json:"page_num" binding:"required,min=1"`
+	PageSize   int      `json:"page_size" binding:"required,min=1,max=50"`
+}
+
+// SemanticSearchRequest 语义搜索帖子
+type SemanticSearchRequest struct {
+	Query string `json:"query" binding:"required,max=500"`
+	Limit int    `json:"limit" binding:"omitempty,min=1,max=50"` // 返回条数, 默认 10
+}
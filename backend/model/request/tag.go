@@ -0,0 +1,18 @@
+package request
+
+// TagRequest 按名称指定标签, 大小写、是否带 # 均可
+type TagRequest struct {
+	Tag string `json:"tag" binding:"required,max=31"`
+}
+
+// ListTagPostsRequest 标签页帖子列表
+type ListTagPostsRequest struct {
+	Tag string `json:"tag" binding:"required,max=31"`
+	ListPostRequest
+}
+
+// TrendingTagsRequest 热门标签
+type TrendingTagsRequest struct {
+	Days  int `json:"days" binding:"omitempty,min=1,max=30"`  // 统计最近几天, 默认 7
+	Limit int `json:"limit" binding:"omitempty,min=1,max=50"` // 返回条数, 默认 10
+}
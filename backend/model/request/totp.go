@@ -0,0 +1,12 @@
+package request
+
+// TOTPCodeRequest 提交验证器中的 6 位验证码, 已开启两步验证时也可以填写恢复码
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required,max=20"`
+}
+
+// TwoFactorLoginRequest 登录第二步, pre_auth_token 来自第一步登录的返回
+type TwoFactorLoginRequest struct {
+	PreAuthToken string `json:"pre_auth_token" binding:"required"`
+	Code         string `json:"code" binding:"required,max=20"`
+}
@@ -1,18 +1,41 @@
 package request
 
+// UpdateProfileInput 用户可自行修改的资料字段
+// Coin / IsVerified / 绑定状态 / MatchStatus 等由服务端维护, 不在此处开放
+// 邮箱只能通过绑定邮箱登录方式（验证过邮箱）设置
 type UpdateProfileInput struct {
-	Username      *string   `json:"username,omitempty"`
-	Email         *string   `json:"email,omitempty"`
-	IsVerified    *bool     `json:"is_verified,omitempty"`
-	AvatarURL     *string   `json:"avatar_url,omitempty"`
-	IntroShort    *string   `json:"intro_short,omitempty"`
-	IntroLong     *string   `json:"intro_long,omitempty"`
-	Gender        *string   `json:"gender,omitempty"`
-	Tags          *[]string `json:"tags,omitempty"`
-	ResearchArea  *string   `json:"research_area,omitempty"`
-	Coin          *int      `json:"coin,omitempty"`
-	IsEmailBound  *bool     `json:"is_email_bound,omitempty"`
-	IsGitHubBound *bool     `json:"is_github_bound,omitempty"`
-	IsGoogleBound *bool     `json:"is_google_bound,omitempty"`
-	MatchStatus   *string   `json:"match_status,omitempty"` // "available" or "matching" or "matched"
+	Username     *string   `json:"username,omitempty" binding:"omitempty,min=2,max=32"`
+	AvatarURL    *string   `json:"avatar_url,omitempty" binding:"omitempty,url"` // 必须是本站媒体域名下的地址
+	IntroShort   *string   `json:"intro_short,omitempty" binding:"omitempty,max=100"`
+	IntroLong    *string   `json:"intro_long,omitempty" binding:"omitempty,max=2000"`
+	Gender       *string   `json:"gender,omitempty"` // male / female / other
+	Tags         *[]string `json:"tags,omitempty" binding:"omitempty,max=10,dive,min=1,max=20"`
+	ResearchArea *string   `json:"research_area,omitempty" binding:"omitempty,max=100"`
+}
+
+// UnbindAccountRequest 解绑登录方式
+type UnbindAccountRequest struct {
+	Provider string `json:"provider" binding:"required,oneof=email github google"`
+}
+
+// MergeAccountRequest 合并账号, merge_token 来自绑定时返回的 duplicate_bind 结果
+type MergeAccountRequest struct {
+	MergeToken string `json:"merge_token" binding:"required"`
+	TOTPCode   string `json:"totp_code"` // 被合并账号开启了两步验证时必填
+}
+
+// AcademicVerifySendRequest 向机构邮箱发送学术认证验证码
+type AcademicVerifySendRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// AcademicVerifyConfirmRequest 提交学术认证验证码
+type AcademicVerifyConfirmRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Code  string `json:"code" binding:"required,len=6"`
+}
+
+// DeleteAccountRequest 申请注销账号
+type DeleteAccountRequest struct {
+	TOTPCode string `json:"totp_code"` // 开启了两步验证时必填
 }
@@ -0,0 +1,52 @@
+package response
+
+import "time"
+
+// AdminUserInfo 管理后台中的用户信息
+type AdminUserInfo struct {
+	UUID        string     `json:"uuid"`
+	Username    string     `json:"username"`
+	Email       string     `json:"email"`
+	AvatarURL   string     `json:"avatar_url"`
+	CreatedAt   time.Time  `json:"created_at"`
+	IsVerified  bool       `json:"is_verified"`
+	Institution string     `json:"institution"`
+	Role        string     `json:"role"`
+	Permissions []string   `json:"permissions"`
+	IsBanned    bool       `json:"is_banned"`
+	BanReason   string     `json:"ban_reason"`
+	BannedAt    *time.Time `json:"banned_at"`
+}
+
+// AdminUserDetail 用户信息 + 内容统计
+type AdminUserDetail struct {
+	AdminUserInfo
+	PostCount      int64 `json:"post_count"`
+	CommentCount   int64 `json:"comment_count"`
+	FollowerCount  int64 `json:"follower_count"`
+	FollowingCount int64 `json:"following_count"`
+}
+
+// AdminUserListResponse 用户查询结果
+type AdminUserListResponse struct {
+	Total int             `json:"total"`
+	List  []AdminUserInfo `json:"list"`
+}
+
+// ModerationLogInfo 管理操作记录
+type ModerationLogInfo struct {
+	ID            uint      `json:"id"`
+	ActorUUID     string    `json:"actor_uuid"`
+	ActorUsername string    `json:"actor_username"`
+	Action        string    `json:"action"`
+	TargetType    string    `json:"target_type"`
+	TargetID      string    `json:"target_id"`
+	Reason        string    `json:"reason"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ModerationLogListResponse 管理操作记录分页
+type ModerationLogListResponse struct {
+	Total int                 `json:"total"`
+	List  []ModerationLogInfo `json:"list"`
+}
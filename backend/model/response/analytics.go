@@ -0,0 +1,42 @@
+package response
+
+import "time"
+
+// AuthorAnalytics 作者数据看板, 统计范围为最近 Days 天
+type AuthorAnalytics struct {
+	Days           int             `json:"days"`
+	Totals         AnalyticsCounts `json:"totals"`          // 统计范围内的合计
+	Followers      int64           `json:"followers"`       // 当前关注者数
+	FollowerGrowth int64           `json:"follower_growth"` // 统计范围内的净增关注者
+	Daily          []AnalyticsDay  `json:"daily"`           // 按日期升序, 没有数据的日期为 0
+	TopPosts       []TopPost       `json:"top_posts"`
+}
+
+// AnalyticsCounts 浏览、互动和关注者变化
+type AnalyticsCounts struct {
+	Views         int64 `json:"views"`
+	Likes         int64 `json:"likes"`
+	Favorites     int64 `json:"favorites"`
+	Comments      int64 `json:"comments"`
+	NewFollowers  int64 `json:"new_followers"`
+	LostFollowers int64 `json:"lost_followers"`
+}
+
+// AnalyticsDay 一天的数据
+type AnalyticsDay struct {
+	Date string `json:"date"` // 2006-01-02
+	AnalyticsCounts
+	Followers int64 `json:"followers"` // 当天结束时的关注者数
+}
+
+// TopPost 统计范围内表现最好的帖子
+type TopPost struct {
+	PostID     uint      `json:"post_id"`
+	Title      string    `json:"title"`
+	CreateDate time.Time `json:"create_date"`
+	Views      int64     `json:"views"`
+	Likes      int64     `json:"likes"`
+	Favorites  int64     `json:"favorites"`
+	Comments   int64     `json:"comments"`
+	Score      float64   `json:"score"` // 与热度分相同的加权
+}
@@ -0,0 +1,23 @@
+package response
+
+import "time"
+
+// APIKeyInfo 个人访问令牌信息, 不包含明文
+type APIKeyInfo struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	RateLimit  int        `json:"rate_limit"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// APIKeyCreateResponse 创建成功后返回, key 明文只返回这一次
+type APIKeyCreateResponse struct {
+	APIKeyInfo
+	Key string `json:"key"`
+}
@@ -0,0 +1,19 @@
+package response
+
+// AttachmentInfo 帖子附件
+type AttachmentInfo struct {
+	ID            uint   `json:"id"`
+	Kind          string `json:"kind"`          // image / pdf / archive / link
+	URL           string `json:"url,omitempty"` // 仅外部链接返回, 上传的文件通过下载接口获取
+	FileName      string `json:"file_name"`
+	MimeType      string `json:"mime_type,omitempty"`
+	Bytes         int64  `json:"bytes,omitempty"`
+	PageCount     int    `json:"page_count,omitempty"`    // PDF 页数
+	ThumbnailURL  string `json:"thumbnail_url,omitempty"` // PDF 第一页缩略图
+	DownloadCount int    `json:"download_count"`
+}
+
+// AttachmentDownloadResponse 附件下载地址, 上传的文件为限时有效的签名地址
+type AttachmentDownloadResponse struct {
+	URL string `json:"url"`
+}
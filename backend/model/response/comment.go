@@ -15,10 +15,19 @@ type CommentInfo struct {
 	Username   string `json:"username"`
 	AvatarURL  string `json:"avatar_url"`
 
-	// 当前用户是否点赞
-	IsLiked bool `json:"is_liked"`
+	// 正文中 @ 到的用户
+	Mentions []MentionEntity `json:"mentions"`
+
+	// 当前用户是否点赞, 未登录访问时省略
+	IsLiked *bool `json:"is_liked,omitempty"`
 
 	// 子评论信息
 	Replies          []CommentInfo `json:"replies,omitempty"`
 	RepliesMoreCount int           `json:"replies_more_count,omitempty"`
 }
+
+// CommentListResponse 评论 / 子评论分页列表
+type CommentListResponse struct {
+	PageInfo
+	List []CommentInfo `json:"list"`
+}
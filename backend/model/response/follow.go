@@ -6,6 +6,12 @@ type FollowedUserInfo struct {
 	AvatarURL string `json:"avatar_url"`
 }
 
+// FollowListResponse 关注 / 粉丝分页列表
+type FollowListResponse struct {
+	PageInfo
+	List []FollowedUserInfo `json:"list"`
+}
+
 type FollowCountResponse struct {
 	FollowingCount int64 `json:"following_count"` // 我关注的人
 	FollowerCount  int64 `json:"follower_count"`  // 关注我的人
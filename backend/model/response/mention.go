@@ -0,0 +1,34 @@
+package response
+
+import "time"
+
+// MentionEntity 正文中的 @提及, 前端把正文里的 @text 替换为指向用户主页的链接
+type MentionEntity struct {
+	UserUUID  string `json:"user_uuid"`
+	Username  string `json:"username"` // 当前用户名, 对方改名后与 text 不同
+	AvatarURL string `json:"avatar_url"`
+	Text      string `json:"text"` // 正文中的写法, 不含 @
+}
+
+// NotificationInfo 一条站内通知
+type NotificationInfo struct {
+	ID        uint      `json:"id"`
+	Type      string    `json:"type"` // mention
+	PostID    uint      `json:"post_id"`
+	CommentID uint      `json:"comment_id,omitempty"` // 在评论中被提及时为评论 ID
+	Excerpt   string    `json:"excerpt"`              // 帖子或评论的摘要
+	IsRead    bool      `json:"is_read"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// 触发通知的用户
+	ActorUUID      string `json:"actor_uuid"`
+	ActorUsername  string `json:"actor_username"`
+	ActorAvatarURL string `json:"actor_avatar_url"`
+}
+
+// NotificationListResponse 通知分页列表
+type NotificationListResponse struct {
+	PageInfo
+	List        []NotificationInfo `json:"list"`
+	UnreadCount int64              `json:"unread_count"`
+}
@@ -0,0 +1,8 @@
+package response
+
+// PageInfo 列表分页信息, 嵌入各列表响应
+type PageInfo struct {
+	Total      int64  `json:"total"`                 // 总条数, 游标分页时不统计, 为 0
+	NextCursor string `json:"next_cursor,omitempty"` // 下一页游标, 没有更多时为空
+	HasMore    bool   `json:"has_more"`
+}
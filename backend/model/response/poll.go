@@ -0,0 +1,23 @@
+package response
+
+import "time"
+
+// PollInfo 投票及实时结果
+type PollInfo struct {
+	ID         uint             `json:"id"`
+	Multiple   bool             `json:"multiple"`
+	ClosesAt   *time.Time       `json:"closes_at,omitempty"`
+	Closed     bool             `json:"closed"`
+	VoterCount int              `json:"voter_count"` // 参与人数
+	TotalVotes int              `json:"total_votes"` // 总票数, 多选时可能大于参与人数
+	Options    []PollOptionInfo `json:"options"`
+	MyVotes    []uint           `json:"my_votes"` // 当前用户选择的选项
+}
+
+// PollOptionInfo 投票选项
+type PollOptionInfo struct {
+	ID      uint    `json:"id"`
+	Text    string  `json:"text"`
+	Votes   int     `json:"votes"`
+	Percent float64 `json:"percent"` // 占参与人数的百分比
+}
@@ -5,33 +5,78 @@ import "time"
 // PostInfo 用于返回帖子信息
 
 type PostInfo struct {
-	PostID         uint      `json:"post_id"`
-	AuthorUUID     string    `json:"author_uuid"`
-	Title          string    `json:"title"`
-	Content        string    `json:"content"`
-	ImageURLs      []string  `json:"image_urls"`
-	CreateDate     time.Time `json:"create_date"`
-	StarNumber     int       `json:"star_number"`
-	FavoriteNumber int       `json:"favorite_number"`
-	ViewNumber     int       `json:"view_number"`
-	CommentNumber  int       `json:"comment_number"`
-
-	// 新增发帖用户信息字段
+	PostID         uint       `json:"post_id"`
+	AuthorUUID     string     `json:"author_uuid"`
+	Title          string     `json:"title"`
+	Content        string     `json:"content"`      // Markdown 原文, 编辑时使用
+	ContentHTML    string     `json:"content_html"` // 渲染并过滤后的 HTML, 公式与代码由前端排版 / 高亮
+	Excerpt        string     `json:"excerpt"`      // 纯文本摘要, 列表页使用
+	ImageURLs      []string   `json:"image_urls"`
+	CreateDate     time.Time  `json:"create_date"`
+	StarNumber     int        `json:"star_number"`
+	FavoriteNumber int        `json:"favorite_number"`
+	ViewNumber     int        `json:"view_number"`
+	CommentNumber  int        `json:"comment_number"`
+	RepostNumber   int        `json:"repost_number"`
+	Tags           []string   `json:"tags"`                 // 显式标签 + #话题
+	Status         string     `json:"status"`               // draft / scheduled / published
+	Visibility     string     `json:"visibility"`           // public / followers / mutual / matched / private
+	PublishAt      *time.Time `json:"publish_at,omitempty"` // 定时发布时间
+	EditedAt       *time.Time `json:"edited_at,omitempty"`  // 发布后最后一次修改的时间
+
+	Attachments []AttachmentInfo `json:"attachments"` // PDF、数据集、图片、链接等附件
+	Mentions    []MentionEntity  `json:"mentions"`    // 正文中 @ 到的用户
+
+	// 转发 / 引用: 正文为空的是纯转发
+	RepostOfID    *uint     `json:"repost_of_id,omitempty"`
+	RepostOf      *PostInfo `json:"repost_of,omitempty"`      // 被转发的帖子, 只展开一层
+	RepostDeleted bool      `json:"repost_deleted,omitempty"` // 被转发的帖子已删除或对当前用户不可见
+
+	// 新增发帖用户信息字段, is_following 在未登录访问时省略
 	Username    string `json:"username"`
 	IntroLong   string `json:"intro_long"`
 	AvatarURL   string `json:"avatar_url"`
-	IsFollowing bool   `json:"is_following"`
+	IsFollowing *bool  `json:"is_following,omitempty"`
+	IsVerified  bool   `json:"is_verified"` // 作者是否通过学术认证
+	Institution string `json:"institution"` // 作者认证机构
 }
 
 // PostListResponse 用于返回分页帖子
 type PostListResponse struct {
-	Total int        `json:"total"` // 总条数
-	List  []PostInfo `json:"list"`  // 当前页帖子
+	PageInfo
+	List []PostInfo `json:"list"` // 当前页帖子
 }
 
 // PostDetailResponse = PostInfo + 用户态信息
+// is_liked / is_favorited / is_reposted（是否已纯转发）在未登录访问时省略
 type PostDetailResponse struct {
 	PostInfo
-	IsLiked     bool `json:"is_liked"`
-	IsFavorited bool `json:"is_favorited"`
+	IsLiked      *bool         `json:"is_liked,omitempty"`
+	IsFavorited  *bool         `json:"is_favorited,omitempty"`
+	IsReposted   *bool         `json:"is_reposted,omitempty"`
+	Poll         *PollInfo     `json:"poll"`          // 帖子中的投票及实时结果, 没有投票时为 null
+	RelatedPosts []RelatedPost `json:"related_posts"` // 内容相似的帖子
+}
+
+// RelatedPost 详情页的相似帖子
+type RelatedPost struct {
+	PostID     uint      `json:"post_id"`
+	Title      string    `json:"title"`
+	AuthorUUID string    `json:"author_uuid"`
+	Username   string    `json:"username"`
+	AvatarURL  string    `json:"avatar_url"`
+	CreateDate time.Time `json:"create_date"`
+	Similarity float64   `json:"similarity"`
+}
+
+// SimilarPostInfo 语义搜索结果
+type SimilarPostInfo struct {
+	PostInfo
+	Similarity float64 `json:"similarity"` // 余弦相似度, 越大越相关
+}
+
+// DraftSavedResponse 草稿保存结果
+type DraftSavedResponse struct {
+	PostID  uint      `json:"post_id"`
+	SavedAt time.Time `json:"saved_at"`
 }
@@ -0,0 +1,32 @@
+package response
+
+import "time"
+
+// PostRevisionInfo 帖子的一个历史版本
+type PostRevisionInfo struct {
+	Version    int       `json:"version"`
+	Title      string    `json:"title"`
+	Content    string    `json:"content"`
+	ImageURLs  []string  `json:"image_urls"`
+	EditorUUID string    `json:"editor_uuid"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// DiffLine 一行差异, op 为 equal / insert / delete
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// RevisionDiff 两个版本之间的差异
+type RevisionDiff struct {
+	PostID        uint       `json:"post_id"`
+	From          int        `json:"from"`
+	To            int        `json:"to"`
+	FromTime      time.Time  `json:"from_time"`
+	ToTime        time.Time  `json:"to_time"`
+	Title         []DiffLine `json:"title"`
+	Content       []DiffLine `json:"content"`
+	ImagesAdded   []string   `json:"images_added"`
+	ImagesRemoved []string   `json:"images_removed"`
+}
@@ -0,0 +1,24 @@
+package response
+
+import "time"
+
+// SearchHit 一条搜索结果, title / snippet 中命中的关键词用 <em> 标出, 其余内容已做 HTML 转义
+type SearchHit struct {
+	Type       string    `json:"type"` // post / user / comment
+	ID         string    `json:"id"`   // 帖子 ID / 用户 UUID / 评论 ID
+	Title      string    `json:"title"`
+	Snippet    string    `json:"snippet"`
+	Score      float64   `json:"score"`
+	PostID     uint      `json:"post_id,omitempty"` // 帖子或评论所属帖子
+	AuthorUUID string    `json:"author_uuid"`
+	Username   string    `json:"username"`
+	AvatarURL  string    `json:"avatar_url"`
+	IsVerified bool      `json:"is_verified"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// SearchResponse 搜索结果分页
+type SearchResponse struct {
+	Total int         `json:"total"`
+	List  []SearchHit `json:"list"`
+}
@@ -0,0 +1,19 @@
+package response
+
+// TagInfo 标签详情
+type TagInfo struct {
+	Name          string `json:"name"`
+	Slug          string `json:"slug"` // 规范化名称, 标签页链接使用
+	PostCount     int64  `json:"post_count,omitempty"`
+	UserCount     int64  `json:"user_count,omitempty"`     // 资料中带有该标签的用户数
+	FollowerCount int64  `json:"follower_count,omitempty"` // 关注该标签的用户数
+	IsFollowing   bool   `json:"is_following"`
+}
+
+// TrendingTag 热门标签
+type TrendingTag struct {
+	Name        string `json:"name"`
+	Slug        string `json:"slug"`
+	PostCount   int64  `json:"post_count"`   // 时间窗口内的帖子数
+	AuthorCount int64  `json:"author_count"` // 时间窗口内的发帖人数
+}
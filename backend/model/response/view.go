@@ -0,0 +1,16 @@
+package response
+
+// PostViewStats 帖子浏览统计, 仅作者可见
+type PostViewStats struct {
+	PostID              uint         `json:"post_id"`
+	TotalViews          int          `json:"total_views"`           // 累计浏览数
+	RecentUniqueViewers int64        `json:"recent_unique_viewers"` // 最近 days 天（与 daily 相同区间）的独立访客数
+	Daily               []DailyViews `json:"daily"`                 // 每日浏览, 按日期升序, 没有浏览的日期为 0
+}
+
+// DailyViews 一天的浏览数
+type DailyViews struct {
+	Date    string `json:"date"` // 2006-01-02
+	Views   int64  `json:"views"`
+	Viewers int64  `json:"viewers"` // 当天独立访客数
+}
@@ -40,6 +40,103 @@ func StartCronJobs() {
 		log.Fatalln("添加定时任务失败:", err)
 	}
 
+	// 每天03:00 删除注销宽限期已过的账号
+	_, err = c.AddFunc("0 0 3 * * *", func() {
+		log.Println("[Cron] 开始清理注销账号:", time.Now().Format("2006-01-02 15:04:05"))
+		if err := service.PurgeDeletedAccounts(); err != nil {
+			log.Println("[Cron] 清理注销账号失败:", err)
+		} else {
+			log.Println("[Cron] 清理注销账号成功！")
+		}
+	})
+
+	if err != nil {
+		log.Fatalln("添加定时任务失败:", err)
+	}
+
+	// 每小时补算缺失的帖子向量（外部接口失败或更换模型后）
+	_, err = c.AddFunc("0 30 * * * *", func() {
+		if err := service.BackfillPostEmbeddings(); err != nil {
+			log.Println("[Cron] 补算帖子向量失败:", err)
+		}
+	})
+
+	if err != nil {
+		log.Fatalln("添加定时任务失败:", err)
+	}
+
+	// 每分钟发布到点的定时帖子
+	_, err = c.AddFunc("0 * * * * *", func() {
+		if err := service.PublishScheduledPosts(); err != nil {
+			log.Println("[Cron] 定时发布帖子失败:", err)
+		}
+	})
+
+	if err != nil {
+		log.Fatalln("添加定时任务失败:", err)
+	}
+
+	// 每分钟把新的浏览记录汇总到帖子浏览数
+	_, err = c.AddFunc("30 * * * * *", func() {
+		if err := service.AggregatePostViews(); err != nil {
+			log.Println("[Cron] 汇总帖子浏览数失败:", err)
+		}
+	})
+
+	if err != nil {
+		log.Fatalln("添加定时任务失败:", err)
+	}
+
+	// 每天04:00 按关系表修正点赞、收藏、评论计数
+	_, err = c.AddFunc("0 0 4 * * *", func() {
+		fixed, err := service.ReconcileCounters()
+		if err != nil {
+			log.Println("[Cron] 修正计数失败:", err)
+		} else {
+			log.Println("[Cron] 修正计数完成, 修正行数:", fixed)
+		}
+	})
+
+	if err != nil {
+		log.Fatalln("添加定时任务失败:", err)
+	}
+
+	// 每天04:30 清理超过保留期的浏览记录
+	_, err = c.AddFunc("0 30 4 * * *", func() {
+		deleted, err := service.PrunePostViews()
+		if err != nil {
+			log.Println("[Cron] 清理浏览记录失败:", err)
+		} else {
+			log.Println("[Cron] 清理浏览记录完成, 删除行数:", deleted)
+		}
+	})
+
+	if err != nil {
+		log.Fatalln("添加定时任务失败:", err)
+	}
+
+	// 每小时汇总作者数据看板的每日统计
+	_, err = c.AddFunc("0 5 * * * *", func() {
+		if err := service.RollupDailyStats(); err != nil {
+			log.Println("[Cron] 汇总每日统计失败:", err)
+		}
+	})
+
+	if err != nil {
+		log.Fatalln("添加定时任务失败:", err)
+	}
+
+	// 每10分钟刷新近期帖子的热度分
+	_, err = c.AddFunc("0 */10 * * * *", func() {
+		if err := service.RefreshHotScores(); err != nil {
+			log.Println("[Cron] 刷新帖子热度失败:", err)
+		}
+	})
+
+	if err != nil {
+		log.Fatalln("添加定时任务失败:", err)
+	}
+
 	c.Start()
 	log.Println("[Cron] 定时任务启动完成")
 }
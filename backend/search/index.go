@@ -0,0 +1,212 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 文档类型
+const (
+	TypePost    = "post"
+	TypeUser    = "user"
+	TypeComment = "comment"
+)
+
+// Document 待索引的文档
+type Document struct {
+	Type       string
+	ID         string
+	Title      string
+	Body       string
+	Tags       []string
+	AuthorUUID string
+	PostID     uint // 评论所属帖子
+	CreatedAt  time.Time
+}
+
+// Query 搜索条件, Keyword 必填, 其余为可选过滤条件
+type Query struct {
+	Keyword    string
+	Types      []string // 为空表示全部类型
+	AuthorUUID string
+	From       *time.Time
+	To         *time.Time
+	Tag        string
+	PageNum    int
+	PageSize   int
+
+	// PostCondition 帖子和评论结果所属帖子需要满足的条件, 为 posts 表上的 SQL 条件, 在分页之前过滤
+	// 为空时不过滤; 用户结果不受影响
+	PostCondition string
+	PostArgs      []interface{}
+}
+
+// Hit 一条搜索结果, Title / Snippet 已做 HTML 转义, 命中的关键词用 <em> 包裹
+type Hit struct {
+	Type       string
+	ID         string
+	Score      float64
+	Title      string
+	Snippet    string
+	AuthorUUID string
+	PostID     uint
+	CreatedAt  time.Time
+}
+
+// Index 搜索索引抽象
+type Index interface {
+	// Index 新增或覆盖一个文档
+	Index(doc Document) error
+	// Delete 删除文档, 文档不存在时不报错
+	Delete(docType string, ids ...string) error
+	// Search 按相关度排序返回结果及总数
+	Search(q Query) ([]Hit, int64, error)
+	// Reset 清空索引, 用于全量重建
+	Reset() error
+}
+
+var defaultIndex Index = NewMemoryIndex()
+
+// Init 根据配置选择索引实现: mysql（默认）或 memory
+func Init(driver string) {
+	switch driver {
+	case "memory":
+		defaultIndex = NewMemoryIndex()
+	default:
+		defaultIndex = NewMySQLIndex()
+	}
+}
+
+// Default 返回当前使用的索引
+func Default() Index {
+	return defaultIndex
+}
+
+// Terms 把关键词拆成检索词, 小写并去重
+func Terms(keyword string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, t := range strings.Fields(strings.ToLower(keyword)) {
+		if !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+// snippetRunes 摘要长度
+const snippetRunes = 120
+
+// Highlight 截取包含第一个命中词的片段, 转义后用 <em> 标出所有命中词
+func Highlight(text string, terms []string, limit int) string {
+	if limit <= 0 {
+		limit = snippetRunes
+	}
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+
+	// 找到第一个命中位置, 让摘要从它前面一点开始
+	start := 0
+	if len(lower) == len(runes) {
+		first := -1
+		for _, t := range terms {
+			if i := strings.Index(string(lower), t); i >= 0 {
+				pos := utf8.RuneCountInString(string(lower)[:i])
+				if first < 0 || pos < first {
+					first = pos
+				}
+			}
+		}
+		if first > limit/4 {
+			start = first - limit/4
+		}
+	}
+	end := start + limit
+	if end > len(runes) {
+		end = len(runes)
+	}
+	fragment := string(runes[start:end])
+
+	out := markTerms(fragment, terms)
+	if start > 0 {
+		out = "..." + out
+	}
+	if end < len(runes) {
+		out += "..."
+	}
+	return out
+}
+
+// markTerms 转义 HTML 并用 <em> 包裹命中词（不区分大小写）
+func markTerms(text string, terms []string) string {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// 大小写转换改变了字节长度, 无法按位置对应, 只做转义
+		return html.EscapeString(text)
+	}
+	marked := make([]bool, len(text))
+	for _, t := range terms {
+		if t == "" {
+			continue
+		}
+		for i := 0; ; {
+			j := strings.Index(lower[i:], t)
+			if j < 0 {
+				break
+			}
+			for k := i + j; k < i+j+len(t); k++ {
+				marked[k] = true
+			}
+			i += j + len(t)
+		}
+	}
+
+	var b strings.Builder
+	in := false
+	for i := 0; i < len(text); {
+		_, size := utf8.DecodeRuneInString(text[i:])
+		if marked[i] && !in {
+			b.WriteString("<em>")
+			in = true
+		} else if !marked[i] && in {
+			b.WriteString("</em>")
+			in = false
+		}
+		b.WriteString(html.EscapeString(text[i : i+size]))
+		i += size
+	}
+	if in {
+		b.WriteString("</em>")
+	}
+	return b.String()
+}
+
+// JoinTags 标签存储格式: 换行分隔, 首尾各一个换行
+func JoinTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	clean := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(strings.ReplaceAll(t, "\n", " ")))
+		if t != "" {
+			clean = append(clean, t)
+		}
+	}
+	if len(clean) == 0 {
+		return ""
+	}
+	return "\n" + strings.Join(clean, "\n") + "\n"
+}
+
+func normalizePage(q *Query) {
+	if q.PageNum <= 0 {
+		q.PageNum = 1
+	}
+	if q.PageSize <= 0 || q.PageSize > 50 {
+		q.PageSize = 10
+	}
+}
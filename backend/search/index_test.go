@@ -0,0 +1,77 @@
+package search
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTerms(t *testing.T) {
+	got := Terms("  Graph  graph ML\tgnn ")
+	want := []string{"graph", "ml", "gnn"}
+	if !equalIDs(got, want) {
+		t.Errorf("Terms = %v, want %v", got, want)
+	}
+	if got := Terms(" "); len(got) != 0 {
+		t.Errorf("Terms(blank) = %v, want empty", got)
+	}
+}
+
+func TestMarkTerms(t *testing.T) {
+	cases := []struct {
+		name  string
+		text  string
+		terms []string
+		want  string
+	}{
+		{"case insensitive", "Graph and graph", []string{"graph"}, "<em>Graph</em> and <em>graph</em>"},
+		{"adjacent terms merge", "foobar", []string{"foo", "bar"}, "<em>foobar</em>"},
+		{"overlapping terms merge", "abcd", []string{"abc", "bcd"}, "<em>abcd</em>"},
+		{"html escaped outside match", `<b>x</b> & graph`, []string{"graph"}, "&lt;b&gt;x&lt;/b&gt; &amp; <em>graph</em>"},
+		{"html escaped inside match", `a<b`, []string{"a<b"}, "<em>a&lt;b</em>"},
+		{"multibyte text", "图神经网络 graph", []string{"神经"}, "图<em>神经</em>网络 graph"},
+		{"empty term ignored", "graph", []string{""}, "graph"},
+		{"no match", "graph", []string{"tree"}, "graph"},
+		// İ 转小写后字节长度变化, 无法对应位置, 只做转义
+		{"length changing lowercase only escapes", "İ<graph", []string{"graph"}, "İ&lt;graph"},
+	}
+	for _, c := range cases {
+		if got := markTerms(c.text, c.terms); got != c.want {
+			t.Errorf("%s: markTerms(%q, %v) = %q, want %q", c.name, c.text, c.terms, got, c.want)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	if got, want := Highlight("short graph text", []string{"graph"}, 0), "short <em>graph</em> text"; got != want {
+		t.Errorf("short text: got %q, want %q", got, want)
+	}
+
+	// 命中位置靠后时从命中词前 limit/4 处开始截取, 两端加省略号
+	text := strings.Repeat("a", 50) + "graph" + strings.Repeat("b", 50)
+	got := Highlight(text, []string{"graph"}, 20)
+	want := "..." + strings.Repeat("a", 5) + "<em>graph</em>" + strings.Repeat("b", 10) + "..."
+	if got != want {
+		t.Errorf("long text: got %q, want %q", got, want)
+	}
+
+	// 命中位置靠前时从开头截取
+	text = "graph " + strings.Repeat("c", 30)
+	got = Highlight(text, []string{"graph"}, 10)
+	want = "<em>graph</em> cccc..."
+	if got != want {
+		t.Errorf("early match: got %q, want %q", got, want)
+	}
+
+	// 按字符而不是字节截取
+	text = strings.Repeat("图", 40) + "网络" + strings.Repeat("图", 40)
+	got = Highlight(text, []string{"网络"}, 8)
+	want = "..." + "图图" + "<em>网络</em>" + "图图图图" + "..."
+	if got != want {
+		t.Errorf("multibyte: got %q, want %q", got, want)
+	}
+
+	// 没有命中时返回开头的片段
+	if got, want := Highlight("abcdef", []string{"zzz"}, 3), "abc..."; got != want {
+		t.Errorf("no match: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,158 @@
+package search
+
+import (
+	"OpenHouse/global"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// MemoryIndex 进程内索引, 用于测试和没有 MySQL 全文索引的环境
+// 打分: 标题命中 3 分, 标签命中 2 分, 正文命中 1 分, 按命中次数累加
+type MemoryIndex struct {
+	mu   sync.RWMutex
+	docs map[string]Document
+}
+
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{docs: make(map[string]Document)}
+}
+
+func memoryKey(docType, id string) string {
+	return docType + ":" + id
+}
+
+func (m *MemoryIndex) Index(doc Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.docs[memoryKey(doc.Type, doc.ID)] = doc
+	return nil
+}
+
+func (m *MemoryIndex) Delete(docType string, ids ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range ids {
+		delete(m.docs, memoryKey(docType, id))
+	}
+	return nil
+}
+
+func (m *MemoryIndex) Reset() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.docs = make(map[string]Document)
+	return nil
+}
+
+func (m *MemoryIndex) Search(q Query) ([]Hit, int64, error) {
+	normalizePage(&q)
+	terms := Terms(q.Keyword)
+	if len(terms) == 0 {
+		return []Hit{}, 0, nil
+	}
+	types := make(map[string]bool, len(q.Types))
+	for _, t := range q.Types {
+		types[t] = true
+	}
+	tag := strings.ToLower(strings.TrimSpace(q.Tag))
+
+	m.mu.RLock()
+	var hits []Hit
+	for _, doc := range m.docs {
+		if len(types) > 0 && !types[doc.Type] {
+			continue
+		}
+		if q.AuthorUUID != "" && doc.AuthorUUID != q.AuthorUUID {
+			continue
+		}
+		if q.From != nil && doc.CreatedAt.Before(*q.From) {
+			continue
+		}
+		if q.To != nil && doc.CreatedAt.After(*q.To) {
+			continue
+		}
+		tags := JoinTags(doc.Tags)
+		if tag != "" && !strings.Contains(tags, "\n"+tag+"\n") {
+			continue
+		}
+
+		title, body := strings.ToLower(doc.Title), strings.ToLower(doc.Body)
+		var score float64
+		for _, t := range terms {
+			score += 3*float64(strings.Count(title, t)) +
+				2*float64(strings.Count(tags, t)) +
+				float64(strings.Count(body, t))
+		}
+		if score == 0 {
+			continue
+		}
+		hits = append(hits, Hit{
+			Type:       doc.Type,
+			ID:         doc.ID,
+			Score:      score,
+			Title:      markTerms(doc.Title, terms),
+			Snippet:    Highlight(doc.Body, terms, 0),
+			AuthorUUID: doc.AuthorUUID,
+			PostID:     doc.PostID,
+			CreatedAt:  doc.CreatedAt,
+		})
+	}
+	m.mu.RUnlock()
+
+	if q.PostCondition != "" {
+		var err error
+		if hits, err = filterPostHits(hits, q.PostCondition, q.PostArgs); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].CreatedAt.After(hits[j].CreatedAt)
+	})
+
+	total := int64(len(hits))
+	start := (q.PageNum - 1) * q.PageSize
+	if start >= len(hits) {
+		return []Hit{}, total, nil
+	}
+	end := start + q.PageSize
+	if end > len(hits) {
+		end = len(hits)
+	}
+	return hits[start:end], total, nil
+}
+
+// filterPostHits 按 PostCondition 查出候选结果中满足条件的帖子, 去掉其余帖子和评论
+func filterPostHits(hits []Hit, cond string, args []interface{}) ([]Hit, error) {
+	postIDs := make([]uint, 0, len(hits))
+	for _, h := range hits {
+		if h.Type != TypeUser {
+			postIDs = append(postIDs, h.PostID)
+		}
+	}
+	if len(postIDs) == 0 {
+		return hits, nil
+	}
+	var visibleIDs []uint
+	if err := global.DB.Table("posts").Where("posts.id IN (?)", postIDs).Where(cond, args...).
+		Pluck("posts.id", &visibleIDs).Error; err != nil {
+		return nil, errors.Wrap(err, "过滤搜索结果失败")
+	}
+	visible := make(map[uint]bool, len(visibleIDs))
+	for _, id := range visibleIDs {
+		visible[id] = true
+	}
+	kept := hits[:0]
+	for _, h := range hits {
+		if h.Type == TypeUser || visible[h.PostID] {
+			kept = append(kept, h)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,190 @@
+package search
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestIndex(t *testing.T) *MemoryIndex {
+	t.Helper()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	idx := NewMemoryIndex()
+	docs := []Document{
+		{Type: TypePost, ID: "1", Title: "Graph neural networks", Body: "A survey of message passing.", Tags: []string{"ML"}, AuthorUUID: "alice", PostID: 1, CreatedAt: base},
+		{Type: TypePost, ID: "2", Title: "Cooking notes", Body: "Graph paper is useful for recipes.", Tags: []string{"food"}, AuthorUUID: "bob", PostID: 2, CreatedAt: base.Add(24 * time.Hour)},
+		{Type: TypePost, ID: "3", Title: "Weekly reading", Body: "Nothing about it here.", Tags: []string{"graph"}, AuthorUUID: "alice", PostID: 3, CreatedAt: base.Add(48 * time.Hour)},
+		{Type: TypeComment, ID: "10", Body: "Great graph, thanks!", AuthorUUID: "bob", PostID: 1, CreatedAt: base.Add(72 * time.Hour)},
+		{Type: TypeUser, ID: "alice", Title: "alice", Body: "Graph theory and ML", AuthorUUID: "alice", CreatedAt: base},
+	}
+	for _, d := range docs {
+		if err := idx.Index(d); err != nil {
+			t.Fatalf("Index(%s:%s): %v", d.Type, d.ID, err)
+		}
+	}
+	return idx
+}
+
+func hitIDs(hits []Hit) []string {
+	ids := make([]string, 0, len(hits))
+	for _, h := range hits {
+		ids = append(ids, h.Type+":"+h.ID)
+	}
+	return ids
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMemoryIndexSearch(t *testing.T) {
+	idx := newTestIndex(t)
+	from := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name  string
+		q     Query
+		want  []string
+		total int64
+	}{
+		{
+			// 标题 3 分 > 标签 2 分 > 正文 1 分, 同分时新的在前
+			name:  "ranking by field weight then recency",
+			q:     Query{Keyword: "graph"},
+			want:  []string{"post:1", "post:3", "comment:10", "post:2", "user:alice"},
+			total: 5,
+		},
+		{
+			name:  "keyword is case insensitive",
+			q:     Query{Keyword: "GRAPH", Types: []string{TypePost}},
+			want:  []string{"post:1", "post:3", "post:2"},
+			total: 3,
+		},
+		{
+			// post:1 标题 + 标签共 5 分; post:3 与 user:alice 同为 2 分, 新的在前
+			name:  "multiple terms add up",
+			q:     Query{Keyword: "graph ml", Types: []string{TypeUser, TypePost}},
+			want:  []string{"post:1", "post:3", "user:alice", "post:2"},
+			total: 4,
+		},
+		{
+			name:  "type filter",
+			q:     Query{Keyword: "graph", Types: []string{TypeComment}},
+			want:  []string{"comment:10"},
+			total: 1,
+		},
+		{
+			name:  "author filter",
+			q:     Query{Keyword: "graph", AuthorUUID: "bob"},
+			want:  []string{"comment:10", "post:2"},
+			total: 2,
+		},
+		{
+			name:  "date range is inclusive",
+			q:     Query{Keyword: "graph", From: &from, To: &to},
+			want:  []string{"post:3", "post:2"},
+			total: 2,
+		},
+		{
+			name:  "tag filter matches whole tag ignoring case",
+			q:     Query{Keyword: "graph", Tag: " Graph "},
+			want:  []string{"post:3"},
+			total: 1,
+		},
+		{
+			name:  "tag filter does not match substrings",
+			q:     Query{Keyword: "graph", Tag: "gra"},
+			want:  []string{},
+			total: 0,
+		},
+		{
+			name:  "no match",
+			q:     Query{Keyword: "quantum"},
+			want:  []string{},
+			total: 0,
+		},
+		{
+			name:  "blank keyword",
+			q:     Query{Keyword: "   "},
+			want:  []string{},
+			total: 0,
+		},
+		{
+			name:  "second page",
+			q:     Query{Keyword: "graph", PageNum: 2, PageSize: 2},
+			want:  []string{"comment:10", "post:2"},
+			total: 5,
+		},
+		{
+			name:  "page past the end",
+			q:     Query{Keyword: "graph", PageNum: 4, PageSize: 2},
+			want:  []string{},
+			total: 5,
+		},
+	}
+	for _, c := range cases {
+		hits, total, err := idx.Search(c.q)
+		if err != nil {
+			t.Errorf("%s: Search error: %v", c.name, err)
+			continue
+		}
+		if got := hitIDs(hits); !equalIDs(got, c.want) || total != c.total {
+			t.Errorf("%s: got %v (total %d), want %v (total %d)", c.name, got, total, c.want, c.total)
+		}
+	}
+}
+
+func TestMemoryIndexScoreAndHighlight(t *testing.T) {
+	idx := newTestIndex(t)
+	hits, _, err := idx.Search(Query{Keyword: "graph", Types: []string{TypePost}, PageSize: 1})
+	if err != nil || len(hits) != 1 {
+		t.Fatalf("Search = %v, %v", hits, err)
+	}
+	h := hits[0]
+	if h.Score != 3 {
+		t.Errorf("score = %v, want 3", h.Score)
+	}
+	if want := "<em>Graph</em> neural networks"; h.Title != want {
+		t.Errorf("title = %q, want %q", h.Title, want)
+	}
+	if h.PostID != 1 || h.AuthorUUID != "alice" {
+		t.Errorf("hit = %+v", h)
+	}
+}
+
+func TestMemoryIndexUpdateDeleteReset(t *testing.T) {
+	idx := newTestIndex(t)
+
+	// 同一 Type+ID 覆盖原文档
+	if err := idx.Index(Document{Type: TypePost, ID: "2", Title: "Cooking notes", Body: "No more paper."}); err != nil {
+		t.Fatal(err)
+	}
+	if hits, _, _ := idx.Search(Query{Keyword: "graph", Types: []string{TypePost}}); !equalIDs(hitIDs(hits), []string{"post:1", "post:3"}) {
+		t.Errorf("after overwrite got %v", hitIDs(hits))
+	}
+
+	// 不同类型的相同 ID 互不影响, 删除不存在的文档不报错
+	if err := idx.Delete(TypeUser, "1", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Delete(TypePost, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if hits, _, _ := idx.Search(Query{Keyword: "graph", Types: []string{TypePost}}); !equalIDs(hitIDs(hits), []string{"post:3"}) {
+		t.Errorf("after delete got %v", hitIDs(hits))
+	}
+
+	if err := idx.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if hits, total, _ := idx.Search(Query{Keyword: "graph"}); len(hits) != 0 || total != 0 {
+		t.Errorf("after reset got %v (total %d)", hitIDs(hits), total)
+	}
+}
@@ -0,0 +1,137 @@
+package search
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MySQLIndex 基于 search_documents 表的 FULLTEXT(ngram) 索引, 按 MATCH ... AGAINST 的相关度排序
+type MySQLIndex struct{}
+
+func NewMySQLIndex() *MySQLIndex {
+	return &MySQLIndex{}
+}
+
+// EnsureFulltextIndex 创建全文索引, AutoMigrate 无法声明 FULLTEXT, 需要单独执行
+// ngram 解析器用于支持中文分词
+func EnsureFulltextIndex() error {
+	var count int
+	if err := global.DB.Raw(`SELECT COUNT(*) FROM information_schema.statistics
+		WHERE table_schema = DATABASE() AND table_name = 'search_documents' AND index_name = 'ft_search'`).
+		Row().Scan(&count); err != nil {
+		return errors.Wrap(err, "查询全文索引失败")
+	}
+	if count > 0 {
+		return nil
+	}
+	return global.DB.Exec("ALTER TABLE search_documents ADD FULLTEXT INDEX ft_search (title, body, tags) WITH PARSER ngram").Error
+}
+
+func (m *MySQLIndex) Index(doc Document) error {
+	row := database.SearchDocument{
+		DocType:    doc.Type,
+		DocID:      doc.ID,
+		Title:      doc.Title,
+		Body:       doc.Body,
+		Tags:       JoinTags(doc.Tags),
+		AuthorUUID: doc.AuthorUUID,
+		PostID:     doc.PostID,
+		CreatedAt:  doc.CreatedAt,
+	}
+	return global.DB.Exec(`INSERT INTO search_documents (doc_type, doc_id, title, body, tags, author_uuid, post_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE title = VALUES(title), body = VALUES(body), tags = VALUES(tags),
+			author_uuid = VALUES(author_uuid), post_id = VALUES(post_id), created_at = VALUES(created_at)`,
+		row.DocType, row.DocID, row.Title, row.Body, row.Tags, row.AuthorUUID, row.PostID, row.CreatedAt).Error
+}
+
+func (m *MySQLIndex) Delete(docType string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return global.DB.Where("doc_type = ? AND doc_id IN (?)", docType, ids).
+		Delete(&database.SearchDocument{}).Error
+}
+
+func (m *MySQLIndex) Reset() error {
+	return global.DB.Exec("DELETE FROM search_documents").Error
+}
+
+func (m *MySQLIndex) Search(q Query) ([]Hit, int64, error) {
+	normalizePage(&q)
+	terms := Terms(q.Keyword)
+	if len(terms) == 0 {
+		return []Hit{}, 0, nil
+	}
+	against := strings.Join(terms, " ")
+
+	db := global.DB.Model(&database.SearchDocument{}).
+		Where("MATCH(title, body, tags) AGAINST (? IN NATURAL LANGUAGE MODE)", against)
+	if len(q.Types) > 0 {
+		db = db.Where("doc_type IN (?)", q.Types)
+	}
+	if q.AuthorUUID != "" {
+		db = db.Where("author_uuid = ?", q.AuthorUUID)
+	}
+	if q.From != nil {
+		db = db.Where("created_at >= ?", *q.From)
+	}
+	if q.To != nil {
+		db = db.Where("created_at <= ?", *q.To)
+	}
+	if tag := strings.ToLower(strings.TrimSpace(q.Tag)); tag != "" {
+		db = db.Where("tags LIKE ?", "%\n"+escapeLike(tag)+"\n%")
+	}
+	if q.PostCondition != "" {
+		args := append([]interface{}{TypeUser}, q.PostArgs...)
+		db = db.Where("doc_type = ? OR post_id IN (SELECT posts.id FROM posts WHERE "+q.PostCondition+")", args...)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "统计搜索结果失败")
+	}
+
+	var rows []struct {
+		DocType    string
+		DocID      string
+		Title      string
+		Body       string
+		AuthorUUID string
+		PostID     uint
+		CreatedAt  time.Time
+		Score      float64
+	}
+	if err := db.Select("doc_type, doc_id, title, body, author_uuid, post_id, created_at, "+
+		"MATCH(title, body, tags) AGAINST (? IN NATURAL LANGUAGE MODE) AS score", against).
+		Order("score desc, created_at desc").
+		Limit(q.PageSize).
+		Offset((q.PageNum - 1) * q.PageSize).
+		Scan(&rows).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "搜索失败")
+	}
+
+	hits := make([]Hit, 0, len(rows))
+	for _, r := range rows {
+		hits = append(hits, Hit{
+			Type:       r.DocType,
+			ID:         r.DocID,
+			Score:      r.Score,
+			Title:      markTerms(r.Title, terms),
+			Snippet:    Highlight(r.Body, terms, 0),
+			AuthorUUID: r.AuthorUUID,
+			PostID:     r.PostID,
+			CreatedAt:  r.CreatedAt,
+		})
+	}
+	return hits, total, nil
+}
+
+// escapeLike 转义 LIKE 通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
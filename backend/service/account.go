@@ -0,0 +1,391 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"fmt"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	jgorm "github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+const mergeTokenPurpose = "merge"
+
+// UnbindAccount 解绑某种登录方式, 不允许解绑最后一种登录方式
+func UnbindAccount(uuid string, provider AuthProvider) error {
+	switch provider {
+	case ProviderEmail, ProviderGitHub, ProviderGoogle:
+	default:
+		return errors.New("不支持的登录方式")
+	}
+
+	var accounts []database.AuthAccount
+	if err := global.DB.Where("profile_uuid = ?", uuid).Find(&accounts).Error; err != nil {
+		return errors.New("查询绑定信息失败")
+	}
+
+	bound, others := 0, 0
+	for _, a := range accounts {
+		if a.Provider == string(provider) {
+			bound++
+		} else {
+			others++
+		}
+	}
+	if bound == 0 {
+		return errors.New("尚未绑定该登录方式")
+	}
+	if others == 0 {
+		return errors.New("不能解绑最后一种登录方式")
+	}
+
+	return global.DB.Transaction(func(tx *jgorm.DB) error {
+		if err := tx.Where("profile_uuid = ? AND provider = ?", uuid, string(provider)).
+			Delete(&database.AuthAccount{}).Error; err != nil {
+			return errors.New("解绑失败")
+		}
+		if err := updateProfileSystem(tx, uuid, providerBoundUpdate(provider, false)); err != nil {
+			return errors.New("更新绑定状态失败")
+		}
+		return nil
+	})
+}
+
+// GenerateMergeToken 生成账号合并凭证
+// 只有在同一会话中证明了两个账号的所有权（已登录 into 账号, 且通过 from 账号的登录方式验证）时才会下发
+func GenerateMergeToken(fromUUID, intoUUID string) (string, error) {
+	claims := jwt.MapClaims{
+		"purpose":    mergeTokenPurpose,
+		"merge_from": fromUUID,
+		"merge_into": intoUUID,
+		"exp":        time.Now().Add(10 * time.Minute).Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(global.VP.GetString("jwt.secret")))
+}
+
+// parseMergeToken 解析合并凭证, 返回被合并账号和保留账号的 UUID
+func parseMergeToken(tokenString string) (fromUUID, intoUUID string, err error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(global.VP.GetString("jwt.secret")), nil
+	})
+	if err != nil || !token.Valid {
+		return "", "", errors.New("合并凭证无效或已过期")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != mergeTokenPurpose {
+		return "", "", errors.New("合并凭证无效")
+	}
+	fromUUID, _ = claims["merge_from"].(string)
+	intoUUID, _ = claims["merge_into"].(string)
+	if fromUUID == "" || intoUUID == "" {
+		return "", "", errors.New("合并凭证无效")
+	}
+	return fromUUID, intoUUID, nil
+}
+
+// MergeAccount 将合并凭证中的 from 账号合并到当前登录账号, from 账号在合并后删除
+// 被合并账号开启了两步验证时, 需要提供该账号的验证码, 避免绕过其两步验证
+func MergeAccount(currentUUID string, mergeToken string, totpCode string) error {
+	fromUUID, intoUUID, err := parseMergeToken(mergeToken)
+	if err != nil {
+		return err
+	}
+	if intoUUID != currentUUID {
+		return errors.New("合并凭证与当前账号不匹配")
+	}
+	if fromUUID == intoUUID {
+		return errors.New("不能合并同一个账号")
+	}
+
+	var from, into database.User
+	if err := global.DB.Where("uuid = ?", fromUUID).First(&from).Error; err != nil {
+		return errors.New("待合并的账号不存在")
+	}
+	if err := global.DB.Where("uuid = ?", intoUUID).First(&into).Error; err != nil {
+		return errors.New("当前账号不存在")
+	}
+	if from.TOTPEnabled {
+		if err := verifyTwoFactorCode(from, totpCode); err != nil {
+			return errors.Wrap(err, "待合并账号的两步验证失败")
+		}
+	}
+
+	var affectedPosts []uint
+	err = global.DB.Transaction(func(tx *jgorm.DB) error {
+		var err error
+		affectedPosts, err = mergeUserData(tx, from, into)
+		return err
+	})
+	if err != nil {
+		return errors.Wrap(err, "合并账号失败")
+	}
+
+	// 去重后点赞/收藏数可能偏大, 重新统计受影响的帖子
+	for _, postID := range affectedPosts {
+		_ = UpdatePostInfo(postID)
+	}
+	// 帖子、评论的作者已变更, 同步搜索索引
+	unindexUser(fromUUID)
+	reindexAuthor(intoUUID)
+	return nil
+}
+
+// mergeUserData 在事务中把 from 的所有数据迁移到 into, 返回需要重新统计计数的帖子
+func mergeUserData(tx *jgorm.DB, from, into database.User) ([]uint, error) {
+	var affectedPosts []uint
+
+	// 1. 帖子与评论（包括已删除的）
+	if err := tx.Unscoped().Model(&database.Post{}).
+		Where("author_uuid = ?", from.UUID).
+		UpdateColumn("author_uuid", into.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "迁移帖子失败")
+	}
+	if err := tx.Unscoped().Model(&database.PostComment{}).
+		Where("author_uuid = ?", from.UUID).
+		UpdateColumn("author_uuid", into.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "迁移评论失败")
+	}
+	if err := tx.Model(&database.PostRevision{}).
+		Where("editor_uuid = ?", from.UUID).
+		UpdateColumn("editor_uuid", into.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "迁移帖子修改记录失败")
+	}
+	if err := tx.Unscoped().Model(&database.Attachment{}).
+		Where("uploader_uuid = ?", from.UUID).
+		UpdateColumn("uploader_uuid", into.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "迁移附件失败")
+	}
+	if err := tx.Exec("UPDATE post_views SET viewer_key = ? WHERE viewer_key = ?", into.UUID, from.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "迁移浏览记录失败")
+	}
+	// 两个账号纯转发过同一个帖子的只保留较早的一条
+	var duplicateReposts []struct {
+		ID         uint
+		RepostOfID uint
+	}
+	if err := tx.Raw(`SELECT p.id, p.repost_of_id FROM posts p
+		JOIN posts q ON q.author_uuid = p.author_uuid AND q.repost_of_id = p.repost_of_id
+			AND q.content = '' AND q.deleted_at IS NULL AND q.id < p.id
+		WHERE p.author_uuid = ? AND p.content = '' AND p.deleted_at IS NULL`, into.UUID).Scan(&duplicateReposts).Error; err != nil {
+		return nil, errors.Wrap(err, "查询重复转发失败")
+	}
+	for _, r := range duplicateReposts {
+		if err := tx.Where("id = ?", r.ID).Delete(&database.Post{}).Error; err != nil {
+			return nil, errors.Wrap(err, "删除重复转发失败")
+		}
+		affectedPosts = append(affectedPosts, r.RepostOfID)
+	}
+	// 两个账号都参与过的投票保留 into 的选择, 撤回 from 的票
+	if err := tx.Exec(`UPDATE poll_options SET vote_count = GREATEST(vote_count - 1, 0) WHERE id IN (
+			SELECT option_id FROM poll_votes WHERE user_uuid = ? AND post_id IN (SELECT post_id FROM poll_votes WHERE user_uuid = ?))`,
+		from.UUID, into.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "合并投票失败")
+	}
+	if err := tx.Exec(`UPDATE polls SET voter_count = GREATEST(voter_count - 1, 0)
+		WHERE post_id IN (SELECT post_id FROM poll_votes WHERE user_uuid = ?)
+			AND post_id IN (SELECT post_id FROM poll_votes WHERE user_uuid = ?)`,
+		from.UUID, into.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "合并投票失败")
+	}
+	// MySQL 不允许在 DELETE 的子查询中直接引用同一张表, 用派生表包一层
+	if err := tx.Exec(`DELETE FROM poll_votes WHERE user_uuid = ? AND post_id IN (
+			SELECT post_id FROM (SELECT DISTINCT post_id FROM poll_votes WHERE user_uuid = ?) AS v)`, from.UUID, into.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "合并投票失败")
+	}
+	if err := tx.Exec("UPDATE poll_votes SET user_uuid = ? WHERE user_uuid = ?", into.UUID, from.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "合并投票失败")
+	}
+	if err := tx.Exec("UPDATE post_daily_stats SET author_uuid = ? WHERE author_uuid = ?", into.UUID, from.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "迁移帖子统计失败")
+	}
+	// 关注者统计按日期累加到保留的账号
+	if err := tx.Exec(`INSERT INTO user_daily_stats (user_uuid, date, new_followers, lost_followers)
+		SELECT ?, s.date, s.new_followers, s.lost_followers
+		FROM (SELECT date, new_followers, lost_followers FROM user_daily_stats WHERE user_uuid = ?) AS s
+		ON DUPLICATE KEY UPDATE new_followers = user_daily_stats.new_followers + VALUES(new_followers),
+			lost_followers = user_daily_stats.lost_followers + VALUES(lost_followers)`, into.UUID, from.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "迁移关注统计失败")
+	}
+	if err := tx.Exec("DELETE FROM user_daily_stats WHERE user_uuid = ?", from.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "迁移关注统计失败")
+	}
+
+	// 2. 帖子点赞 / 收藏: 两个账号都点过的只保留一条
+	for _, table := range []string{"user_post_likes", "user_post_favorites"} {
+		postIDs, err := duplicateActiveKeys(tx, table, "post_id", from.UUID, into.UUID)
+		if err != nil {
+			return nil, err
+		}
+		affectedPosts = append(affectedPosts, postIDs...)
+		if err := mergeUserRows(tx, table, "user_id", "post_id", from.UUID, into.UUID); err != nil {
+			return nil, err
+		}
+	}
+
+	// 3. 评论点赞: 重复的点赞需要同步扣减评论点赞数
+	commentIDs, err := duplicateActiveKeys(tx, "comment_likes", "comment_id", from.UUID, into.UUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := mergeUserRows(tx, "comment_likes", "user_id", "comment_id", from.UUID, into.UUID); err != nil {
+		return nil, err
+	}
+	if len(commentIDs) > 0 {
+		if err := tx.Exec("UPDATE post_comments SET like_number = GREATEST(like_number - 1, 0) WHERE id IN (?)", commentIDs).Error; err != nil {
+			return nil, errors.Wrap(err, "更新评论点赞数失败")
+		}
+	}
+
+	// 4. 关注关系: 我关注的人 + 关注我的人 + 关注的标签, 并去掉合并后产生的自己关注自己
+	if err := mergeUserRows(tx, "user_follows", "user_id", "follow_id", from.UUID, into.UUID); err != nil {
+		return nil, err
+	}
+	if err := mergeUserRows(tx, "user_follows", "follow_id", "user_id", from.UUID, into.UUID); err != nil {
+		return nil, err
+	}
+	if err := tx.Exec("DELETE FROM user_follows WHERE user_id = ? AND follow_id = ?", into.UUID, into.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "清理关注关系失败")
+	}
+	if err := mergeUserRows(tx, "tag_follows", "user_uuid", "tag_id", from.UUID, into.UUID); err != nil {
+		return nil, err
+	}
+
+	// 5. 聊天记录, 两个账号之间的对话直接删除
+	if err := tx.Exec(`DELETE FROM chat_messages WHERE (sender_uuid = ? AND receiver_uuid = ?) OR (sender_uuid = ? AND receiver_uuid = ?)`,
+		from.UUID, into.UUID, into.UUID, from.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "清理聊天记录失败")
+	}
+	if err := tx.Exec("UPDATE chat_messages SET sender_uuid = ? WHERE sender_uuid = ?", into.UUID, from.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "迁移聊天记录失败")
+	}
+	if err := tx.Exec("UPDATE chat_messages SET receiver_uuid = ? WHERE receiver_uuid = ?", into.UUID, from.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "迁移聊天记录失败")
+	}
+
+	// 6. 匹配记录, 两个账号之间的匹配直接删除
+	if err := tx.Exec(`DELETE FROM match_results WHERE (user_uuid = ? AND match_uuid = ?) OR (user_uuid = ? AND match_uuid = ?)`,
+		from.UUID, into.UUID, into.UUID, from.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "清理匹配记录失败")
+	}
+	if err := tx.Exec("UPDATE match_results SET user_uuid = ? WHERE user_uuid = ?", into.UUID, from.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "迁移匹配记录失败")
+	}
+	if err := tx.Exec("UPDATE match_results SET match_uuid = ? WHERE match_uuid = ?", into.UUID, from.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "迁移匹配记录失败")
+	}
+
+	// 7. 提及与通知: 两人在同一处都被提及的只保留一条, 并去掉合并后产生的自己通知自己
+	if err := tx.Exec(`DELETE a FROM mentions a JOIN mentions b ON a.post_id = b.post_id AND a.comment_id = b.comment_id
+		WHERE a.user_uuid = ? AND b.user_uuid = ?`, from.UUID, into.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "合并提及失败")
+	}
+	if err := tx.Exec("UPDATE mentions SET user_uuid = ? WHERE user_uuid = ?", into.UUID, from.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "合并提及失败")
+	}
+	if err := tx.Exec("UPDATE notifications SET user_uuid = ? WHERE user_uuid = ?", into.UUID, from.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "迁移通知失败")
+	}
+	if err := tx.Exec("UPDATE notifications SET actor_uuid = ? WHERE actor_uuid = ?", into.UUID, from.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "迁移通知失败")
+	}
+	if err := tx.Exec("DELETE FROM notifications WHERE user_uuid = ? AND actor_uuid = ?", into.UUID, into.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "清理通知失败")
+	}
+
+	// 8. 登录方式
+	if err := tx.Model(&database.AuthAccount{}).
+		Where("profile_uuid = ?", from.UUID).
+		UpdateColumn("profile_uuid", into.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "迁移登录方式失败")
+	}
+	if err := tx.Model(&database.APIKey{}).
+		Where("user_uuid = ?", from.UUID).
+		UpdateColumn("user_uuid", into.UUID).Error; err != nil {
+		return nil, errors.Wrap(err, "迁移 API Key 失败")
+	}
+
+	// 9. 合并用户表字段, 然后删除 from 账号
+	coin := into.Coin + from.Coin
+	emailBound := into.IsEmailBound || from.IsEmailBound
+	githubBound := into.IsGitHubBound || from.IsGitHubBound
+	googleBound := into.IsGoogleBound || from.IsGoogleBound
+	system := ProfileSystemUpdate{
+		Coin:          &coin,
+		IsEmailBound:  &emailBound,
+		IsGitHubBound: &githubBound,
+		IsGoogleBound: &googleBound,
+	}
+	if !into.IsVerified && from.IsVerified {
+		system.IsVerified = &from.IsVerified
+		system.Institution = &from.Institution
+		system.AcademicEmail = from.AcademicEmail
+		system.VerifiedAt = from.VerifiedAt
+		// 机构邮箱有唯一索引, 先从 from 账号上移除再转给 into
+		if err := tx.Model(&database.User{}).Where("uuid = ?", from.UUID).
+			UpdateColumn("academic_email", nil).Error; err != nil {
+			return nil, errors.Wrap(err, "迁移认证信息失败")
+		}
+	}
+	if into.Email == "" && from.Email != "" {
+		system.Email = &from.Email
+	}
+	if err := updateProfileSystem(tx, into.UUID, system); err != nil {
+		return nil, errors.Wrap(err, "更新用户信息失败")
+	}
+	if err := tx.Where("user_uuid = ?", from.UUID).Delete(&database.RecoveryCode{}).Error; err != nil {
+		return nil, errors.Wrap(err, "清理恢复码失败")
+	}
+	if err := tx.Where("user_uuid = ?", from.UUID).Delete(&database.UserTag{}).Error; err != nil {
+		return nil, errors.Wrap(err, "清理用户标签失败")
+	}
+	if err := tx.Where("uuid = ?", from.UUID).Delete(&database.User{}).Error; err != nil {
+		return nil, errors.Wrap(err, "删除旧账号失败")
+	}
+
+	return affectedPosts, nil
+}
+
+// duplicateActiveKeys 查询两个用户都有有效记录的 keyCol 值
+func duplicateActiveKeys(tx *jgorm.DB, table, keyCol, fromUUID, intoUUID string) ([]uint, error) {
+	var rows []struct {
+		Key uint
+	}
+	if err := tx.Raw(fmt.Sprintf(`SELECT a.%[2]s AS `+"`key`"+` FROM %[1]s a JOIN %[1]s b ON a.%[2]s = b.%[2]s
+		WHERE a.user_id = ? AND b.user_id = ? AND a.deleted_at IS NULL AND b.deleted_at IS NULL`, table, keyCol),
+		fromUUID, intoUUID).Scan(&rows).Error; err != nil {
+		return nil, errors.Wrapf(err, "查询 %s 重复记录失败", table)
+	}
+	keys := make([]uint, 0, len(rows))
+	for _, r := range rows {
+		keys = append(keys, r.Key)
+	}
+	return keys, nil
+}
+
+// mergeUserRows 把关系表中 ownerCol = from 的记录迁移给 into
+// 以 keyCol 去重: into 已有的有效记录优先保留, into 已删除而 from 仍存在的记录被 from 的记录替换
+func mergeUserRows(tx *jgorm.DB, table, ownerCol, keyCol, fromUUID, intoUUID string) error {
+	// into 已软删除、from 也有记录的, 删除 into 的那条
+	if err := tx.Exec(fmt.Sprintf(`DELETE a FROM %[1]s a JOIN %[1]s b ON a.%[3]s = b.%[3]s
+		WHERE a.%[2]s = ? AND b.%[2]s = ? AND a.deleted_at IS NOT NULL`, table, ownerCol, keyCol),
+		intoUUID, fromUUID).Error; err != nil {
+		return errors.Wrapf(err, "合并 %s 失败", table)
+	}
+	// into 仍保留的, 删除 from 的重复记录
+	if err := tx.Exec(fmt.Sprintf(`DELETE a FROM %[1]s a JOIN %[1]s b ON a.%[3]s = b.%[3]s
+		WHERE a.%[2]s = ? AND b.%[2]s = ?`, table, ownerCol, keyCol),
+		fromUUID, intoUUID).Error; err != nil {
+		return errors.Wrapf(err, "合并 %s 失败", table)
+	}
+	if err := tx.Exec(fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", table, ownerCol, ownerCol),
+		intoUUID, fromUUID).Error; err != nil {
+		return errors.Wrapf(err, "合并 %s 失败", table)
+	}
+	return nil
+}
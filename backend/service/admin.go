@@ -0,0 +1,343 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"OpenHouse/model/response"
+	"OpenHouse/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	jgorm "github.com/jinzhu/gorm"
+)
+
+// writeModerationLog 记录一次管理操作
+func writeModerationLog(db *jgorm.DB, actorUUID, action, targetType, targetID, reason string) error {
+	return db.Create(&database.ModerationLog{
+		ActorUUID:  actorUUID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     reason,
+		CreatedAt:  time.Now(),
+	}).Error
+}
+
+func toAdminUserInfo(u database.User) response.AdminUserInfo {
+	role := u.Role
+	if role == "" {
+		role = utils.RoleUser
+	}
+	perms := utils.ParseTags(u.Permissions)
+	if perms == nil {
+		perms = []string{}
+	}
+	return response.AdminUserInfo{
+		UUID:        u.UUID,
+		Username:    u.Username,
+		Email:       u.Email,
+		AvatarURL:   u.AvatarURL,
+		CreatedAt:   u.CreatedAt,
+		IsVerified:  u.IsVerified,
+		Institution: u.Institution,
+		Role:        role,
+		Permissions: perms,
+		IsBanned:    u.IsBanned,
+		BanReason:   u.BanReason,
+		BannedAt:    u.BannedAt,
+	}
+}
+
+// AdminSearchUsers 按 UUID / 用户名 / 邮箱模糊查询用户
+func AdminSearchUsers(keyword string, pageNum, pageSize int) ([]response.AdminUserInfo, int64, error) {
+	var users []database.User
+	var total int64
+
+	db := global.DB.Model(&database.User{})
+	if keyword = strings.TrimSpace(keyword); keyword != "" {
+		like := "%" + keyword + "%"
+		db = db.Where("uuid = ? OR username LIKE ? OR email LIKE ? OR academic_email LIKE ?", keyword, like, like, like)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Order("created_at desc").
+		Limit(pageSize).
+		Offset((pageNum - 1) * pageSize).
+		Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]response.AdminUserInfo, 0, len(users))
+	for _, u := range users {
+		result = append(result, toAdminUserInfo(u))
+	}
+	return result, total, nil
+}
+
+// AdminGetUser 查询单个用户详情及内容统计
+func AdminGetUser(uuid string) (response.AdminUserDetail, error) {
+	var user database.User
+	if err := global.DB.Where("uuid = ?", uuid).First(&user).Error; err != nil {
+		return response.AdminUserDetail{}, errors.New("用户不存在")
+	}
+
+	detail := response.AdminUserDetail{AdminUserInfo: toAdminUserInfo(user)}
+	global.DB.Model(&database.Post{}).Where("author_uuid = ?", uuid).Count(&detail.PostCount)
+	global.DB.Model(&database.PostComment{}).Where("author_uuid = ?", uuid).Count(&detail.CommentCount)
+	following, follower, err := GetFollowCount(uuid)
+	if err == nil {
+		detail.FollowingCount = following
+		detail.FollowerCount = follower
+	}
+	return detail, nil
+}
+
+// loadModerationTarget 查询操作人和被操作用户, 任何人不能操作自己
+// 非管理员只能操作权限不超过自己的账号, 见 utils.CanManage
+func loadModerationTarget(actorUUID, targetUUID string) (database.User, database.User, error) {
+	var actor, target database.User
+	if actorUUID == targetUUID {
+		return actor, target, errors.New("不能对自己执行该操作")
+	}
+	if err := global.DB.Where("uuid = ?", actorUUID).First(&actor).Error; err != nil {
+		return actor, target, errors.New("操作人不存在")
+	}
+	if err := global.DB.Where("uuid = ?", targetUUID).First(&target).Error; err != nil {
+		return actor, target, errors.New("用户不存在")
+	}
+	if target.Role == utils.RoleAdmin && actor.Role != utils.RoleAdmin {
+		return actor, target, errors.New("无权操作管理员账号")
+	}
+	if !utils.CanManage(actor.Role, actor.Permissions, target.Role, target.Permissions) {
+		return actor, target, errors.New("无权操作拥有自己没有的权限的账号")
+	}
+	return actor, target, nil
+}
+
+// AdminBanUser 封禁用户, 封禁后已签发的 token 立即失效
+func AdminBanUser(actorUUID, targetUUID, reason string) error {
+	_, target, err := loadModerationTarget(actorUUID, targetUUID)
+	if err != nil {
+		return err
+	}
+	if target.IsBanned {
+		return errors.New("该用户已被封禁")
+	}
+
+	now := time.Now()
+	return global.DB.Transaction(func(tx *jgorm.DB) error {
+		if err := tx.Model(&database.User{}).Where("uuid = ?", targetUUID).Updates(map[string]interface{}{
+			"is_banned":  true,
+			"ban_reason": reason,
+			"banned_at":  now,
+		}).Error; err != nil {
+			return errors.New("封禁失败")
+		}
+		return writeModerationLog(tx, actorUUID, "ban", "user", targetUUID, reason)
+	})
+}
+
+// AdminUnbanUser 解除封禁
+func AdminUnbanUser(actorUUID, targetUUID string) error {
+	_, target, err := loadModerationTarget(actorUUID, targetUUID)
+	if err != nil {
+		return err
+	}
+	if !target.IsBanned {
+		return errors.New("该用户未被封禁")
+	}
+
+	return global.DB.Transaction(func(tx *jgorm.DB) error {
+		if err := tx.Model(&database.User{}).Where("uuid = ?", targetUUID).Updates(map[string]interface{}{
+			"is_banned":  false,
+			"ban_reason": "",
+			"banned_at":  nil,
+		}).Error; err != nil {
+			return errors.New("解封失败")
+		}
+		return writeModerationLog(tx, actorUUID, "unban", "user", targetUUID, "")
+	})
+}
+
+// AdminSetUserRole 修改用户角色及额外权限
+func AdminSetUserRole(actorUUID, targetUUID, role string, permissions []string) error {
+	if !utils.IsValidRole(role) {
+		return errors.New("角色不存在")
+	}
+	for _, p := range permissions {
+		if !utils.IsValidPermission(p) {
+			return fmt.Errorf("权限 %s 不存在", p)
+		}
+	}
+	actor, _, err := loadModerationTarget(actorUUID, targetUUID)
+	if err != nil {
+		return err
+	}
+	if !utils.CanGrant(actor.Role, actor.Permissions, role, permissions) {
+		return errors.New("只能授予自己已有的权限, 管理员角色只能由管理员授予")
+	}
+
+	if permissions == nil {
+		permissions = []string{}
+	}
+	permJSON, err := json.Marshal(permissions)
+	if err != nil {
+		return errors.New("权限序列化失败")
+	}
+
+	return global.DB.Transaction(func(tx *jgorm.DB) error {
+		if err := tx.Model(&database.User{}).Where("uuid = ?", targetUUID).Updates(map[string]interface{}{
+			"role":        role,
+			"permissions": permJSON,
+		}).Error; err != nil {
+			return errors.New("修改角色失败")
+		}
+		reason := fmt.Sprintf("role=%s permissions=%s", role, strings.Join(permissions, ","))
+		return writeModerationLog(tx, actorUUID, "set_role", "user", targetUUID, reason)
+	})
+}
+
+// AdminTakedownPost 下架帖子（级联删除评论、点赞、收藏）
+func AdminTakedownPost(actorUUID string, postID uint, reason string) error {
+	var post database.Post
+	if err := global.DB.First(&post, postID).Error; err != nil {
+		return errors.New("帖子不存在")
+	}
+	if err := removePost(postID); err != nil {
+		return err
+	}
+	return writeModerationLog(global.DB, actorUUID, "takedown_post", "post", fmt.Sprint(postID), reason)
+}
+
+// AdminTakedownComment 下架评论及其子评论
+func AdminTakedownComment(actorUUID string, commentID uint, reason string) error {
+	var comment database.PostComment
+	if err := global.DB.First(&comment, commentID).Error; err != nil {
+		return errors.New("评论不存在")
+	}
+
+	var commentIDs []uint
+	if err := global.DB.Model(&database.PostComment{}).
+		Where("id = ? OR comment_id = ?", commentID, commentID).
+		Pluck("id", &commentIDs).Error; err != nil {
+		return errors.New("查询评论失败")
+	}
+
+	err := global.DB.Transaction(func(tx *jgorm.DB) error {
+		if err := tx.Where("id IN (?)", commentIDs).
+			Delete(&database.PostComment{}).Error; err != nil {
+			return errors.New("删除评论失败")
+		}
+		if err := tx.Where("comment_id IN (?)", commentIDs).
+			Delete(&database.CommentLike{}).Error; err != nil {
+			return errors.New("删除评论点赞失败")
+		}
+		if err := removeMentions(tx, comment.PostID, commentIDs...); err != nil {
+			return errors.New("删除评论提及失败")
+		}
+		return writeModerationLog(tx, actorUUID, "takedown_comment", "comment", fmt.Sprint(commentID), reason)
+	})
+	if err != nil {
+		return err
+	}
+	unindexComments(commentIDs...)
+
+	// 重新统计帖子评论数
+	_ = UpdatePostInfo(comment.PostID)
+	return nil
+}
+
+// AdminListModerationLogs 分页查询管理操作记录
+func AdminListModerationLogs(pageNum, pageSize int) ([]response.ModerationLogInfo, int64, error) {
+	var logs []database.ModerationLog
+	var total int64
+
+	db := global.DB.Model(&database.ModerationLog{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Order("id desc").
+		Limit(pageSize).
+		Offset((pageNum - 1) * pageSize).
+		Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	actorUUIDs := make([]string, 0, len(logs))
+	for _, l := range logs {
+		actorUUIDs = append(actorUUIDs, l.ActorUUID)
+	}
+	userMap := make(map[string]database.User)
+	if len(actorUUIDs) > 0 {
+		var users []database.User
+		_ = global.DB.Where("uuid IN (?)", actorUUIDs).Find(&users)
+		for _, u := range users {
+			userMap[u.UUID] = u
+		}
+	}
+
+	result := make([]response.ModerationLogInfo, 0, len(logs))
+	for _, l := range logs {
+		result = append(result, response.ModerationLogInfo{
+			ID:            l.ID,
+			ActorUUID:     l.ActorUUID,
+			ActorUsername: userMap[l.ActorUUID].Username,
+			Action:        l.Action,
+			TargetType:    l.TargetType,
+			TargetID:      l.TargetID,
+			Reason:        l.Reason,
+			CreatedAt:     l.CreatedAt,
+		})
+	}
+	return result, total, nil
+}
+
+// sensitiveConfigKeys 配置项名称中包含这些片段的值会被隐藏
+var sensitiveConfigKeys = []string{"secret", "password", "pass", "key", "token", "dsn"}
+
+// AdminGetConfig 返回当前运行配置, 敏感字段打码
+func AdminGetConfig() map[string]interface{} {
+	return maskConfig(global.VP.AllSettings())
+}
+
+func maskConfig(settings map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(settings))
+	for k, v := range settings {
+		if sub, ok := v.(map[string]interface{}); ok {
+			result[k] = maskConfig(sub)
+			continue
+		}
+		masked := false
+		lower := strings.ToLower(k)
+		for _, s := range sensitiveConfigKeys {
+			if strings.Contains(lower, s) {
+				masked = true
+				break
+			}
+		}
+		if masked {
+			result[k] = "******"
+		} else {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// EnsureAdmins 将配置文件 admin.uuids 中的用户设为管理员, 用于初始化第一个管理员
+func EnsureAdmins() {
+	uuids := global.VP.GetStringSlice("admin.uuids")
+	if len(uuids) == 0 {
+		return
+	}
+	if err := global.DB.Model(&database.User{}).
+		Where("uuid IN (?)", uuids).
+		Update("role", utils.RoleAdmin).Error; err != nil {
+		log.Println("[Admin] 初始化管理员失败:", err)
+	}
+}
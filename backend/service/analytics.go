@@ -0,0 +1,180 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"OpenHouse/model/response"
+	"fmt"
+	"time"
+
+	jgorm "github.com/jinzhu/gorm"
+)
+
+const (
+	defaultAnalyticsDays = 30
+	defaultAnalyticsTop  = 5
+	analyticsBackfill    = 90 // 汇总表为空时补算最近多少天
+	statDateLayout       = "2006-01-02"
+)
+
+// postStatSources 帖子每日汇总的各项指标及其来源, 点赞 / 收藏只统计当天产生且仍然有效的记录
+var postStatSources = []struct {
+	column string
+	query  string
+}{
+	{"views", "SELECT post_id, COUNT(*) AS n FROM post_views WHERE created_at >= ? AND created_at < ? GROUP BY post_id"},
+	{"likes", "SELECT post_id, COUNT(*) AS n FROM user_post_likes WHERE deleted_at IS NULL AND created_at >= ? AND created_at < ? GROUP BY post_id"},
+	{"favorites", "SELECT post_id, COUNT(*) AS n FROM user_post_favorites WHERE deleted_at IS NULL AND created_at >= ? AND created_at < ? GROUP BY post_id"},
+	{"comments", "SELECT post_id, COUNT(*) AS n FROM post_comments WHERE deleted_at IS NULL AND create_time >= ? AND create_time < ? GROUP BY post_id"},
+}
+
+// analyticsBackfilled 本进程是否已经检查过历史数据补算
+var analyticsBackfilled bool
+
+// RollupDailyStats 重新汇总昨天和今天的帖子 / 关注者数据, 由定时任务每小时调用
+// 首次运行且汇总表为空时补算最近 analyticsBackfill 天
+func RollupDailyStats() error {
+	days := 2
+	if !analyticsBackfilled {
+		var posts, users int
+		global.DB.Model(&database.PostDailyStat{}).Count(&posts)
+		global.DB.Model(&database.UserDailyStat{}).Count(&users)
+		if posts == 0 && users == 0 {
+			days = analyticsBackfill
+		}
+	}
+
+	today := startOfDay(time.Now())
+	for i := days - 1; i >= 0; i-- {
+		if err := rollupDay(today.AddDate(0, 0, -i)); err != nil {
+			return err
+		}
+	}
+	analyticsBackfilled = true
+	return nil
+}
+
+// rollupDay 用当天的原始记录覆盖该日期的汇总, 可以重复执行
+func rollupDay(day time.Time) error {
+	date := day.Format(statDateLayout)
+	start, end := day, day.AddDate(0, 0, 1)
+
+	return global.DB.Transaction(func(tx *jgorm.DB) error {
+		if err := tx.Exec("DELETE FROM post_daily_stats WHERE date = ?", date).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM user_daily_stats WHERE date = ?", date).Error; err != nil {
+			return err
+		}
+
+		for _, src := range postStatSources {
+			sql := fmt.Sprintf(`INSERT INTO post_daily_stats (post_id, author_uuid, date, %[1]s)
+				SELECT s.post_id, posts.author_uuid, ?, s.n FROM (%[2]s) AS s
+				JOIN posts ON posts.id = s.post_id AND posts.deleted_at IS NULL
+				ON DUPLICATE KEY UPDATE %[1]s = VALUES(%[1]s)`, src.column, src.query)
+			if err := tx.Exec(sql, date, start, end).Error; err != nil {
+				return err
+			}
+		}
+
+		// 新增关注按关注时间, 流失按取消关注时间
+		if err := tx.Exec(`INSERT INTO user_daily_stats (user_uuid, date, new_followers)
+			SELECT follow_id, ?, COUNT(*) FROM user_follows WHERE created_at >= ? AND created_at < ? GROUP BY follow_id
+			ON DUPLICATE KEY UPDATE new_followers = VALUES(new_followers)`, date, start, end).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`INSERT INTO user_daily_stats (user_uuid, date, lost_followers)
+			SELECT follow_id, ?, COUNT(*) FROM user_follows WHERE deleted_at >= ? AND deleted_at < ? GROUP BY follow_id
+			ON DUPLICATE KEY UPDATE lost_followers = VALUES(lost_followers)`, date, start, end).Error
+	})
+}
+
+// GetAuthorAnalytics 作者数据看板: 最近 days 天所有帖子的每日浏览、点赞、收藏、评论, 关注者增长和表现最好的帖子
+// 数据来自每小时更新的汇总表, 当天的数据最多延迟一小时
+func GetAuthorAnalytics(userUUID string, days, top int) (response.AuthorAnalytics, error) {
+	if days <= 0 {
+		days = defaultAnalyticsDays
+	}
+	if top <= 0 {
+		top = defaultAnalyticsTop
+	}
+	today := startOfDay(time.Now())
+	start := today.AddDate(0, 0, -(days - 1))
+	since := start.Format(statDateLayout)
+
+	var postRows []struct {
+		Date      string
+		Views     int64
+		Likes     int64
+		Favorites int64
+		Comments  int64
+	}
+	if err := global.DB.Raw(`SELECT date, SUM(views) AS views, SUM(likes) AS likes, SUM(favorites) AS favorites, SUM(comments) AS comments
+		FROM post_daily_stats WHERE author_uuid = ? AND date >= ? GROUP BY date`, userUUID, since).Scan(&postRows).Error; err != nil {
+		return response.AuthorAnalytics{}, err
+	}
+	var followRows []database.UserDailyStat
+	if err := global.DB.Where("user_uuid = ? AND date >= ?", userUUID, since).Find(&followRows).Error; err != nil {
+		return response.AuthorAnalytics{}, err
+	}
+
+	byDate := make(map[string]*response.AnalyticsDay, days)
+	result := response.AuthorAnalytics{Days: days, Daily: make([]response.AnalyticsDay, 0, days)}
+	for d := start; !d.After(today); d = d.AddDate(0, 0, 1) {
+		result.Daily = append(result.Daily, response.AnalyticsDay{Date: d.Format(statDateLayout)})
+	}
+	for i := range result.Daily {
+		byDate[result.Daily[i].Date] = &result.Daily[i]
+	}
+	for _, r := range postRows {
+		if day, ok := byDate[r.Date]; ok {
+			day.Views, day.Likes, day.Favorites, day.Comments = r.Views, r.Likes, r.Favorites, r.Comments
+		}
+	}
+	for _, r := range followRows {
+		if day, ok := byDate[r.Date]; ok {
+			day.NewFollowers, day.LostFollowers = r.NewFollowers, r.LostFollowers
+		}
+	}
+
+	// 从当前关注者数往前倒推每天结束时的关注者数
+	global.DB.Model(&database.UserFollow{}).Where("follow_id = ? AND deleted_at IS NULL", userUUID).Count(&result.Followers)
+	followers := result.Followers
+	for i := len(result.Daily) - 1; i >= 0; i-- {
+		day := &result.Daily[i]
+		day.Followers = followers
+		followers -= day.NewFollowers - day.LostFollowers
+		if followers < 0 {
+			followers = 0
+		}
+
+		result.Totals.Views += day.Views
+		result.Totals.Likes += day.Likes
+		result.Totals.Favorites += day.Favorites
+		result.Totals.Comments += day.Comments
+		result.Totals.NewFollowers += day.NewFollowers
+		result.Totals.LostFollowers += day.LostFollowers
+	}
+	result.FollowerGrowth = result.Totals.NewFollowers - result.Totals.LostFollowers
+
+	// 表现最好的帖子, 排序权重与热度分一致
+	result.TopPosts = []response.TopPost{}
+	if err := global.DB.Raw(`SELECT s.post_id, posts.title, posts.create_date,
+			SUM(s.views) AS views, SUM(s.likes) AS likes, SUM(s.favorites) AS favorites, SUM(s.comments) AS comments,
+			SUM(s.views) * ? + SUM(s.likes) * ? + SUM(s.favorites) * ? + SUM(s.comments) * ? AS score
+		FROM post_daily_stats s
+		JOIN posts ON posts.id = s.post_id AND posts.deleted_at IS NULL
+		WHERE s.author_uuid = ? AND s.date >= ?
+		GROUP BY s.post_id, posts.title, posts.create_date
+		ORDER BY score DESC, s.post_id DESC
+		LIMIT ?`, hotViewWeight, hotStarWeight, hotFavoriteWeight, hotCommentWeight,
+		userUUID, since, top).Scan(&result.TopPosts).Error; err != nil {
+		return response.AuthorAnalytics{}, err
+	}
+	return result, nil
+}
+
+// startOfDay 当天零点
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"OpenHouse/model/response"
+	"OpenHouse/utils"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	maxAPIKeysPerUser      = 20
+	defaultAPIKeyRateLimit = 60
+)
+
+func toAPIKeyInfo(k database.APIKey) response.APIKeyInfo {
+	scopes := utils.ParseTags(k.Scopes)
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return response.APIKeyInfo{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     scopes,
+		RateLimit:  k.RateLimit,
+		LastUsedAt: k.LastUsedAt,
+		LastUsedIP: k.LastUsedIP,
+		ExpiresAt:  k.ExpiresAt,
+		RevokedAt:  k.RevokedAt,
+		CreatedAt:  k.CreatedAt,
+	}
+}
+
+// CreateAPIKey 创建个人访问令牌, 明文只在创建时返回一次, 库中只保存哈希
+func CreateAPIKey(uuid, name string, scopes []string, rateLimit, expiresInDays int) (response.APIKeyCreateResponse, error) {
+	seen := make(map[string]bool, len(scopes))
+	uniq := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		if !utils.IsValidScope(s) {
+			return response.APIKeyCreateResponse{}, fmt.Errorf("权限范围 %s 不存在", s)
+		}
+		if !seen[s] {
+			seen[s] = true
+			uniq = append(uniq, s)
+		}
+	}
+	if rateLimit <= 0 {
+		rateLimit = defaultAPIKeyRateLimit
+	}
+
+	var count int
+	if err := global.DB.Model(&database.APIKey{}).
+		Where("user_uuid = ? AND revoked_at IS NULL", uuid).
+		Count(&count).Error; err != nil {
+		return response.APIKeyCreateResponse{}, errors.New("查询 API Key 失败")
+	}
+	if count >= maxAPIKeysPerUser {
+		return response.APIKeyCreateResponse{}, fmt.Errorf("最多只能创建 %d 个 API Key", maxAPIKeysPerUser)
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return response.APIKeyCreateResponse{}, errors.New("生成 API Key 失败")
+	}
+	key := utils.APIKeyPrefix + hex.EncodeToString(buf)
+	scopeJSON, _ := json.Marshal(uniq)
+
+	apiKey := database.APIKey{
+		UserUUID:  uuid,
+		Name:      name,
+		Prefix:    key[:len(utils.APIKeyPrefix)+8],
+		KeyHash:   utils.HashAPIKey(key),
+		Scopes:    scopeJSON,
+		RateLimit: rateLimit,
+		CreatedAt: time.Now(),
+	}
+	if expiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, expiresInDays)
+		apiKey.ExpiresAt = &expiresAt
+	}
+	if err := global.DB.Create(&apiKey).Error; err != nil {
+		return response.APIKeyCreateResponse{}, errors.New("保存 API Key 失败")
+	}
+
+	return response.APIKeyCreateResponse{
+		APIKeyInfo: toAPIKeyInfo(apiKey),
+		Key:        key,
+	}, nil
+}
+
+// ListAPIKeys 列出用户的全部 API Key（包括已撤销的）
+func ListAPIKeys(uuid string) ([]response.APIKeyInfo, error) {
+	var keys []database.APIKey
+	if err := global.DB.Where("user_uuid = ?", uuid).Order("id desc").Find(&keys).Error; err != nil {
+		return nil, errors.New("查询 API Key 失败")
+	}
+	result := make([]response.APIKeyInfo, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, toAPIKeyInfo(k))
+	}
+	return result, nil
+}
+
+// RevokeAPIKey 撤销 API Key, 撤销后立即失效
+func RevokeAPIKey(uuid string, id uint) error {
+	res := global.DB.Model(&database.APIKey{}).
+		Where("id = ? AND user_uuid = ? AND revoked_at IS NULL", id, uuid).
+		UpdateColumn("revoked_at", time.Now())
+	if res.Error != nil {
+		return errors.New("撤销 API Key 失败")
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("API Key 不存在或已撤销")
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/utils"
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	jgorm "github.com/jinzhu/gorm"
+)
+
+const (
+	attachmentHourlyLimit    = 30               // 每人每小时最多上传的附件数
+	attachmentHourlyBytes    = 500 << 20        // 每人每小时最多上传的字节数
+	attachmentDownloadURLTTL = 10 * time.Minute // 下载接口返回的签名地址有效期
+	pdfThumbnailWorkers      = 2                // 同时处理的 PDF 数, 限制内存和 pdftoppm 进程数
+	pdfThumbnailQueueSize    = 32               // 排队等待处理的 PDF 数, 超出时跳过缩略图
+)
+
+// pdfThumbnailJob 上传时写到临时文件的 PDF, 由后台任务识别页数、生成缩略图后删除
+type pdfThumbnailJob struct {
+	attachmentID uint
+	path         string
+}
+
+var (
+	pdfThumbnailOnce  sync.Once
+	pdfThumbnailQueue chan pdfThumbnailJob
+)
+
+// enqueuePDFThumbnail 把 PDF 交给后台任务处理, 队列已满时直接放弃, 不阻塞上传请求
+func enqueuePDFThumbnail(job pdfThumbnailJob) {
+	pdfThumbnailOnce.Do(func() {
+		pdfThumbnailQueue = make(chan pdfThumbnailJob, pdfThumbnailQueueSize)
+		for i := 0; i < pdfThumbnailWorkers; i++ {
+			go func() {
+				for job := range pdfThumbnailQueue {
+					processPDFThumbnail(job)
+				}
+			}()
+		}
+	})
+	select {
+	case pdfThumbnailQueue <- job:
+	default:
+		log.Println("[Attachment] PDF 处理队列已满, 跳过缩略图:", job.attachmentID)
+		os.Remove(job.path)
+	}
+}
+
+func processPDFThumbnail(job pdfThumbnailJob) {
+	defer os.Remove(job.path)
+	data, err := os.ReadFile(job.path)
+	if err != nil {
+		log.Println("[Attachment] 读取 PDF 失败:", job.attachmentID, err)
+		return
+	}
+	updates := map[string]interface{}{"page_count": utils.PDFPageCount(data)}
+	if thumb, err := utils.PDFThumbnail(job.path); err != nil {
+		log.Println("[Attachment] 生成 PDF 缩略图失败:", job.attachmentID, err)
+	} else if thumbURL, err := utils.UploadToOSS(bytes.NewReader(thumb), "thumb.png"); err != nil {
+		log.Println("[Attachment] 上传 PDF 缩略图失败:", job.attachmentID, err)
+	} else {
+		updates["thumbnail_url"] = thumbURL
+	}
+	if err := global.DB.Model(&database.Attachment{}).Where("id = ?", job.attachmentID).
+		UpdateColumns(updates).Error; err != nil {
+		log.Println("[Attachment] 保存 PDF 信息失败:", job.attachmentID, err)
+	}
+}
+
+// checkAttachmentQuota 限制每人每小时上传的附件数和总大小, 已删除的附件也计入
+func checkAttachmentQuota(uploaderUUID string, size int64) error {
+	var count, total int64
+	if err := global.DB.Unscoped().Model(&database.Attachment{}).
+		Where("uploader_uuid = ? AND kind <> ? AND created_at > ?", uploaderUUID, database.AttachmentLink, time.Now().Add(-time.Hour)).
+		Select("COUNT(*), COALESCE(SUM(bytes), 0)").Row().Scan(&count, &total); err != nil {
+		return errors.New("查询上传记录失败")
+	}
+	if count >= attachmentHourlyLimit || total+size > attachmentHourlyBytes {
+		return errors.New("上传过于频繁, 请稍后再试")
+	}
+	return nil
+}
+
+// UploadAttachment 上传附件, 返回的 ID 在发帖 / 修改帖子时通过 attachments 关联到帖子
+// 文件直接流式上传; PDF 同时写入临时文件, 页数和第一页缩略图由后台任务稍后补上
+func UploadAttachment(uploaderUUID, kind string, file *multipart.FileHeader) (response.AttachmentInfo, error) {
+	src, err := file.Open()
+	if err != nil {
+		return response.AttachmentInfo{}, errors.New("读取文件失败")
+	}
+	defer src.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return response.AttachmentInfo{}, errors.New("读取文件失败")
+	}
+	head = head[:n]
+	mime, err := utils.CheckAttachment(kind, file.Filename, file.Size, head)
+	if err != nil {
+		return response.AttachmentInfo{}, err
+	}
+	if err := checkAttachmentQuota(uploaderUUID, file.Size); err != nil {
+		return response.AttachmentInfo{}, err
+	}
+
+	attachment := database.Attachment{
+		UploaderUUID: uploaderUUID,
+		Kind:         kind,
+		FileName:     filepath.Base(file.Filename),
+		MimeType:     mime,
+		Bytes:        file.Size,
+	}
+
+	body := io.MultiReader(bytes.NewReader(head), src)
+	var pdfPath string
+	if kind == database.AttachmentPDF {
+		if tmp, err := os.CreateTemp("", "attachment-*.pdf"); err != nil {
+			log.Println("[Attachment] 创建临时文件失败, 跳过缩略图:", err)
+		} else {
+			defer tmp.Close()
+			pdfPath = tmp.Name()
+			body = io.TeeReader(body, tmp)
+		}
+	}
+
+	if attachment.URL, err = utils.UploadToOSS(body, file.Filename); err != nil {
+		if pdfPath != "" {
+			os.Remove(pdfPath)
+		}
+		return response.AttachmentInfo{}, errors.New("上传失败")
+	}
+	if err := global.DB.Create(&attachment).Error; err != nil {
+		if pdfPath != "" {
+			os.Remove(pdfPath)
+		}
+		return response.AttachmentInfo{}, err
+	}
+	if pdfPath != "" {
+		enqueuePDFThumbnail(pdfThumbnailJob{attachmentID: attachment.ID, path: pdfPath})
+	}
+	return utils.ConvertAttachment(attachment), nil
+}
+
+// prepareAttachments 校验帖子附件: 上传的附件必须是本人的且没有关联到其他帖子, 链接只允许 http / https
+func prepareAttachments(db *jgorm.DB, uploaderUUID string, postID uint, refs []request.AttachmentRef) error {
+	var ids []uint
+	seen := make(map[uint]bool)
+	for _, ref := range refs {
+		if ref.AttachmentID == 0 {
+			u, err := url.Parse(ref.URL)
+			if ref.URL == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return errors.New("附件链接无效")
+			}
+			continue
+		}
+		if seen[ref.AttachmentID] {
+			return errors.New("附件重复")
+		}
+		seen[ref.AttachmentID] = true
+		ids = append(ids, ref.AttachmentID)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	var n int64
+	if err := db.Model(&database.Attachment{}).
+		Where("id IN (?) AND uploader_uuid = ? AND (post_id = 0 OR post_id = ?)", ids, uploaderUUID, postID).
+		Count(&n).Error; err != nil {
+		return err
+	}
+	if int(n) != len(ids) {
+		return errors.New("附件不存在")
+	}
+	return nil
+}
+
+// setPostAttachments 用 refs 覆盖帖子的附件, 不再使用的附件删除; 在调用方的事务 tx 中执行
+func setPostAttachments(tx *jgorm.DB, postID uint, uploaderUUID string, refs []request.AttachmentRef) error {
+	if err := prepareAttachments(tx, uploaderUUID, postID, refs); err != nil {
+		return err
+	}
+	keep := []uint{0}
+	for _, ref := range refs {
+		if ref.AttachmentID > 0 {
+			keep = append(keep, ref.AttachmentID)
+		}
+	}
+	if err := tx.Where("post_id = ? AND id NOT IN (?)", postID, keep).
+		Delete(&database.Attachment{}).Error; err != nil {
+		return err
+	}
+	for i, ref := range refs {
+		if ref.AttachmentID > 0 {
+			if err := tx.Model(&database.Attachment{}).Where("id = ?", ref.AttachmentID).
+				UpdateColumns(map[string]interface{}{"post_id": postID, "position": i}).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		title := strings.TrimSpace(ref.Title)
+		if title == "" {
+			if u, err := url.Parse(ref.URL); err == nil {
+				title = u.Host
+			}
+		}
+		if err := tx.Create(&database.Attachment{
+			UploaderUUID: uploaderUUID,
+			PostID:       postID,
+			Position:     i,
+			Kind:         database.AttachmentLink,
+			URL:          ref.URL,
+			FileName:     title,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DownloadAttachment 返回附件地址并计一次下载; 未发布帖子的附件只有作者能下载
+// 上传的文件返回限时有效的签名地址, 外部链接原样返回
+func DownloadAttachment(userUUID string, attachmentID uint) (string, error) {
+	var attachment database.Attachment
+	if err := global.DB.First(&attachment, attachmentID).Error; err != nil {
+		return "", errors.New("附件不存在")
+	}
+	if attachment.PostID == 0 {
+		// 还没有关联到帖子, 只有上传者能访问, 不计下载
+		if attachment.UploaderUUID != userUUID {
+			return "", errors.New("附件不存在")
+		}
+		return attachmentDownloadURL(attachment)
+	}
+
+	var post database.Post
+	if err := global.DB.First(&post, attachment.PostID).Error; err != nil {
+		return "", errors.New("附件不存在")
+	}
+	if post.Status != database.PostStatusPublished {
+		if post.AuthorUUID != userUUID {
+			return "", errors.New("附件不存在")
+		}
+		return attachmentDownloadURL(attachment)
+	}
+	if !utils.CanViewPost(post, userUUID) {
+		return "", errors.New("附件不存在")
+	}
+
+	if err := global.DB.Model(&database.Attachment{}).Where("id = ?", attachment.ID).
+		UpdateColumn("download_count", jgorm.Expr("download_count + 1")).Error; err != nil {
+		log.Println("[Attachment] 更新下载数失败:", attachment.ID, err)
+	}
+	return attachmentDownloadURL(attachment)
+}
+
+func attachmentDownloadURL(attachment database.Attachment) (string, error) {
+	if attachment.Kind == database.AttachmentLink {
+		return attachment.URL, nil
+	}
+	signed, err := utils.SignOSSURL(attachment.URL, attachmentDownloadURLTTL)
+	if err != nil {
+		log.Println("[Attachment] 生成下载地址失败:", attachment.ID, err)
+		return "", errors.New("获取下载地址失败")
+	}
+	return signed, nil
+}
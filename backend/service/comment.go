@@ -3,19 +3,20 @@ package service
 import (
 	"OpenHouse/global"
 	"OpenHouse/model/database"
+	"OpenHouse/model/request"
 	"OpenHouse/model/response"
+	"OpenHouse/utils"
 	"errors"
-	"fmt"
 	"time"
 
-	"gorm.io/gorm"
+	jgorm "github.com/jinzhu/gorm"
 )
 
 func CreateComment(userUUID string, postID uint, commentID *uint, content string) error {
-	// 检查帖子是否存在
-	var post database.Post
-	if err := global.DB.First(&post, postID).Error; err != nil {
-		return errors.New("帖子不存在")
+	// 检查帖子是否存在且对当前用户可见
+	post, err := visiblePost(postID, userUUID)
+	if err != nil {
+		return err
 	}
 
 	comment := database.PostComment{
@@ -25,17 +26,16 @@ func CreateComment(userUUID string, postID uint, commentID *uint, content string
 		Content:    content,
 		CreateTime: time.Now(),
 	}
-	if err := global.DB.Create(&comment).Error; err != nil {
-		return err
-	}
-
-	if err := global.DB.Model(&database.Post{}).
-		Where("id = ?", postID).
-		Select("comment_number").
-		Updates(database.Post{CommentNumber: post.CommentNumber + 1}).Error; err != nil {
+	if err := global.DB.Transaction(func(tx *jgorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		return incrCounter(tx, "posts", "comment_number", postID, 1)
+	}); err != nil {
 		return err
 	}
-
+	indexComment(comment)
+	updateMentions(post, comment.ID, userUUID, content)
 	return nil
 }
 
@@ -45,86 +45,79 @@ func LikeComment(userUUID string, commentID uint) error {
 	if err := global.DB.First(&comment, commentID).Error; err != nil {
 		return errors.New("评论不存在")
 	}
-
-	var like database.CommentLike
-	err := global.DB.
-		Where("user_id = ? AND comment_id = ?", userUUID, commentID).
-		First(&like).Error
-	if err == nil {
-		if like.DeletedAt.Valid {
-			return global.DB.Model(&like).Update("deleted_at", nil).Error
-		}
-		return errors.New("请勿重复点赞")
-	}
-	if !errors.Is(err, gorm.ErrRecordNotFound) {
-		return err
-	}
-	// 新增点赞
-	like = database.CommentLike{UserID: userUUID, CommentID: commentID}
-	if err := global.DB.Create(&like).Error; err != nil {
-		return err
+	if _, err := visiblePost(comment.PostID, userUUID); err != nil {
+		return errors.New("评论不存在")
 	}
 
-	// 更新评论点赞数
-	if err := global.DB.Model(&database.PostComment{}).
-		Where("id = ?", commentID).
-		Select("like_number").
-		Updates(database.PostComment{LikeNumber: comment.LikeNumber + 1}).Error; err != nil {
-		return errors.New("更新点赞数失败")
-	}
-	return nil
+	return global.DB.Transaction(func(tx *jgorm.DB) error {
+		ok, err := activateRelation(tx, "comment_likes", "comment_id", userUUID, commentID, false)
+		if err != nil {
+			return errors.New("点赞失败")
+		}
+		if !ok {
+			return errors.New("请勿重复点赞")
+		}
+		if err := incrCounter(tx, "post_comments", "like_number", commentID, 1); err != nil {
+			return errors.New("更新点赞数失败")
+		}
+		return nil
+	})
 }
 
 func UnlikeComment(userUUID string, commentID uint) error {
-	// 检查评论是否存在
-	var comment database.PostComment
-	if err := global.DB.First(&comment, commentID).Error; err != nil {
-		return errors.New("评论不存在")
-	}
+	return global.DB.Transaction(func(tx *jgorm.DB) error {
+		ok, err := deactivateRelation(tx, "comment_likes", "comment_id", userUUID, commentID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("尚未点赞该评论")
+		}
+		// 点赞数 -1（最小为 0）
+		if err := incrCounter(tx, "post_comments", "like_number", commentID, -1); err != nil {
+			return errors.New("更新点赞数失败")
+		}
+		return nil
+	})
+}
 
-	var like database.CommentLike
-	err := global.DB.
-		Where("user_id = ? AND comment_id = ? AND deleted_at IS NULL", userUUID, commentID).
-		First(&like).Error
+// ListComments 查询某个帖子的一级评论 + 默认前 3 条子评论
+func ListComments(postID uint, page request.CursorPage, sortBy string, currentUserUUID string) ([]response.CommentInfo, response.PageInfo, error) {
+	cur, err := decodePageCursor(page)
 	if err != nil {
-		return errors.New("尚未点赞该评论")
+		return nil, response.PageInfo{}, err
 	}
-	if err := global.DB.Delete(&like).Error; err != nil {
-		return err
+	if _, err := visiblePost(postID, currentUserUUID); err != nil {
+		return nil, response.PageInfo{}, err
 	}
-	// 同步更新点赞数
-	if err := global.DB.Model(&database.PostComment{}).
-		Where("id = ?", commentID).
-		Select("like_number").
-		Updates(database.PostComment{LikeNumber: comment.LikeNumber - 1}).Error; err != nil {
-		return errors.New("更新点赞数失败")
-	}
-	return nil
-}
 
-// ListComments 查询某个帖子的一级评论 + 默认前 3 条子评论
-func ListComments(postID uint, pageNum, pageSize int, sortBy string, currentUserUUID string) ([]response.CommentInfo, int64, error) {
 	var comments []database.PostComment
 	var total int64
 
-	// 查询一级评论总数
+	// 查询一级评论总数（页码分页时）
 	db := global.DB.Model(&database.PostComment{}).Where("post_id = ? AND comment_id IS NULL", postID)
-	if err := db.Count(&total).Error; err != nil {
-		return nil, 0, err
+	if !page.UseCursor() {
+		if err := db.Count(&total).Error; err != nil {
+			return nil, response.PageInfo{}, err
+		}
 	}
 	// 排序方式
-	order := "create_time desc"
+	key := sortKey{Col: "create_time", Time: true, Desc: true}
 	if sortBy == "likes" {
-		order = "like_number desc"
+		key = sortKey{Col: "like_number", Desc: true}
 	}
 
 	// 查询一级评论分页数据
-	if err := db.Order(order).
-		Limit(pageSize).
-		Offset((pageNum - 1) * pageSize).
-		Find(&comments).Error; err != nil {
-		return nil, 0, err
+	if err := key.scope(db, page, cur).Find(&comments).Error; err != nil {
+		return nil, response.PageInfo{}, err
 	}
+	n, info := pageResult(len(comments), page, total, func(i int) utils.Cursor {
+		if sortBy == "likes" {
+			return valueCursor(float64(comments[i].LikeNumber), comments[i].ID)
+		}
+		return timeCursor(comments[i].CreateTime, comments[i].ID)
+	})
+	comments = comments[:n]
 
 	// 收集一级评论 ID + 用户 UUID
 	commentIDs := make([]uint, 0, len(comments))
@@ -209,7 +202,8 @@ func ListComments(postID uint, pageNum, pageSize int, sortBy string, currentUser
 				AuthorUUID: child.AuthorUUID,
 				Username:   author.Username,
 				AvatarURL:  author.AvatarURL,
-				IsLiked:    subLikedMap[child.ID],
+				Mentions:   utils.MentionEntities(child.PostID, child.ID),
+				IsLiked:    utils.ViewerFlag(currentUserUUID, subLikedMap[child.ID]),
 			})
 		}
 
@@ -229,35 +223,50 @@ func ListComments(postID uint, pageNum, pageSize int, sortBy string, currentUser
 			AuthorUUID:       c.AuthorUUID,
 			Username:         u.Username,
 			AvatarURL:        u.AvatarURL,
-			IsLiked:          likedMap[c.ID],
+			Mentions:         utils.MentionEntities(c.PostID, c.ID),
+			IsLiked:          utils.ViewerFlag(currentUserUUID, likedMap[c.ID]),
 			Replies:          subComments,
 			RepliesMoreCount: int(count) - len(subComments),
 		})
 	}
 
-	fmt.Println(result)
-	return result, total, nil
+	return result, info, nil
 }
 
 // ListChildComments 返回某条评论下的子评论（分页 + 按时间升序）
-func ListChildComments(parentCommentID uint, pageNum, pageSize int, currentUserUUID string) ([]response.CommentInfo, int64, error) {
+func ListChildComments(parentCommentID uint, page request.CursorPage, currentUserUUID string) ([]response.CommentInfo, response.PageInfo, error) {
+	cur, err := decodePageCursor(page)
+	if err != nil {
+		return nil, response.PageInfo{}, err
+	}
+	var parent database.PostComment
+	if err := global.DB.First(&parent, parentCommentID).Error; err != nil {
+		return nil, response.PageInfo{}, errors.New("评论不存在")
+	}
+	if _, err := visiblePost(parent.PostID, currentUserUUID); err != nil {
+		return nil, response.PageInfo{}, errors.New("评论不存在")
+	}
+
 	var children []database.PostComment
 	var total int64
 
-	// 查询总数量
+	// 查询总数量（页码分页时）
 	db := global.DB.Model(&database.PostComment{}).Where("comment_id = ?", parentCommentID)
-	if err := db.Count(&total).Error; err != nil {
-		return nil, 0, err
+	if !page.UseCursor() {
+		if err := db.Count(&total).Error; err != nil {
+			return nil, response.PageInfo{}, err
+		}
 	}
-	fmt.Println("子评论总数：", total)
 
 	// 查询分页数据
-	if err := db.Order("create_time asc").
-		Limit(pageSize).
-		Offset((pageNum - 1) * pageSize).
-		Find(&children).Error; err != nil {
-		return nil, 0, err
+	key := sortKey{Col: "create_time", Time: true}
+	if err := key.scope(db, page, cur).Find(&children).Error; err != nil {
+		return nil, response.PageInfo{}, err
 	}
+	n, info := pageResult(len(children), page, total, func(i int) utils.Cursor {
+		return timeCursor(children[i].CreateTime, children[i].ID)
+	})
+	children = children[:n]
 
 	// 批量获取作者用户
 	userIDs := make([]string, 0, len(children))
@@ -299,9 +308,10 @@ func ListChildComments(parentCommentID uint, pageNum, pageSize int, currentUserU
 			AuthorUUID: c.AuthorUUID,
 			Username:   u.Username,
 			AvatarURL:  u.AvatarURL,
-			IsLiked:    likedMap[c.ID],
+			Mentions:   utils.MentionEntities(c.PostID, c.ID),
+			IsLiked:    utils.ViewerFlag(currentUserUUID, likedMap[c.ID]),
 		})
 	}
 
-	return result, total, nil
+	return result, info, nil
 }
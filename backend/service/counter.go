@@ -0,0 +1,95 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"fmt"
+	"time"
+
+	jgorm "github.com/jinzhu/gorm"
+)
+
+// 点赞、收藏等关系表的计数规则: 关系记录由 (user_id, 目标) 唯一索引约束, 取消时软删除、再次操作时恢复,
+// 计数只在关系记录真正发生变化时在 SQL 中原子加减, 并由定时任务按关系表重新统计兜底
+
+// activateRelation 插入或恢复一条关系记录, 返回 false 表示已存在有效记录
+// withTime 为 true 时同时记录操作时间, 恢复的记录也刷新为当前时间
+func activateRelation(tx *jgorm.DB, table, targetCol, userUUID string, targetID uint, withTime bool) (bool, error) {
+	// 已有有效记录时各列都不变, 受影响行数为 0
+	var res *jgorm.DB
+	if withTime {
+		res = tx.Exec(fmt.Sprintf(`INSERT INTO %s (user_id, %s, created_at) VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE created_at = IF(deleted_at IS NULL, created_at, VALUES(created_at)), deleted_at = NULL`, table, targetCol),
+			userUUID, targetID, time.Now())
+	} else {
+		res = tx.Exec(fmt.Sprintf("INSERT INTO %s (user_id, %s) VALUES (?, ?) ON DUPLICATE KEY UPDATE deleted_at = NULL", table, targetCol),
+			userUUID, targetID)
+	}
+	return res.RowsAffected > 0, res.Error
+}
+
+// deactivateRelation 软删除有效的关系记录, 返回 false 表示没有有效记录
+func deactivateRelation(tx *jgorm.DB, table, targetCol, userUUID string, targetID uint) (bool, error) {
+	res := tx.Exec(fmt.Sprintf("UPDATE %s SET deleted_at = ? WHERE user_id = ? AND %s = ? AND deleted_at IS NULL", table, targetCol),
+		time.Now(), userUUID, targetID)
+	return res.RowsAffected > 0, res.Error
+}
+
+// incrCounter 在 SQL 中原子加减计数, 减到 0 为止
+func incrCounter(tx *jgorm.DB, table, col string, id uint, delta int) error {
+	if delta >= 0 {
+		return tx.Exec(fmt.Sprintf("UPDATE %s SET %[2]s = %[2]s + ? WHERE id = ?", table, col), delta, id).Error
+	}
+	return tx.Exec(fmt.Sprintf("UPDATE %s SET %[2]s = GREATEST(%[2]s - ?, 0) WHERE id = ?", table, col), -delta, id).Error
+}
+
+// counterRule 一个计数字段及其统计来源: source 表中未删除的记录按 key 分组计数
+type counterRule struct {
+	table      string // 计数所在的表
+	col        string
+	source     string
+	key        string
+	postFilter string // 只统计部分帖子时 source 表的过滤条件
+}
+
+var counterRules = []counterRule{
+	{"posts", "star_number", "user_post_likes", "post_id", "post_id IN (?)"},
+	{"posts", "favorite_number", "user_post_favorites", "post_id", "post_id IN (?)"},
+	{"posts", "comment_number", "post_comments", "post_id", "post_id IN (?)"},
+	{"posts", "repost_number", "posts", "repost_of_id", "repost_of_id IN (?)"},
+	{"post_comments", "like_number", "comment_likes", "comment_id", "comment_id IN (SELECT id FROM post_comments WHERE post_id IN (?))"},
+}
+
+// reconcileCounters 按关系表重新统计计数, 只改写有偏差的行, 返回修正的行数
+// postIDs 为空时处理全部帖子和评论, 否则只处理这些帖子及其评论
+func reconcileCounters(postIDs []uint) (int64, error) {
+	var fixed int64
+	for _, rule := range counterRules {
+		sourceWhere, targetWhere := "", ""
+		var args []interface{}
+		if len(postIDs) > 0 {
+			sourceWhere = " AND " + rule.postFilter
+			if rule.table == "posts" {
+				targetWhere = " AND t.id IN (?)"
+			} else {
+				targetWhere = " AND t.post_id IN (?)"
+			}
+			args = append(args, postIDs, postIDs)
+		}
+		query := fmt.Sprintf(`UPDATE %[1]s t LEFT JOIN (
+				SELECT %[4]s AS target, COUNT(*) AS n FROM %[3]s WHERE deleted_at IS NULL%[5]s GROUP BY %[4]s
+			) c ON c.target = t.id
+			SET t.%[2]s = COALESCE(c.n, 0)
+			WHERE t.%[2]s <> COALESCE(c.n, 0)%[6]s`, rule.table, rule.col, rule.source, rule.key, sourceWhere, targetWhere)
+		res := global.DB.Exec(query, args...)
+		if res.Error != nil {
+			return fixed, fmt.Errorf("统计 %s.%s 失败: %w", rule.table, rule.col, res.Error)
+		}
+		fixed += res.RowsAffected
+	}
+	return fixed, nil
+}
+
+// ReconcileCounters 修正全部帖子的点赞、收藏、评论、转发数和评论点赞数, 由定时任务调用
+func ReconcileCounters() (int64, error) {
+	return reconcileCounters(nil)
+}
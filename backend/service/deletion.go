@@ -0,0 +1,401 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	jgorm "github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+// DeletedUserUUID 注销用户留下的评论会改挂到这个占位 UUID 上, 保留楼层结构但不再关联任何个人信息
+const DeletedUserUUID = "00000000-0000-0000-0000-000000000000"
+
+// deletedCommentContent 注销用户评论的替换内容
+const deletedCommentContent = "[该评论已随账号注销删除]"
+
+const defaultDeletionGraceDays = 14
+
+func deletionGracePeriod() time.Duration {
+	days := global.VP.GetInt("account.deletion_grace_days")
+	if days <= 0 {
+		days = defaultDeletionGraceDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// ExportUserData 导出用户的全部数据, 打包为 zip, 每类数据一个 JSON 文件
+func ExportUserData(uuid string) ([]byte, error) {
+	var user database.User
+	if err := global.DB.Where("uuid = ?", uuid).First(&user).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+
+	var (
+		posts       []database.Post
+		comments    []database.PostComment
+		likes       []database.UserPostLike
+		favorites   []database.UserPostFavorite
+		commentLike []database.CommentLike
+		following   []database.UserFollow
+		followers   []database.UserFollow
+		chats       []database.ChatMessage
+		matches     []database.MatchResult
+		accounts    []database.AuthAccount
+		apiKeys     []database.APIKey
+		topics      []database.Tag
+		revisions   []database.PostRevision
+		attachments []database.Attachment
+		views       []database.PostView
+		postStats   []database.PostDailyStat
+		followStats []database.UserDailyStat
+		pollVotes   []database.PollVote
+		notices     []database.Notification
+	)
+	queries := []struct {
+		name string
+		err  error
+	}{
+		{"posts", global.DB.Where("author_uuid = ?", uuid).Order("id").Find(&posts).Error},
+		{"comments", global.DB.Where("author_uuid = ?", uuid).Order("id").Find(&comments).Error},
+		{"likes", global.DB.Where("user_id = ?", uuid).Order("id").Find(&likes).Error},
+		{"favorites", global.DB.Where("user_id = ?", uuid).Order("id").Find(&favorites).Error},
+		{"comment_likes", global.DB.Where("user_id = ?", uuid).Order("id").Find(&commentLike).Error},
+		{"following", global.DB.Where("user_id = ?", uuid).Order("id").Find(&following).Error},
+		{"followers", global.DB.Where("follow_id = ?", uuid).Order("id").Find(&followers).Error},
+		{"chats", global.DB.Where("sender_uuid = ? OR receiver_uuid = ?", uuid, uuid).Order("id").Find(&chats).Error},
+		{"matches", global.DB.Where("user_uuid = ? OR match_uuid = ?", uuid, uuid).Order("id").Find(&matches).Error},
+		{"auth_accounts", global.DB.Where("profile_uuid = ?", uuid).Order("id").Find(&accounts).Error},
+		{"api_keys", global.DB.Where("user_uuid = ?", uuid).Order("id").Find(&apiKeys).Error},
+		{"followed_tags", global.DB.Joins("JOIN tag_follows ON tag_follows.tag_id = tags.id AND tag_follows.deleted_at IS NULL").
+			Where("tag_follows.user_uuid = ?", uuid).Order("tag_follows.id").Find(&topics).Error},
+		{"post_revisions", global.DB.Where("post_id IN (SELECT id FROM posts WHERE author_uuid = ?)", uuid).
+			Order("post_id, version").Find(&revisions).Error},
+		{"attachments", global.DB.Where("uploader_uuid = ?", uuid).Order("id").Find(&attachments).Error},
+		{"post_views", global.DB.Where("viewer_key = ?", uuid).Order("id").Find(&views).Error},
+		{"post_daily_stats", global.DB.Where("author_uuid = ?", uuid).Order("date, post_id").Find(&postStats).Error},
+		{"user_daily_stats", global.DB.Where("user_uuid = ?", uuid).Order("date").Find(&followStats).Error},
+		{"poll_votes", global.DB.Where("user_uuid = ?", uuid).Order("id").Find(&pollVotes).Error},
+		{"notifications", global.DB.Where("user_uuid = ?", uuid).Order("id").Find(&notices).Error},
+	}
+	for _, q := range queries {
+		if q.err != nil {
+			return nil, errors.Wrapf(q.err, "查询 %s 失败", q.name)
+		}
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", user},
+		{"posts.json", posts},
+		{"comments.json", comments},
+		{"post_likes.json", likes},
+		{"favorites.json", favorites},
+		{"comment_likes.json", commentLike},
+		{"following.json", following},
+		{"followers.json", followers},
+		{"chat_messages.json", chats},
+		{"match_history.json", matches},
+		{"login_methods.json", accounts},
+		{"api_keys.json", apiKeys},
+		{"followed_tags.json", topics},
+		{"post_revisions.json", revisions},
+		{"attachments.json", attachments},
+		{"post_views.json", views},
+		{"post_daily_stats.json", postStats},
+		{"follower_daily_stats.json", followStats},
+		{"poll_votes.json", pollVotes},
+		{"notifications.json", notices},
+	}
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, errors.Wrap(err, "打包失败")
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.data); err != nil {
+			return nil, errors.Wrapf(err, "写入 %s 失败", f.name)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, errors.Wrap(err, "打包失败")
+	}
+	return buf.Bytes(), nil
+}
+
+// RequestAccountDeletion 申请注销账号, 宽限期内可以撤销, 到期后由定时任务彻底删除
+// 开启了两步验证的账号需要提供验证码
+func RequestAccountDeletion(uuid, totpCode string) (time.Time, error) {
+	var user database.User
+	if err := global.DB.Where("uuid = ?", uuid).First(&user).Error; err != nil {
+		return time.Time{}, errors.New("用户不存在")
+	}
+	if user.DeletionScheduledAt != nil {
+		return *user.DeletionScheduledAt, errors.New("已申请注销")
+	}
+	if user.TOTPEnabled {
+		if err := verifyTwoFactorCode(user, totpCode); err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	scheduledAt := time.Now().Add(deletionGracePeriod())
+	err := global.DB.Transaction(func(tx *jgorm.DB) error {
+		if err := tx.Model(&database.User{}).Where("uuid = ?", uuid).
+			Update("deletion_scheduled_at", scheduledAt).Error; err != nil {
+			return errors.New("申请注销失败")
+		}
+		// 注销期间不再允许脚本访问
+		if err := tx.Model(&database.APIKey{}).
+			Where("user_uuid = ? AND revoked_at IS NULL", uuid).
+			UpdateColumn("revoked_at", time.Now()).Error; err != nil {
+			return errors.New("撤销 API Key 失败")
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return scheduledAt, nil
+}
+
+// CancelAccountDeletion 撤销注销申请
+func CancelAccountDeletion(uuid string) error {
+	res := global.DB.Model(&database.User{}).
+		Where("uuid = ? AND deletion_scheduled_at IS NOT NULL", uuid).
+		Update("deletion_scheduled_at", nil)
+	if res.Error != nil {
+		return errors.New("撤销注销失败")
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("尚未申请注销")
+	}
+	return nil
+}
+
+// GetAccountDeletionStatus 查询注销申请的删除时间, 未申请返回 nil
+func GetAccountDeletionStatus(uuid string) (*time.Time, error) {
+	var user database.User
+	if err := global.DB.Select("uuid, deletion_scheduled_at").Where("uuid = ?", uuid).First(&user).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	return user.DeletionScheduledAt, nil
+}
+
+// PurgeDeletedAccounts 彻底删除宽限期已过的账号, 由定时任务调用
+func PurgeDeletedAccounts() error {
+	var uuids []string
+	if err := global.DB.Model(&database.User{}).
+		Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", time.Now()).
+		Pluck("uuid", &uuids).Error; err != nil {
+		return errors.Wrap(err, "查询待删除账号失败")
+	}
+
+	var failed int
+	for _, uuid := range uuids {
+		if err := purgeAccount(uuid); err != nil {
+			log.Println("[Cron] 删除账号失败:", uuid, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d 个账号删除失败", failed)
+	}
+	return nil
+}
+
+// purgeAccount 删除账号及其数据:
+// 自己的帖子连同其下的评论、点赞、收藏一起删除; 在别人帖子下的评论保留楼层, 内容和作者匿名化;
+// 点赞、收藏、关注、私信、匹配记录、登录方式、恢复码、API Key、验证码全部删除
+func purgeAccount(uuid string) error {
+	var user database.User
+	if err := global.DB.Where("uuid = ?", uuid).First(&user).Error; err != nil {
+		return errors.Wrap(err, "用户不存在")
+	}
+
+	// removedComments 被删除或匿名化的评论, 事务完成后从搜索索引中移除
+	var affectedPosts, postIDs, repostedPosts, removedComments []uint
+	err := global.DB.Transaction(func(tx *jgorm.DB) error {
+		// 1. 自己的帖子
+		if err := tx.Unscoped().Model(&database.Post{}).
+			Where("author_uuid = ?", uuid).
+			Pluck("id", &postIDs).Error; err != nil {
+			return errors.Wrap(err, "查询帖子失败")
+		}
+		if len(postIDs) > 0 {
+			var postCommentIDs []uint
+			if err := tx.Unscoped().Model(&database.PostComment{}).
+				Where("post_id IN (?)", postIDs).
+				Pluck("id", &postCommentIDs).Error; err != nil {
+				return errors.Wrap(err, "查询评论失败")
+			}
+			removedComments = append(removedComments, postCommentIDs...)
+			if len(postCommentIDs) > 0 {
+				if err := tx.Unscoped().Where("comment_id IN (?)", postCommentIDs).
+					Delete(&database.CommentLike{}).Error; err != nil {
+					return errors.Wrap(err, "删除评论点赞失败")
+				}
+			}
+			// 转发过的帖子稍后重新统计转发数; 别人对这些帖子的纯转发一并删除, 引用帖保留
+			if err := tx.Unscoped().Model(&database.Post{}).
+				Where("id IN (?) AND repost_of_id IS NOT NULL", postIDs).
+				Pluck("repost_of_id", &repostedPosts).Error; err != nil {
+				return errors.Wrap(err, "查询转发失败")
+			}
+			if err := tx.Where("repost_of_id IN (?) AND content = ''", postIDs).Delete(&database.Post{}).Error; err != nil {
+				return errors.Wrap(err, "删除转发失败")
+			}
+			for _, model := range []interface{}{&database.PostComment{}, &database.UserPostLike{}, &database.UserPostFavorite{}, &database.PostTag{}, &database.PostRevision{}, &database.PostView{}, &database.PostDailyStat{},
+				&database.Poll{}, &database.PollOption{}, &database.PollVote{}, &database.Mention{}, &database.Notification{}} {
+				if err := tx.Unscoped().Where("post_id IN (?)", postIDs).Delete(model).Error; err != nil {
+					return errors.Wrap(err, "删除帖子关联数据失败")
+				}
+			}
+			if err := tx.Unscoped().Where("id IN (?)", postIDs).Delete(&database.Post{}).Error; err != nil {
+				return errors.Wrap(err, "删除帖子失败")
+			}
+		}
+
+		if err := tx.Unscoped().Where("uploader_uuid = ?", uuid).Delete(&database.Attachment{}).Error; err != nil {
+			return errors.Wrap(err, "删除附件失败")
+		}
+		if err := tx.Where("viewer_key = ?", uuid).Delete(&database.PostView{}).Error; err != nil {
+			return errors.Wrap(err, "删除浏览记录失败")
+		}
+		if err := tx.Where("user_uuid = ?", uuid).Delete(&database.UserDailyStat{}).Error; err != nil {
+			return errors.Wrap(err, "删除关注统计失败")
+		}
+
+		// 2. 在别人帖子下的评论: 匿名化
+		var ownComments []uint
+		if err := tx.Unscoped().Model(&database.PostComment{}).
+			Where("author_uuid = ?", uuid).
+			Pluck("id", &ownComments).Error; err != nil {
+			return errors.Wrap(err, "查询评论失败")
+		}
+		removedComments = append(removedComments, ownComments...)
+		if err := tx.Unscoped().Model(&database.PostComment{}).
+			Where("author_uuid = ?", uuid).
+			UpdateColumns(map[string]interface{}{
+				"author_uuid": DeletedUserUUID,
+				"content":     deletedCommentContent,
+			}).Error; err != nil {
+			return errors.Wrap(err, "匿名化评论失败")
+		}
+		if len(ownComments) > 0 {
+			if err := tx.Where("comment_id IN (?)", ownComments).Delete(&database.Mention{}).Error; err != nil {
+				return errors.Wrap(err, "删除评论提及失败")
+			}
+		}
+
+		// 3. 点赞 / 收藏: 记录受影响的帖子, 稍后重新统计
+		var likedPosts []uint
+		if err := tx.Model(&database.UserPostLike{}).Where("user_id = ?", uuid).
+			Pluck("post_id", &likedPosts).Error; err != nil {
+			return errors.Wrap(err, "查询点赞失败")
+		}
+		var favoritePosts []uint
+		if err := tx.Model(&database.UserPostFavorite{}).Where("user_id = ?", uuid).
+			Pluck("post_id", &favoritePosts).Error; err != nil {
+			return errors.Wrap(err, "查询收藏失败")
+		}
+		affectedPosts = append(append(likedPosts, favoritePosts...), repostedPosts...)
+		// 在别人投票中的选择: 撤回并扣减票数
+		if err := tx.Exec(`UPDATE poll_options SET vote_count = GREATEST(vote_count - 1, 0)
+			WHERE id IN (SELECT option_id FROM poll_votes WHERE user_uuid = ?)`, uuid).Error; err != nil {
+			return errors.Wrap(err, "更新投票结果失败")
+		}
+		if err := tx.Exec(`UPDATE polls SET voter_count = GREATEST(voter_count - 1, 0)
+			WHERE post_id IN (SELECT DISTINCT post_id FROM poll_votes WHERE user_uuid = ?)`, uuid).Error; err != nil {
+			return errors.Wrap(err, "更新投票结果失败")
+		}
+		if err := tx.Where("user_uuid = ?", uuid).Delete(&database.PollVote{}).Error; err != nil {
+			return errors.Wrap(err, "删除投票记录失败")
+		}
+		if err := tx.Exec(`UPDATE post_comments SET like_number = GREATEST(like_number - 1, 0)
+			WHERE id IN (SELECT comment_id FROM comment_likes WHERE user_id = ? AND deleted_at IS NULL)`, uuid).Error; err != nil {
+			return errors.Wrap(err, "更新评论点赞数失败")
+		}
+		for _, model := range []interface{}{&database.UserPostLike{}, &database.UserPostFavorite{}, &database.CommentLike{}} {
+			if err := tx.Unscoped().Where("user_id = ?", uuid).Delete(model).Error; err != nil {
+				return errors.Wrap(err, "删除点赞收藏失败")
+			}
+		}
+
+		// 4. 关注关系、私信、匹配记录
+		if err := tx.Unscoped().Where("user_id = ? OR follow_id = ?", uuid, uuid).
+			Delete(&database.UserFollow{}).Error; err != nil {
+			return errors.Wrap(err, "删除关注关系失败")
+		}
+		if err := tx.Unscoped().Where("sender_uuid = ? OR receiver_uuid = ?", uuid, uuid).
+			Delete(&database.ChatMessage{}).Error; err != nil {
+			return errors.Wrap(err, "删除私信失败")
+		}
+		if err := tx.Unscoped().Where("user_uuid = ? OR match_uuid = ?", uuid, uuid).
+			Delete(&database.MatchResult{}).Error; err != nil {
+			return errors.Wrap(err, "删除匹配记录失败")
+		}
+		if err := tx.Unscoped().Where("user_uuid = ?", uuid).Delete(&database.TagFollow{}).Error; err != nil {
+			return errors.Wrap(err, "删除关注标签失败")
+		}
+		if err := tx.Where("user_uuid = ?", uuid).Delete(&database.UserTag{}).Error; err != nil {
+			return errors.Wrap(err, "删除用户标签失败")
+		}
+		// 别人对自己的提及变回普通文字; 自己收到和发出的通知一并删除
+		if err := tx.Where("user_uuid = ?", uuid).Delete(&database.Mention{}).Error; err != nil {
+			return errors.Wrap(err, "删除提及失败")
+		}
+		if err := tx.Where("user_uuid = ? OR actor_uuid = ?", uuid, uuid).Delete(&database.Notification{}).Error; err != nil {
+			return errors.Wrap(err, "删除通知失败")
+		}
+
+		// 5. 登录方式与凭证
+		if err := tx.Where("profile_uuid = ?", uuid).Delete(&database.AuthAccount{}).Error; err != nil {
+			return errors.Wrap(err, "删除登录方式失败")
+		}
+		if err := tx.Where("user_uuid = ?", uuid).Delete(&database.RecoveryCode{}).Error; err != nil {
+			return errors.Wrap(err, "删除恢复码失败")
+		}
+		if err := tx.Where("user_uuid = ?", uuid).Delete(&database.APIKey{}).Error; err != nil {
+			return errors.Wrap(err, "删除 API Key 失败")
+		}
+		emails := []string{user.Email}
+		if user.AcademicEmail != nil {
+			emails = append(emails, *user.AcademicEmail)
+		}
+		if err := tx.Where("email IN (?) OR user_uuid = ?", emails, uuid).
+			Delete(&database.VerifyCode{}).Error; err != nil {
+			return errors.Wrap(err, "删除验证码失败")
+		}
+
+		// 6. 用户本身
+		if err := tx.Where("uuid = ?", uuid).Delete(&database.User{}).Error; err != nil {
+			return errors.Wrap(err, "删除用户失败")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, postID := range affectedPosts {
+		_ = UpdatePostInfo(postID)
+	}
+	unindexUser(uuid)
+	unindexComments(removedComments...)
+	unindexPosts(postIDs...)
+	removePostEmbeddings(postIDs...)
+	return nil
+}
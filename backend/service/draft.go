@@ -0,0 +1,196 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	jgorm "github.com/jinzhu/gorm"
+)
+
+// published 只查询已发布的帖子, 草稿和定时发布的帖子不进入列表、信息流和搜索
+func published(db *jgorm.DB) *jgorm.DB {
+	return db.Where("posts.status = ?", database.PostStatusPublished)
+}
+
+// ownDraft 查询作者自己未发布的帖子（草稿或定时发布）
+func ownDraft(authorUUID string, postID uint) (database.Post, error) {
+	var post database.Post
+	if err := global.DB.First(&post, postID).Error; err != nil {
+		return post, errors.New("草稿不存在")
+	}
+	if post.AuthorUUID != authorUUID {
+		return post, errors.New("无权限修改该草稿")
+	}
+	if post.Status == database.PostStatusPublished {
+		return post, errors.New("帖子已发布, 请使用修改接口")
+	}
+	return post, nil
+}
+
+// SaveDraft 保存草稿, 供编辑器自动保存; post_id 为空时新建
+// 定时发布的帖子保存后仍保持定时状态
+func SaveDraft(authorUUID string, req request.SaveDraftRequest) (response.DraftSavedResponse, error) {
+	if err := validatePoll(req.Poll); err != nil {
+		return response.DraftSavedResponse{}, err
+	}
+	if req.ImageURLs == nil {
+		req.ImageURLs = []string{}
+	}
+	imgJSON, err := json.Marshal(req.ImageURLs)
+	if err != nil {
+		return response.DraftSavedResponse{}, err
+	}
+
+	var post database.Post
+	if req.PostID != 0 {
+		if post, err = ownDraft(authorUUID, req.PostID); err != nil {
+			return response.DraftSavedResponse{}, err
+		}
+	}
+
+	// 草稿和附件、投票、标签一起写入, 任何一步失败都不留下保存了一半的草稿
+	now := time.Now()
+	err = global.DB.Transaction(func(tx *jgorm.DB) error {
+		if req.PostID == 0 {
+			post = database.Post{
+				AuthorUUID: authorUUID,
+				Title:      req.Title,
+				Content:    req.Content,
+				ImageURLs:  imgJSON,
+				CreateDate: now,
+				Status:     database.PostStatusDraft,
+				Visibility: normalizeVisibility(req.Visibility),
+			}
+			if err := tx.Create(&post).Error; err != nil {
+				return err
+			}
+		} else {
+			fields := map[string]interface{}{
+				"title":      req.Title,
+				"content":    req.Content,
+				"image_urls": imgJSON,
+			}
+			if req.Visibility != "" {
+				fields["visibility"] = req.Visibility
+			}
+			if err := tx.Model(&post).Updates(fields).Error; err != nil {
+				return err
+			}
+		}
+		if req.Attachments != nil {
+			if err := setPostAttachments(tx, post.ID, authorUUID, req.Attachments); err != nil {
+				return err
+			}
+		}
+		if req.Poll != nil {
+			if err := setPostPoll(tx, post.ID, req.Poll); err != nil {
+				return err
+			}
+		}
+		if err := setPostTags(tx, post.ID, req.Tags, req.Title, req.Content); err != nil {
+			return errors.New("保存标签失败")
+		}
+		return nil
+	})
+	if err != nil {
+		return response.DraftSavedResponse{}, err
+	}
+	// 草稿只保存提及, 发布时再通知
+	if err := syncMentions(post.ID, 0, req.Content); err != nil {
+		log.Println("[Mention] 保存提及失败:", post.ID, err)
+	}
+	return response.DraftSavedResponse{PostID: post.ID, SavedAt: now}, nil
+}
+
+// ListDrafts 我的草稿与定时发布的帖子, 最近创建的在前
+func ListDrafts(authorUUID string, page request.CursorPage) ([]response.PostInfo, response.PageInfo, error) {
+	db := global.DB.Model(&database.Post{}).
+		Where("author_uuid = ? AND status IN (?)", authorUUID, []string{database.PostStatusDraft, database.PostStatusScheduled})
+	return queryPostPage(db, page, sortKey{Desc: true}, authorUUID)
+}
+
+// PublishPost 发布草稿; publishAt 为将来的时间时改为定时发布
+func PublishPost(authorUUID string, postID uint, publishAt *time.Time) error {
+	post, err := ownDraft(authorUUID, postID)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(post.Title) == "" || strings.TrimSpace(post.Content) == "" {
+		return errors.New("标题和内容不能为空")
+	}
+
+	if publishAt != nil && publishAt.After(time.Now()) {
+		return global.DB.Model(&post).Updates(map[string]interface{}{
+			"status":     database.PostStatusScheduled,
+			"publish_at": publishAt,
+		}).Error
+	}
+	return publishPost(post, time.Now())
+}
+
+// CancelSchedule 取消定时发布, 帖子退回草稿
+func CancelSchedule(authorUUID string, postID uint) error {
+	post, err := ownDraft(authorUUID, postID)
+	if err != nil {
+		return err
+	}
+	if post.Status != database.PostStatusScheduled {
+		return errors.New("该帖子没有定时发布")
+	}
+	return global.DB.Model(&post).Updates(map[string]interface{}{
+		"status":     database.PostStatusDraft,
+		"publish_at": nil,
+	}).Error
+}
+
+// publishPost 把帖子置为已发布, 发帖时间记为 at, 随后进入搜索与推荐
+func publishPost(post database.Post, at time.Time) error {
+	post.CreateDate = at
+	// 条件更新, 避免定时任务和手动发布同时处理同一篇帖子
+	res := global.DB.Model(&database.Post{}).
+		Where("id = ? AND status <> ?", post.ID, database.PostStatusPublished).
+		Updates(map[string]interface{}{
+			"status":      database.PostStatusPublished,
+			"create_date": at,
+			"publish_at":  nil,
+			"hot_score":   hotScore(post, at),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil
+	}
+
+	post.Status = database.PostStatusPublished
+	post.PublishAt = nil
+	if err := recordRevision(global.DB, post, post.AuthorUUID); err != nil {
+		log.Println("[Revision] 保存帖子版本失败:", post.ID, err)
+	}
+	indexPost(post)
+	embedPostAsync(post)
+	notifyMentions(post, 0, post.AuthorUUID)
+	return nil
+}
+
+// PublishScheduledPosts 发布到点的定时帖子, 由定时任务调用
+func PublishScheduledPosts() error {
+	var posts []database.Post
+	if err := global.DB.Where("status = ? AND publish_at <= ?", database.PostStatusScheduled, time.Now()).
+		Find(&posts).Error; err != nil {
+		return err
+	}
+	for _, p := range posts {
+		if err := publishPost(p, *p.PublishAt); err != nil {
+			log.Println("[Draft] 定时发布失败:", p.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"OpenHouse/model/response"
+	"OpenHouse/utils"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	relatedPostCount     = 5   // 详情页相似帖子数量
+	minRelatedSimilarity = 0.2 // 低于该相似度的帖子不推荐
+	embeddingBatchSize   = 100
+)
+
+var (
+	embedderOnce sync.Once
+	embedder     utils.Embedder
+)
+
+// postEmbedder 首次使用时根据配置创建向量化实现
+func postEmbedder() utils.Embedder {
+	embedderOnce.Do(func() {
+		embedder = utils.NewEmbedder()
+	})
+	return embedder
+}
+
+// embeddingCache 当前模型下全部帖子向量的内存缓存, 相似度检索为暴力扫描
+var embeddingCache = struct {
+	sync.RWMutex
+	loaded  bool
+	vectors map[uint][]float32
+}{vectors: map[uint][]float32{}}
+
+func loadEmbeddingCache() error {
+	embeddingCache.RLock()
+	loaded := embeddingCache.loaded
+	embeddingCache.RUnlock()
+	if loaded {
+		return nil
+	}
+
+	var rows []database.PostEmbedding
+	if err := global.DB.Where("model = ?", postEmbedder().Model()).Find(&rows).Error; err != nil {
+		return err
+	}
+	embeddingCache.Lock()
+	defer embeddingCache.Unlock()
+	for _, r := range rows {
+		embeddingCache.vectors[r.PostID] = utils.DecodeVector(r.Vector)
+	}
+	embeddingCache.loaded = true
+	return nil
+}
+
+func postEmbeddingText(post database.Post) string {
+	return post.Title + "\n" + post.Content
+}
+
+// embedPost 计算并保存帖子向量, 内容和模型都没变时跳过
+func embedPost(post database.Post) error {
+	e := postEmbedder()
+	text := postEmbeddingText(post)
+	sum := sha256.Sum256([]byte(e.Model() + "\n" + text))
+	hash := hex.EncodeToString(sum[:])
+
+	var existing database.PostEmbedding
+	if err := global.DB.Where("post_id = ?", post.ID).First(&existing).Error; err == nil && existing.ContentHash == hash {
+		return nil
+	}
+
+	vec, err := e.Embed(text)
+	if err != nil {
+		return err
+	}
+	if err := global.DB.Exec(`INSERT INTO post_embeddings (post_id, model, dim, vector, content_hash, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE model = VALUES(model), dim = VALUES(dim), vector = VALUES(vector),
+			content_hash = VALUES(content_hash), updated_at = VALUES(updated_at)`,
+		post.ID, e.Model(), len(vec), utils.EncodeVector(vec), hash, time.Now()).Error; err != nil {
+		return err
+	}
+
+	embeddingCache.Lock()
+	embeddingCache.vectors[post.ID] = vec
+	embeddingCache.Unlock()
+	return nil
+}
+
+// embedPostAsync 发帖 / 改帖后异步计算向量, 外部接口较慢时不阻塞请求, 失败的由定时任务补算
+func embedPostAsync(post database.Post) {
+	if post.Status != database.PostStatusPublished || isPlainRepost(post) {
+		return
+	}
+	go func() {
+		if err := embedPost(post); err != nil {
+			log.Println("[Embedding] 计算帖子向量失败:", post.ID, err)
+		}
+	}()
+}
+
+// removePostEmbeddings 删除帖子向量
+func removePostEmbeddings(postIDs ...uint) {
+	if len(postIDs) == 0 {
+		return
+	}
+	if err := global.DB.Where("post_id IN (?)", postIDs).Delete(&database.PostEmbedding{}).Error; err != nil {
+		log.Println("[Embedding] 删除帖子向量失败:", err)
+	}
+	embeddingCache.Lock()
+	for _, id := range postIDs {
+		delete(embeddingCache.vectors, id)
+	}
+	embeddingCache.Unlock()
+}
+
+// BackfillPostEmbeddings 为还没有向量或向量来自其他模型的帖子补算向量, 由定时任务调用
+func BackfillPostEmbeddings() error {
+	model := postEmbedder().Model()
+	var lastID uint
+	for {
+		var posts []database.Post
+		if err := global.DB.Select("posts.*").
+			Joins("LEFT JOIN post_embeddings ON post_embeddings.post_id = posts.id").
+			Where("posts.id > ? AND (post_embeddings.post_id IS NULL OR post_embeddings.model <> ?)", lastID, model).
+			Scopes(published, notPlainRepost).
+			Order("posts.id").
+			Limit(embeddingBatchSize).
+			Find(&posts).Error; err != nil {
+			return err
+		}
+		for _, p := range posts {
+			if err := embedPost(p); err != nil {
+				return err
+			}
+		}
+		if len(posts) < embeddingBatchSize {
+			return nil
+		}
+		lastID = posts[len(posts)-1].ID
+	}
+}
+
+type scoredPost struct {
+	PostID     uint
+	Similarity float64
+}
+
+// nearestPosts 按相似度从高到低返回全部帖子, exclude 中的帖子不参与
+func nearestPosts(vec []float32, exclude map[uint]bool) ([]scoredPost, error) {
+	if err := loadEmbeddingCache(); err != nil {
+		return nil, err
+	}
+	embeddingCache.RLock()
+	scored := make([]scoredPost, 0, len(embeddingCache.vectors))
+	for id, v := range embeddingCache.vectors {
+		if exclude[id] {
+			continue
+		}
+		scored = append(scored, scoredPost{PostID: id, Similarity: utils.CosineSimilarity(vec, v)})
+	}
+	embeddingCache.RUnlock()
+
+	sort.Slice(scored, func(i, j int) bool {
+		return scored[i].Similarity > scored[j].Similarity
+	})
+	return scored, nil
+}
+
+// loadScoredPosts 按相似度顺序查出 viewer 能看到的前 k 个帖子, 已删除或不可见的帖子跳过
+// 每次多取几倍候选, 过滤后不足 k 个时继续往后取, 直到凑满或相似度低于 minSimilarity
+func loadScoredPosts(scored []scoredPost, k int, minSimilarity float64, viewer string) []database.Post {
+	result := make([]database.Post, 0, k)
+	batch := 4 * k
+	for start := 0; start < len(scored) && len(result) < k; start += batch {
+		end := start + batch
+		if end > len(scored) {
+			end = len(scored)
+		}
+		ids := make([]uint, 0, end-start)
+		for _, s := range scored[start:end] {
+			if s.Similarity >= minSimilarity {
+				ids = append(ids, s.PostID)
+			}
+		}
+		if len(ids) == 0 {
+			break
+		}
+		var posts []database.Post
+		_ = global.DB.Scopes(published, visibleTo(viewer)).Where("id IN (?)", ids).Find(&posts)
+		postMap := make(map[uint]database.Post, len(posts))
+		for _, p := range posts {
+			postMap[p.ID] = p
+		}
+		for _, id := range ids {
+			if p, ok := postMap[id]; ok && len(result) < k {
+				result = append(result, p)
+			}
+		}
+		if len(ids) < end-start {
+			// 后面的相似度都低于下限
+			break
+		}
+	}
+	return result
+}
+
+// SemanticSearch 按语义相似度搜索帖子
+func SemanticSearch(query string, limit int, userUUID string) ([]response.SimilarPostInfo, error) {
+	vec, err := postEmbedder().Embed(query)
+	if err != nil {
+		return nil, errors.New("计算查询向量失败")
+	}
+	scored, err := nearestPosts(vec, nil)
+	if err != nil {
+		return nil, errors.New("加载帖子向量失败")
+	}
+	similarity := make(map[uint]float64, len(scored))
+	for _, s := range scored {
+		similarity[s.PostID] = s.Similarity
+	}
+
+	posts := loadScoredPosts(scored, limit, 0, userUUID)
+	result := make([]response.SimilarPostInfo, 0, len(posts))
+	for i, info := range utils.ConvertPostModels(posts, userUUID) {
+		result = append(result, response.SimilarPostInfo{
+			PostInfo:   info,
+			Similarity: similarity[posts[i].ID],
+		})
+	}
+	return result, nil
+}
+
+// relatedPosts 详情页的相似帖子, 只返回摘要信息
+func relatedPosts(postID uint, viewer string) []response.RelatedPost {
+	if err := loadEmbeddingCache(); err != nil {
+		return []response.RelatedPost{}
+	}
+	embeddingCache.RLock()
+	vec, ok := embeddingCache.vectors[postID]
+	embeddingCache.RUnlock()
+	if !ok {
+		return []response.RelatedPost{}
+	}
+
+	scored, err := nearestPosts(vec, map[uint]bool{postID: true})
+	if err != nil {
+		return []response.RelatedPost{}
+	}
+	similarity := make(map[uint]float64, len(scored))
+	for _, s := range scored {
+		similarity[s.PostID] = s.Similarity
+	}
+
+	posts := loadScoredPosts(scored, relatedPostCount, minRelatedSimilarity, viewer)
+	authorUUIDs := make([]string, 0, len(posts))
+	for _, p := range posts {
+		authorUUIDs = append(authorUUIDs, p.AuthorUUID)
+	}
+	userMap := make(map[string]database.User)
+	if len(authorUUIDs) > 0 {
+		var users []database.User
+		_ = global.DB.Where("uuid IN (?)", authorUUIDs).Find(&users)
+		for _, u := range users {
+			userMap[u.UUID] = u
+		}
+	}
+
+	result := make([]response.RelatedPost, 0, len(posts))
+	for _, p := range posts {
+		author := userMap[p.AuthorUUID]
+		result = append(result, response.RelatedPost{
+			PostID:     p.ID,
+			Title:      p.Title,
+			AuthorUUID: p.AuthorUUID,
+			Username:   author.Username,
+			AvatarURL:  author.AvatarURL,
+			CreateDate: p.CreateDate,
+			Similarity: similarity[p.ID],
+		})
+	}
+	return result
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/utils"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	jgorm "github.com/jinzhu/gorm"
+)
+
+// 帖子流模式
+const (
+	FeedFresh        = "fresh"
+	FeedHot          = "hot"
+	FeedPersonalized = "personalized"
+)
+
+// 热度分参数: 互动加权和 / (发帖小时数 + 2)^gravity
+const (
+	hotStarWeight     = 2.0
+	hotFavoriteWeight = 3.0
+	hotCommentWeight  = 4.0
+	hotViewWeight     = 0.1
+	hotGravity        = 1.5
+	hotWindowDays     = 30 // 超过该天数的帖子热度分置 0, 不再刷新
+)
+
+// 个性化加权: 最终分 = (热度分 + 基础分) * (1 + 各项加成)
+const (
+	personalBase         = 0.05
+	personalFollowBoost  = 1.0 // 关注的作者
+	personalMatchBoost   = 0.8 // 匹配过的用户
+	personalTagBoost     = 0.5 // 每命中一个我的标签 / 研究领域
+	personalTopicBoost   = 0.8 // 带有我关注的标签
+	personalMaxTagBoosts = 3
+	personalHotPool      = 300 // 候选集: 近期热度最高的帖子数
+	personalAuthorPool   = 200 // 候选集: 关注 / 匹配作者、关注标签的最新帖子数
+	personalWindowDays   = 14
+)
+
+// hotScore 计算帖子热度分
+func hotScore(post database.Post, now time.Time) float64 {
+	engagement := 1 +
+		hotStarWeight*float64(post.StarNumber) +
+		hotFavoriteWeight*float64(post.FavoriteNumber) +
+		hotCommentWeight*float64(post.CommentNumber) +
+		hotViewWeight*float64(post.ViewNumber)
+	ageHours := now.Sub(post.CreateDate).Hours()
+	if ageHours < 0 {
+		ageHours = 0
+	}
+	return engagement / math.Pow(ageHours+2, hotGravity)
+}
+
+// hotScoreSQL 与 hotScore 相同的公式, 用于在数据库中批量计算, 参数为 hotScoreArgs(now)
+const hotScoreSQL = `(1 + ? * star_number + ? * favorite_number + ? * comment_number + ? * view_number) /
+	POW(GREATEST(TIMESTAMPDIFF(SECOND, create_date, ?), 0) / 3600 + 2, ?)`
+
+func hotScoreArgs(now time.Time) []interface{} {
+	return []interface{}{hotStarWeight, hotFavoriteWeight, hotCommentWeight, hotViewWeight, now, hotGravity}
+}
+
+// RefreshHotScores 刷新近期帖子的热度分, 由定时任务调用; 一条 UPDATE 完成, 不逐行读写
+func RefreshHotScores() error {
+	now := time.Now()
+	since := now.AddDate(0, 0, -hotWindowDays)
+
+	if err := global.DB.Model(&database.Post{}).Scopes(published).
+		Where("create_date >= ?", since).
+		UpdateColumn("hot_score", jgorm.Expr(hotScoreSQL, hotScoreArgs(now)...)).Error; err != nil {
+		return err
+	}
+
+	// 过了窗口期的帖子不再上热门
+	return global.DB.Model(&database.Post{}).
+		Where("create_date < ? AND hot_score > 0", since).
+		UpdateColumn("hot_score", 0).Error
+}
+
+// ListFeed 首页帖子流, mode 为 fresh / hot / personalized
+func ListFeed(mode string, page request.CursorPage, sortOrder string, userUUID string) ([]response.PostInfo, response.PageInfo, error) {
+	switch mode {
+	case FeedHot:
+		return listHotPosts(page, userUUID)
+	case FeedPersonalized:
+		return listPersonalizedPosts(page, userUUID)
+	default:
+		return ListPosts(page, sortOrder, userUUID)
+	}
+}
+
+func listHotPosts(page request.CursorPage, userUUID string) ([]response.PostInfo, response.PageInfo, error) {
+	db := global.DB.Model(&database.Post{}).Scopes(published, notPlainRepost, visibleTo(userUUID))
+	return queryPostPage(db, page, sortKey{Col: "hot_score", Desc: true}, userUUID)
+}
+
+// listPersonalizedPosts 个性化推荐:
+// 候选集为近期热门帖子 + 关注 / 匹配作者、关注标签的最新帖子, 在热度分基础上按关注、匹配、标签命中加权排序
+// 排序在内存中完成, 游标记录上一页最后一条的 (分数, id), 下一页从它之后开始, 排名变化时不会按偏移量重复或跳过
+func listPersonalizedPosts(page request.CursorPage, userUUID string) ([]response.PostInfo, response.PageInfo, error) {
+	cur, err := decodePageCursor(page)
+	if err != nil {
+		return nil, response.PageInfo{}, err
+	}
+
+	var user database.User
+	if err := global.DB.Where("uuid = ?", userUUID).First(&user).Error; err != nil {
+		return listHotPosts(page, userUUID)
+	}
+
+	var followIDs []string
+	global.DB.Model(&database.UserFollow{}).Where("user_id = ?", userUUID).Pluck("follow_id", &followIDs)
+	// 匹配结果只存在匹配发起方一侧, 两个方向都算匹配过, 与 utils.VisiblePostCondition 一致
+	var matchIDs, matchedBy []string
+	global.DB.Model(&database.MatchResult{}).Where("user_uuid = ?", userUUID).Pluck("match_uuid", &matchIDs)
+	global.DB.Model(&database.MatchResult{}).Where("match_uuid = ?", userUUID).Pluck("user_uuid", &matchedBy)
+	matchIDs = append(matchIDs, matchedBy...)
+
+	followed := make(map[string]bool, len(followIDs))
+	for _, id := range followIDs {
+		followed[id] = true
+	}
+	matched := make(map[string]bool, len(matchIDs))
+	for _, id := range matchIDs {
+		matched[id] = true
+	}
+
+	// 兴趣词: 标签 + 研究领域, 按标签规则归一化
+	var interests []string
+	for _, t := range append(utils.ParseTags(user.Tags), user.ResearchArea) {
+		if t = utils.NormalizeTag(t); t != "" {
+			interests = append(interests, t)
+		}
+	}
+
+	since := time.Now().AddDate(0, 0, -personalWindowDays)
+	candidates := make(map[uint]database.Post)
+	var hot []database.Post
+	if err := global.DB.Scopes(published, notPlainRepost, visibleTo(userUUID)).Where("create_date >= ?", since).
+		Order("hot_score desc").Limit(personalHotPool).Find(&hot).Error; err != nil {
+		return nil, response.PageInfo{}, err
+	}
+	for _, p := range hot {
+		candidates[p.ID] = p
+	}
+	authors := append(append([]string{}, followIDs...), matchIDs...)
+	if len(authors) > 0 {
+		var authorPosts []database.Post
+		if err := global.DB.Scopes(published, notPlainRepost, visibleTo(userUUID)).Where("author_uuid IN (?) AND create_date >= ?", authors, since).
+			Order("create_date desc").Limit(personalAuthorPool).Find(&authorPosts).Error; err != nil {
+			return nil, response.PageInfo{}, err
+		}
+		for _, p := range authorPosts {
+			candidates[p.ID] = p
+		}
+	}
+	followedTopics := make(map[uint]bool)
+	if tagIDs := followedTagIDs(userUUID); len(tagIDs) > 0 {
+		var topicPosts []database.Post
+		if err := global.DB.Scopes(published, notPlainRepost, visibleTo(userUUID)).Where("id IN (SELECT post_id FROM post_tags WHERE tag_id IN (?)) AND create_date >= ?", tagIDs, since).
+			Order("create_date desc").Limit(personalAuthorPool).Find(&topicPosts).Error; err != nil {
+			return nil, response.PageInfo{}, err
+		}
+		for _, p := range topicPosts {
+			candidates[p.ID] = p
+		}
+		var tagged []uint
+		global.DB.Model(&database.PostTag{}).Where("tag_id IN (?) AND post_id IN (?)", tagIDs, candidateIDs(candidates)).
+			Pluck("post_id", &tagged)
+		for _, id := range tagged {
+			followedTopics[id] = true
+		}
+	}
+
+	postSlugs := candidateTagSlugs(candidateIDs(candidates))
+
+	type scored struct {
+		post  database.Post
+		score float64
+	}
+	list := make([]scored, 0, len(candidates))
+	for _, p := range candidates {
+		if p.AuthorUUID == userUUID {
+			continue
+		}
+		boost := 0.0
+		if followed[p.AuthorUUID] {
+			boost += personalFollowBoost
+		}
+		if matched[p.AuthorUUID] {
+			boost += personalMatchBoost
+		}
+		if followedTopics[p.ID] {
+			boost += personalTopicBoost
+		}
+		words := textWords(p.Title + "\n" + p.Content)
+		hits := 0
+		for _, slug := range interests {
+			if hits < personalMaxTagBoosts && (postSlugs[p.ID][slug] || words[slug]) {
+				hits++
+			}
+		}
+		boost += personalTagBoost * float64(hits)
+		list = append(list, scored{post: p, score: (p.HotScore + personalBase) * (1 + boost)})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].score != list[j].score {
+			return list[i].score > list[j].score
+		}
+		return list[i].post.ID > list[j].post.ID
+	})
+
+	start := 0
+	if cur != nil {
+		start = sort.Search(len(list), func(i int) bool {
+			return list[i].score < cur.Value || (list[i].score == cur.Value && list[i].post.ID < cur.ID)
+		})
+	} else if !page.UseCursor() {
+		start = (page.PageNum - 1) * page.PageSize
+	}
+	if start < 0 || start > len(list) {
+		start = len(list)
+	}
+	end := start + page.PageSize
+	if end > len(list) {
+		end = len(list)
+	}
+
+	info := response.PageInfo{HasMore: end < len(list)}
+	if !page.UseCursor() {
+		info.Total = int64(len(list))
+	}
+	if info.HasMore {
+		last := list[end-1]
+		info.NextCursor = utils.EncodeCursor(utils.Cursor{Value: last.score, ID: last.post.ID})
+	}
+
+	posts := make([]database.Post, 0, end-start)
+	for _, s := range list[start:end] {
+		posts = append(posts, s.post)
+	}
+	return utils.ConvertPostModels(posts, userUUID), info, nil
+}
+
+// candidateTagSlugs 候选帖子的标签, 按帖子分组
+func candidateTagSlugs(postIDs []uint) map[uint]map[string]bool {
+	slugs := make(map[uint]map[string]bool)
+	if len(postIDs) == 0 {
+		return slugs
+	}
+	var rows []struct {
+		PostID uint
+		Slug   string
+	}
+	global.DB.Table("post_tags").
+		Select("post_tags.post_id, tags.slug").
+		Joins("JOIN tags ON tags.id = post_tags.tag_id").
+		Where("post_tags.post_id IN (?)", postIDs).
+		Scan(&rows)
+	for _, r := range rows {
+		if slugs[r.PostID] == nil {
+			slugs[r.PostID] = make(map[string]bool)
+		}
+		slugs[r.PostID][r.Slug] = true
+	}
+	return slugs
+}
+
+// textWords 正文中的单词（按字母数字以外的字符切分）及相邻单词用 - 连接的词组, 与标签的归一化形式一致
+// 兴趣词只按整词命中, 避免 "ai" 命中 "said" 这类子串
+func textWords(text string) map[string]bool {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	words := make(map[string]bool, len(fields)*3)
+	for i := range fields {
+		phrase := fields[i]
+		words[phrase] = true
+		// 研究领域常是两三个词, 如 machine-learning
+		for j := i + 1; j < len(fields) && j < i+3; j++ {
+			phrase += "-" + fields[j]
+			words[phrase] = true
+		}
+	}
+	return words
+}
+
+func candidateIDs(candidates map[uint]database.Post) []uint {
+	ids := make([]uint, 0, len(candidates))
+	for id := range candidates {
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -3,8 +3,10 @@ package service
 import (
 	"OpenHouse/global"
 	"OpenHouse/model/database"
+	"OpenHouse/model/request"
 	"OpenHouse/model/response"
 	"OpenHouse/utils"
+	"time"
 
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
@@ -23,19 +25,18 @@ func FollowUser(userUUID, followedUUID string) error {
 
 	var relation database.UserFollow
 	err := global.DB.
-		Where("user_id = ? AND follow_id = ?", userUUID, followedUUID).
+		Where("user_id = ? AND follow_id = ? AND deleted_at IS NULL", userUUID, followedUUID).
 		First(&relation).Error
-
 	if err == nil {
-		if relation.DeletedAt.Valid {
-			return global.DB.Model(&relation).Update("deleted_at", nil).Error
-		}
 		return errors.New("请勿重复关注")
 	}
 
+	// 关注记录只追加不恢复: 取关的记录保留关注时间和取关时间, 再次关注新建一条, 作者看板按天汇总时历史不变
+	now := time.Now()
 	newRelation := database.UserFollow{
-		UserID:   userUUID,
-		FollowID: followedUUID,
+		UserID:    userUUID,
+		FollowID:  followedUUID,
+		CreatedAt: &now,
 	}
 	return global.DB.Create(&newRelation).Error
 }
@@ -52,90 +53,74 @@ func UnfollowUser(userUUID, followedUUID string) error {
 	return global.DB.Delete(&relation).Error
 }
 
-func ListFollowedUsers(userUUID string, pageNum, pageSize int) ([]response.FollowedUserInfo, int64, error) {
-	var relations []database.UserFollow
-	var total int64
-
-	if err := global.DB.Model(&database.UserFollow{}).
-		Where("user_id = ? AND deleted_at IS NULL", userUUID).
-		Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	if err := global.DB.
-		Where("user_id = ? AND deleted_at IS NULL", userUUID).
-		Order("id desc").
-		Limit(pageSize).
-		Offset((pageNum - 1) * pageSize).
-		Find(&relations).Error; err != nil {
-		return nil, 0, err
-	}
-
-	followIDs := make([]string, 0, len(relations))
-	for _, r := range relations {
-		followIDs = append(followIDs, r.FollowID)
-	}
+// ListFollowedUsers 我关注的人, 按关注先后倒序
+func ListFollowedUsers(userUUID string, page request.CursorPage) ([]response.FollowedUserInfo, response.PageInfo, error) {
+	return listFollowRelations("user_id", "follow_id", userUUID, page)
+}
 
-	var users []database.User
-	if len(followIDs) == 0 {
-		return []response.FollowedUserInfo{}, total, nil
-	}
-	if err := global.DB.Where("uuid IN ?", followIDs).Find(&users).Error; err != nil {
-		return nil, 0, err
-	}
+// ListFollowers 关注我的人, 按关注先后倒序
+func ListFollowers(userUUID string, page request.CursorPage) ([]response.FollowedUserInfo, response.PageInfo, error) {
+	return listFollowRelations("follow_id", "user_id", userUUID, page)
+}
 
-	result := make([]response.FollowedUserInfo, 0, len(users))
-	for _, u := range users {
-		result = append(result, response.FollowedUserInfo{
-			UUID:      u.UUID,
-			Username:  u.Username,
-			AvatarURL: u.AvatarURL,
-		})
+// listFollowRelations 按 whereCol = uuid 分页查询关注关系, 返回 otherCol 一侧的用户
+func listFollowRelations(whereCol, otherCol, uuid string, page request.CursorPage) ([]response.FollowedUserInfo, response.PageInfo, error) {
+	cur, err := decodePageCursor(page)
+	if err != nil {
+		return nil, response.PageInfo{}, err
 	}
-	return result, total, nil
-}
 
-func ListFollowers(userUUID string, pageNum, pageSize int) ([]response.FollowedUserInfo, int64, error) {
-	var relations []database.UserFollow
+	db := global.DB.Model(&database.UserFollow{}).Where(whereCol+" = ? AND deleted_at IS NULL", uuid)
 	var total int64
-
-	if err := global.DB.Model(&database.UserFollow{}).
-		Where("follow_id = ? AND deleted_at IS NULL", userUUID).
-		Count(&total).Error; err != nil {
-		return nil, 0, err
+	if !page.UseCursor() {
+		if err := db.Count(&total).Error; err != nil {
+			return nil, response.PageInfo{}, err
+		}
 	}
 
-	if err := global.DB.
-		Where("follow_id = ? AND deleted_at IS NULL", userUUID).
-		Order("id desc").
-		Limit(pageSize).
-		Offset((pageNum - 1) * pageSize).
-		Find(&relations).Error; err != nil {
-		return nil, 0, err
+	var relations []database.UserFollow
+	if err := (sortKey{Desc: true}).scope(db, page, cur).Find(&relations).Error; err != nil {
+		return nil, response.PageInfo{}, err
+	}
+	n, info := pageResult(len(relations), page, total, func(i int) utils.Cursor {
+		return utils.Cursor{ID: relations[i].ID}
+	})
+
+	uuids := make([]string, 0, n)
+	for _, r := range relations[:n] {
+		if otherCol == "follow_id" {
+			uuids = append(uuids, r.FollowID)
+		} else {
+			uuids = append(uuids, r.UserID)
+		}
 	}
-
-	userIDs := make([]string, 0, len(relations))
-	for _, r := range relations {
-		userIDs = append(userIDs, r.UserID)
+	if len(uuids) == 0 {
+		return []response.FollowedUserInfo{}, info, nil
 	}
 
 	var users []database.User
-	if len(userIDs) == 0 {
-		return []response.FollowedUserInfo{}, total, nil
+	if err := global.DB.Where("uuid IN (?)", uuids).Find(&users).Error; err != nil {
+		return nil, response.PageInfo{}, err
 	}
-	if err := global.DB.Where("uuid IN ?", userIDs).Find(&users).Error; err != nil {
-		return nil, 0, err
+	userMap := make(map[string]database.User, len(users))
+	for _, u := range users {
+		userMap[u.UUID] = u
 	}
 
+	// 保持关注先后顺序
 	result := make([]response.FollowedUserInfo, 0, len(users))
-	for _, u := range users {
+	for _, id := range uuids {
+		u, ok := userMap[id]
+		if !ok {
+			continue
+		}
 		result = append(result, response.FollowedUserInfo{
 			UUID:      u.UUID,
 			Username:  u.Username,
 			AvatarURL: u.AvatarURL,
 		})
 	}
-	return result, total, nil
+	return result, info, nil
 }
 
 func GetFollowCount(userUUID string) (int64, int64, error) {
@@ -172,12 +157,12 @@ func IsFollowing(userUUID, targetUUID string) (bool, error) {
 	return false, err
 }
 
-func ListFollowedPosts(userUUID string, pageNum, pageSize int, sortOrder string) ([]response.PostInfo, int64, error) {
+func ListFollowedPosts(userUUID string, page request.CursorPage, sortOrder string) ([]response.PostInfo, response.PageInfo, error) {
 	var relations []database.UserFollow
 	if err := global.DB.
 		Where("user_id = ? AND deleted_at IS NULL", userUUID).
 		Find(&relations).Error; err != nil {
-		return nil, 0, err
+		return nil, response.PageInfo{}, err
 	}
 
 	followUUIDs := make([]string, 0, len(relations))
@@ -185,35 +170,21 @@ func ListFollowedPosts(userUUID string, pageNum, pageSize int, sortOrder string)
 		followUUIDs = append(followUUIDs, r.FollowID)
 	}
 
-	if len(followUUIDs) == 0 {
-		return []response.PostInfo{}, 0, nil
-	}
-
-	var posts []database.Post
-	var total int64
-
-	db := global.DB.Model(&database.Post{}).Where("author_uuid IN (?)", followUUIDs)
-	if err := db.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	order := "create_date desc"
-	if sortOrder == "asc" {
-		order = "create_date asc"
-	}
-
-	if err := db.Order(order).
-		Limit(pageSize).
-		Offset((pageNum - 1) * pageSize).
-		Find(&posts).Error; err != nil {
-		return nil, 0, err
-	}
+	// 关注的标签下的帖子也出现在关注流中
+	tagIDs := followedTagIDs(userUUID)
 
-	result := make([]response.PostInfo, 0, len(posts))
-	for _, p := range posts {
-		result = append(result, utils.ConvertPostModelWithUser(p, userUUID))
+	db := global.DB.Model(&database.Post{})
+	switch {
+	case len(followUUIDs) > 0 && len(tagIDs) > 0:
+		db = db.Where("author_uuid IN (?) OR id IN (SELECT post_id FROM post_tags WHERE tag_id IN (?))", followUUIDs, tagIDs)
+	case len(followUUIDs) > 0:
+		db = db.Where("author_uuid IN (?)", followUUIDs)
+	case len(tagIDs) > 0:
+		db = db.Where("id IN (SELECT post_id FROM post_tags WHERE tag_id IN (?))", tagIDs)
+	default:
+		return []response.PostInfo{}, response.PageInfo{}, nil
 	}
-	return result, total, nil
+	return listPostsWhere(db, page, sortOrder, userUUID)
 }
 
 // CheckIsFollowing 判断当前用户是否已关注目标用户
@@ -14,12 +14,7 @@ import (
 )
 
 func markUserMatchStatus(userUUID string, status string) error {
-	var input request.UpdateProfileInput
-	jsoninfo := `{"match_status": "` + status + `"}`
-	if err := json.Unmarshal([]byte(jsoninfo), &input); err != nil {
-		return errors.New("解析用户信息失败")
-	}
-	if err := UpdateProfile(userUUID, input); err != nil {
+	if err := UpdateProfileSystem(userUUID, ProfileSystemUpdate{MatchStatus: &status}); err != nil {
 		return errors.New("更新用户信息失败")
 	}
 	return nil
@@ -46,7 +41,8 @@ func TriggerDailyConfirm() error {
 func TriggerDailyMatch() error {
 	// Step 1：拉取所有符合条件的用户，match_status = "matching"
 	var users []database.User
-	if err := global.DB.Where("match_status = ?", "matching").Find(&users).Error; err != nil {
+	// 已封禁或申请注销的用户不参与匹配
+	if err := global.DB.Where("match_status = ? AND is_banned = ? AND deletion_scheduled_at IS NULL", "matching", false).Find(&users).Error; err != nil {
 		return errors.New("拉取用户失败")
 	}
 	fmt.Println("匹配用户数量:", len(users))
@@ -136,7 +132,7 @@ func TriggerUserMatch(userUUID string) error {
 
 	// Step 2：拉取所有符合条件的用户
 	var users []database.User
-	if err := global.DB.Find(&users).Error; err != nil {
+	if err := global.DB.Where("is_banned = ? AND deletion_scheduled_at IS NULL", false).Find(&users).Error; err != nil {
 		return errors.New("拉取用户失败")
 	}
 
@@ -268,7 +264,7 @@ func BuildUserMatchInfo(user database.User) request.MatchUserInfoForLLM {
 
 	// 查询用户最新的一条帖子
 	var post database.Post
-	err := global.DB.
+	err := global.DB.Scopes(published).
 		Where("author_uuid = ?", user.UUID).
 		Order("create_date desc").
 		First(&post).Error
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/utils"
+	"errors"
+	"log"
+	"time"
+
+	jgorm "github.com/jinzhu/gorm"
+)
+
+// notificationExcerptRunes 通知中帖子 / 评论摘要的最大字数
+const notificationExcerptRunes = 80
+
+// syncMentions 按正文重建帖子正文（commentID 为 0）或评论的提及记录
+// 按用户名唯一键匹配, 不区分大小写; 找不到对应用户的 @ 当作普通文字
+func syncMentions(postID, commentID uint, content string) error {
+	names := utils.ExtractMentions(content)
+	userMap := make(map[string]database.User, len(names))
+	if len(names) > 0 {
+		lower := make([]string, 0, len(names))
+		for _, name := range names {
+			lower = append(lower, usernameKey(name))
+		}
+		var users []database.User
+		if err := global.DB.Where("username_key IN (?) AND uuid <> ?", lower, DeletedUserUUID).
+			Find(&users).Error; err != nil {
+			return err
+		}
+		for _, u := range users {
+			userMap[*u.UsernameKey] = u
+		}
+	}
+
+	return global.DB.Transaction(func(tx *jgorm.DB) error {
+		if err := tx.Where("post_id = ? AND comment_id = ?", postID, commentID).Delete(&database.Mention{}).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, name := range names {
+			u, ok := userMap[usernameKey(name)]
+			if !ok {
+				continue
+			}
+			if err := tx.Create(&database.Mention{
+				PostID:    postID,
+				CommentID: commentID,
+				UserUUID:  u.UUID,
+				Text:      name,
+				CreatedAt: now,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// notifyMentions 通知帖子正文或评论中被提及的用户
+// 帖子发布后才通知, 看不到该帖子的用户和作者本人不通知; 同一处提及只通知一次, 修改正文不会重复通知
+func notifyMentions(post database.Post, commentID uint, actorUUID string) {
+	if post.Status != database.PostStatusPublished {
+		return
+	}
+	var mentions []database.Mention
+	global.DB.Where("post_id = ? AND comment_id = ?", post.ID, commentID).Find(&mentions)
+	for _, m := range mentions {
+		if m.UserUUID == actorUUID || !utils.CanViewPost(post, m.UserUUID) {
+			continue
+		}
+		var n int
+		global.DB.Model(&database.Notification{}).
+			Where("user_uuid = ? AND type = ? AND post_id = ? AND comment_id = ?", m.UserUUID, database.NotificationMention, post.ID, commentID).
+			Count(&n)
+		if n > 0 {
+			continue
+		}
+		if err := global.DB.Create(&database.Notification{
+			UserUUID:  m.UserUUID,
+			ActorUUID: actorUUID,
+			Type:      database.NotificationMention,
+			PostID:    post.ID,
+			CommentID: commentID,
+			CreatedAt: time.Now(),
+		}).Error; err != nil {
+			log.Println("[Mention] 发送提及通知失败:", post.ID, commentID, err)
+		}
+	}
+}
+
+// updateMentions 保存正文后更新提及并通知, 失败只记录日志, 不影响发帖 / 评论
+func updateMentions(post database.Post, commentID uint, actorUUID, content string) {
+	if err := syncMentions(post.ID, commentID, content); err != nil {
+		log.Println("[Mention] 保存提及失败:", post.ID, commentID, err)
+		return
+	}
+	notifyMentions(post, commentID, actorUUID)
+}
+
+// removeMentions 删除帖子（commentIDs 为空）或其中部分评论时, 清理提及和对应的通知
+func removeMentions(tx *jgorm.DB, postID uint, commentIDs ...uint) error {
+	cond, args := "post_id = ?", []interface{}{postID}
+	if len(commentIDs) > 0 {
+		cond, args = "post_id = ? AND comment_id IN (?)", []interface{}{postID, commentIDs}
+	}
+	for _, model := range []interface{}{&database.Mention{}, &database.Notification{}} {
+		if err := tx.Where(cond, args...).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListNotifications 我的通知, 最新的在前, 同时返回未读数
+func ListNotifications(userUUID string, req request.ListNotificationsRequest) (response.NotificationListResponse, error) {
+	cur, err := decodePageCursor(req.CursorPage)
+	if err != nil {
+		return response.NotificationListResponse{}, err
+	}
+	db := global.DB.Model(&database.Notification{}).Where("user_uuid = ?", userUUID)
+	if req.UnreadOnly {
+		db = db.Where("is_read = ?", false)
+	}
+
+	var total int64
+	if !req.UseCursor() {
+		if err := db.Count(&total).Error; err != nil {
+			return response.NotificationListResponse{}, err
+		}
+	}
+	var notifications []database.Notification
+	key := sortKey{Col: "created_at", Time: true, Desc: true}
+	if err := key.scope(db, req.CursorPage, cur).Find(&notifications).Error; err != nil {
+		return response.NotificationListResponse{}, err
+	}
+	n, info := pageResult(len(notifications), req.CursorPage, total, func(i int) utils.Cursor {
+		return timeCursor(notifications[i].CreatedAt, notifications[i].ID)
+	})
+	notifications = notifications[:n]
+
+	result := response.NotificationListResponse{
+		PageInfo: info,
+		List:     make([]response.NotificationInfo, 0, len(notifications)),
+	}
+	global.DB.Model(&database.Notification{}).Where("user_uuid = ? AND is_read = ?", userUUID, false).Count(&result.UnreadCount)
+	if len(notifications) == 0 {
+		return result, nil
+	}
+
+	actorUUIDs := make([]string, 0, len(notifications))
+	postIDs := make([]uint, 0, len(notifications))
+	commentIDs := make([]uint, 0, len(notifications))
+	for _, item := range notifications {
+		actorUUIDs = append(actorUUIDs, item.ActorUUID)
+		postIDs = append(postIDs, item.PostID)
+		if item.CommentID != 0 {
+			commentIDs = append(commentIDs, item.CommentID)
+		}
+	}
+	var users []database.User
+	global.DB.Where("uuid IN (?)", actorUUIDs).Find(&users)
+	userMap := make(map[string]database.User, len(users))
+	for _, u := range users {
+		userMap[u.UUID] = u
+	}
+	var posts []database.Post
+	global.DB.Where("id IN (?)", postIDs).Find(&posts)
+	postMap := make(map[uint]database.Post, len(posts))
+	for _, p := range posts {
+		postMap[p.ID] = p
+	}
+	commentMap := make(map[uint]database.PostComment, len(commentIDs))
+	if len(commentIDs) > 0 {
+		var comments []database.PostComment
+		global.DB.Where("id IN (?)", commentIDs).Find(&comments)
+		for _, c := range comments {
+			commentMap[c.ID] = c
+		}
+	}
+
+	for _, item := range notifications {
+		actor := userMap[item.ActorUUID]
+		// 帖子之后被删除或改为对自己不可见时不再展示内容摘要
+		var excerpt string
+		if post, ok := postMap[item.PostID]; ok && utils.CanViewPost(post, userUUID) {
+			switch {
+			case item.CommentID != 0:
+				excerpt = commentMap[item.CommentID].Content
+			case post.Title != "":
+				excerpt = post.Title
+			default:
+				excerpt = post.Content
+			}
+		}
+		result.List = append(result.List, response.NotificationInfo{
+			ID:             item.ID,
+			Type:           item.Type,
+			PostID:         item.PostID,
+			CommentID:      item.CommentID,
+			Excerpt:        utils.Excerpt(excerpt, notificationExcerptRunes),
+			IsRead:         item.IsRead,
+			CreatedAt:      item.CreatedAt,
+			ActorUUID:      item.ActorUUID,
+			ActorUsername:  actor.Username,
+			ActorAvatarURL: actor.AvatarURL,
+		})
+	}
+	return result, nil
+}
+
+// ReadNotifications 把通知标记为已读, ids 为空时标记全部
+func ReadNotifications(userUUID string, ids []uint) error {
+	db := global.DB.Model(&database.Notification{}).Where("user_uuid = ? AND is_read = ?", userUUID, false)
+	if len(ids) > 0 {
+		db = db.Where("id IN (?)", ids)
+	}
+	if err := db.UpdateColumn("is_read", true).Error; err != nil {
+		return errors.New("标记已读失败")
+	}
+	return nil
+}
@@ -44,12 +44,15 @@ type AuthInput struct {
 
 // AuthResult 返回登录结果
 type AuthResult struct {
-	Token string
+	Token             string
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"` // 开启了两步验证, 需要用 pre_auth_token 继续验证
+	PreAuthToken      string `json:"pre_auth_token,omitempty"`
 }
 
 // BindAccountResult 绑定账号结果
 type BindAccountResult struct {
-	Result string
+	Result     string
+	MergeToken string `json:"merge_token,omitempty"` // duplicate_bind 时返回, 用于合并两个账号
 }
 
 // GenerateJWT 生成JWT Token
@@ -129,7 +132,7 @@ func CreateVerifyCodeRecode(code string, email string) (err error) {
 
 func CheckVerifyCode(userID uint64, code int, email string) (rec database.VerifyCode, notFound bool) {
 	rec = database.VerifyCode{}
-	err := global.DB.Where("code = ? AND email = ?", code, email).First(&rec).Error
+	err := global.DB.Where("code = ? AND email = ? AND purpose = ''", code, email).First(&rec).Error
 	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
 		return rec, true
 	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -300,7 +303,9 @@ func BindAccount(input AuthInput, uuid string) (BindAccountResult, error) {
 	if err == nil {
 		// 如果已存在绑定且不是当前用户
 		if existing.ProfileUUID != uuid {
-			return BindAccountResult{Result: "duplicate_bind"}, errors.New("Error: this account is already bound to another user")
+			// 已证明同时拥有两个账号, 下发合并凭证, 由用户决定是否合并
+			mergeToken, _ := GenerateMergeToken(existing.ProfileUUID, uuid)
+			return BindAccountResult{Result: "duplicate_bind", MergeToken: mergeToken}, errors.New("Error: this account is already bound to another user")
 		}
 		// 如果已绑定当前用户 → 直接返回
 		return BindAccountResult{Result: "already_bound"}, nil
@@ -316,18 +321,13 @@ func BindAccount(input AuthInput, uuid string) (BindAccountResult, error) {
 		return BindAccountResult{Result: "failed_bind"}, errors.Wrap(err, "Error: creating bind record failed")
 	}
 
-	// 3. 更新用户表中的绑定标志位
-	field := map[AuthProvider]string{
-		ProviderEmail:  "is_email_bound",
-		ProviderGitHub: "is_github_bound",
-		ProviderGoogle: "is_google_bound",
-	}[input.Provider]
-
-	if field != "" {
-		_ = global.DB.Model(&database.User{}).
-			Where("uuid = ?", uuid).
-			Update(field, true).Error
+	// 3. 更新用户表中的绑定标志位; 绑定邮箱时以验证过的邮箱作为资料中的邮箱
+	system := providerBoundUpdate(input.Provider, true)
+	if input.Provider == ProviderEmail && input.ProviderID != "" {
+		// 邮箱登录方式的 ProviderID 即通过验证码验证的邮箱
+		system.Email = &input.ProviderID
 	}
+	_ = UpdateProfileSystem(uuid, system)
 
 	return BindAccountResult{Result: "success_bind"}, nil
 }
@@ -345,6 +345,17 @@ func LoginOrRegister(input AuthInput) (AuthResult, error) {
 		if err := global.DB.Where("uuid = ?", auth.ProfileUUID).First(&user).Error; err != nil {
 			return AuthResult{}, errors.New("Error: user not found")
 		}
+		if user.IsBanned {
+			return AuthResult{}, errors.New("Error: this account has been banned")
+		}
+		// 开启了两步验证: 只下发临时凭证, 验证码通过后再签发正式 token
+		if user.TOTPEnabled {
+			preAuthToken, err := GeneratePreAuthToken(user.UUID)
+			if err != nil {
+				return AuthResult{}, errors.New("Error: generating pre-auth token failed")
+			}
+			return AuthResult{TwoFactorRequired: true, PreAuthToken: preAuthToken}, nil
+		}
 		// 生成JWT
 		token, _ := GenerateJWT(user.UUID)
 		return AuthResult{Token: token}, nil
@@ -362,6 +373,11 @@ func LoginOrRegister(input AuthInput) (AuthResult, error) {
 		Gender:     "Other", // 默认设置为Other，实际可以根据情况修改
 		Coin:       0,
 	}
+	// 用户名与已有账号重名时不设置唯一键, 之后在资料里改名即可
+	if taken, err := usernameTaken(newUser.Username, ""); err == nil && !taken && usernameKey(newUser.Username) != "" {
+		key := usernameKey(newUser.Username)
+		newUser.UsernameKey = &key
+	}
 	// 根据AuthInput的Provider设置绑定标志位
 	switch input.Provider {
 	case ProviderEmail:
@@ -390,6 +406,7 @@ func LoginOrRegister(input AuthInput) (AuthResult, error) {
 	if err := global.DB.Create(&newAuth).Error; err != nil {
 		return AuthResult{}, errors.New("Error: auth account creation failed")
 	}
+	indexUser(newUUID)
 
 	// 生成JWT
 	token, _ := GenerateJWT(newUser.UUID)
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/utils"
+	"fmt"
+	"time"
+
+	jgorm "github.com/jinzhu/gorm"
+)
+
+// sortKey 列表排序键: 先按 Col 再按 id, Col 为空时只按 id
+// 游标分页以 (Col, id) 为复合键, 要求 id 能区分 Col 相同的记录
+type sortKey struct {
+	Col  string
+	Time bool // Col 是时间列, 游标取 Time; 否则取 Value
+	Desc bool
+}
+
+// decodePageCursor 解析请求中的游标, 没传游标时返回 nil
+func decodePageCursor(page request.CursorPage) (*utils.Cursor, error) {
+	if page.Cursor == "" {
+		return nil, nil
+	}
+	cur, err := utils.DecodeCursor(page.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	return &cur, nil
+}
+
+// scope 为查询追加排序与分页条件
+// 游标分页从 cur 之后开始取, 页码分页按 offset 取; 都多取一条用于判断 has_more
+func (k sortKey) scope(db *jgorm.DB, page request.CursorPage, cur *utils.Cursor) *jgorm.DB {
+	op, dir := ">", "asc"
+	if k.Desc {
+		op, dir = "<", "desc"
+	}
+
+	if page.UseCursor() {
+		if cur != nil {
+			if k.Col == "" {
+				db = db.Where("id "+op+" ?", cur.ID)
+			} else {
+				var after interface{} = cur.Value
+				if k.Time {
+					after = time.Unix(0, cur.Time)
+				}
+				db = db.Where(fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", k.Col, op, k.Col, op), after, after, cur.ID)
+			}
+		}
+	} else {
+		db = db.Offset((page.PageNum - 1) * page.PageSize)
+	}
+
+	if k.Col != "" {
+		db = db.Order(k.Col + " " + dir)
+	}
+	return db.Order("id " + dir).Limit(page.PageSize + 1)
+}
+
+// timeCursor / valueCursor 由一条记录的排序键生成游标
+func timeCursor(t time.Time, id uint) utils.Cursor {
+	return utils.Cursor{Time: t.UnixNano(), ID: id}
+}
+
+func valueCursor(v float64, id uint) utils.Cursor {
+	return utils.Cursor{Value: v, ID: id}
+}
+
+// pageResult 根据实际取到的条数（多取了一条）计算本页条数与分页信息
+// last(i) 返回第 i 条记录的游标, 只在还有下一页时调用
+func pageResult(n int, page request.CursorPage, total int64, last func(i int) utils.Cursor) (int, response.PageInfo) {
+	info := response.PageInfo{Total: total}
+	if n <= page.PageSize {
+		return n, info
+	}
+	info.HasMore = true
+	info.NextCursor = utils.EncodeCursor(last(page.PageSize - 1))
+	return page.PageSize, info
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	jgorm "github.com/jinzhu/gorm"
+)
+
+// validatePoll 检查投票参数: 选项去空白后不能为空或重复, 截止时间必须在将来
+func validatePoll(input *request.PollInput) error {
+	if input == nil {
+		return nil
+	}
+	seen := make(map[string]bool, len(input.Options))
+	for i, text := range input.Options {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return errors.New("投票选项不能为空")
+		}
+		if seen[text] {
+			return errors.New("投票选项不能重复")
+		}
+		seen[text] = true
+		input.Options[i] = text
+	}
+	if input.ClosesAt != nil && !input.ClosesAt.After(time.Now()) {
+		return errors.New("投票截止时间必须晚于当前时间")
+	}
+	return nil
+}
+
+// setPostPoll 用 input 覆盖帖子的投票, 已有人投票后不能再修改; 在调用方的事务 tx 中执行
+func setPostPoll(tx *jgorm.DB, postID uint, input *request.PollInput) error {
+	if err := validatePoll(input); err != nil {
+		return err
+	}
+	var existing database.Poll
+	if err := tx.Where("post_id = ?", postID).First(&existing).Error; err == nil {
+		if existing.VoterCount > 0 {
+			return errors.New("已有人参与投票, 不能修改")
+		}
+		if err := deletePostPolls(tx, postID); err != nil {
+			return err
+		}
+	}
+
+	poll := database.Poll{PostID: postID, Multiple: input.Multiple, ClosesAt: input.ClosesAt}
+	if err := tx.Create(&poll).Error; err != nil {
+		return err
+	}
+	for i, text := range input.Options {
+		if err := tx.Create(&database.PollOption{PostID: postID, Position: i, Text: text}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deletePostPolls 删除帖子的投票、选项和投票记录
+func deletePostPolls(tx *jgorm.DB, postIDs ...uint) error {
+	for _, model := range []interface{}{&database.PollVote{}, &database.PollOption{}, &database.Poll{}} {
+		if err := tx.Where("post_id IN (?)", postIDs).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VotePoll 投票; 单选只能选一个选项, 再次投票会覆盖之前的选择, 截止后不能再投
+func VotePoll(userUUID string, postID uint, optionIDs []uint) (*response.PollInfo, error) {
+	if _, err := visiblePost(postID, userUUID); err != nil {
+		return nil, err
+	}
+	var poll database.Poll
+	if err := global.DB.Where("post_id = ?", postID).First(&poll).Error; err != nil {
+		return nil, errors.New("该帖子没有投票")
+	}
+	if pollClosed(poll, time.Now()) {
+		return nil, errors.New("投票已截止")
+	}
+
+	chosen := uniqueUints(optionIDs)
+	if !poll.Multiple && len(chosen) != 1 {
+		return nil, errors.New("单选投票只能选择一个选项")
+	}
+	var n int
+	global.DB.Model(&database.PollOption{}).Where("post_id = ? AND id IN (?)", postID, chosen).Count(&n)
+	if n != len(chosen) {
+		return nil, errors.New("投票选项不存在")
+	}
+
+	err := global.DB.Transaction(func(tx *jgorm.DB) error {
+		// 锁住投票, 同一用户的并发投票串行执行
+		if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&poll, poll.ID).Error; err != nil {
+			return err
+		}
+		var previous []uint
+		if err := tx.Model(&database.PollVote{}).Where("post_id = ? AND user_uuid = ?", postID, userUUID).
+			Pluck("option_id", &previous).Error; err != nil {
+			return err
+		}
+
+		if len(previous) > 0 {
+			if err := tx.Where("post_id = ? AND user_uuid = ?", postID, userUUID).Delete(&database.PollVote{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("UPDATE poll_options SET vote_count = GREATEST(vote_count - 1, 0) WHERE id IN (?)", previous).Error; err != nil {
+				return err
+			}
+		} else if err := incrCounter(tx, "polls", "voter_count", poll.ID, 1); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, id := range chosen {
+			if err := tx.Create(&database.PollVote{PostID: postID, UserUUID: userUUID, OptionID: id, CreatedAt: now}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Exec("UPDATE poll_options SET vote_count = vote_count + 1 WHERE id IN (?)", chosen).Error
+	})
+	if err != nil {
+		return nil, errors.New("投票失败")
+	}
+	return getPollInfo(postID, userUUID), nil
+}
+
+// getPollInfo 帖子的投票及当前结果, 没有投票时返回 nil
+func getPollInfo(postID uint, userUUID string) *response.PollInfo {
+	var poll database.Poll
+	if err := global.DB.Where("post_id = ?", postID).First(&poll).Error; err != nil {
+		return nil
+	}
+	var options []database.PollOption
+	global.DB.Where("post_id = ?", postID).Order("position, id").Find(&options)
+
+	info := &response.PollInfo{
+		ID:         poll.ID,
+		Multiple:   poll.Multiple,
+		ClosesAt:   poll.ClosesAt,
+		Closed:     pollClosed(poll, time.Now()),
+		VoterCount: poll.VoterCount,
+		Options:    make([]response.PollOptionInfo, 0, len(options)),
+		MyVotes:    []uint{},
+	}
+	for _, o := range options {
+		option := response.PollOptionInfo{ID: o.ID, Text: o.Text, Votes: o.VoteCount}
+		if poll.VoterCount > 0 {
+			option.Percent = math.Round(float64(o.VoteCount)*1000/float64(poll.VoterCount)) / 10
+		}
+		info.TotalVotes += o.VoteCount
+		info.Options = append(info.Options, option)
+	}
+	if userUUID != "" {
+		global.DB.Model(&database.PollVote{}).Where("post_id = ? AND user_uuid = ?", postID, userUUID).
+			Order("option_id").Pluck("option_id", &info.MyVotes)
+	}
+	return info
+}
+
+func pollClosed(poll database.Poll, now time.Time) bool {
+	return poll.ClosesAt != nil && !now.Before(*poll.ClosesAt)
+}
+
+func uniqueUints(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	out := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -8,16 +8,24 @@ import (
 	"OpenHouse/utils"
 	"encoding/json"
 	"errors"
-	"fmt"
+	"log"
 	"time"
 
+	jgorm "github.com/jinzhu/gorm"
 	"gorm.io/datatypes"
 )
 
-func CreatePost(authorUUID string, title string, content string, imageURLs []string) (database.Post, error) {
+// CreatePost 发帖; publishAt 为将来的时间时定时发布, 到点前不出现在列表和搜索中
+func CreatePost(authorUUID string, title string, content string, imageURLs []string, attachments []request.AttachmentRef, poll *request.PollInput, tags []string, visibility string, publishAt *time.Time) (database.Post, error) {
 	if len(imageURLs) > 3 {
 		return database.Post{}, errors.New("最多只能上传3张图片")
 	}
+	if err := validatePoll(poll); err != nil {
+		return database.Post{}, err
+	}
+	if err := prepareAttachments(global.DB, authorUUID, 0, attachments); err != nil {
+		return database.Post{}, err
+	}
 
 	imgJSON, err := json.Marshal(imageURLs)
 	if err != nil {
@@ -30,11 +38,47 @@ func CreatePost(authorUUID string, title string, content string, imageURLs []str
 		Content:    content,
 		ImageURLs:  imgJSON,
 		CreateDate: time.Now(),
+		Status:     database.PostStatusPublished,
+		Visibility: normalizeVisibility(visibility),
+	}
+	if publishAt != nil && publishAt.After(post.CreateDate) {
+		post.Status = database.PostStatusScheduled
+		post.PublishAt = publishAt
 	}
+	post.HotScore = hotScore(post, post.CreateDate)
 
-	if err := global.DB.Create(&post).Error; err != nil {
+	// 帖子和附件、投票、标签一起写入, 任何一步失败都不留下半成品
+	err = global.DB.Transaction(func(tx *jgorm.DB) error {
+		if err := tx.Create(&post).Error; err != nil {
+			return err
+		}
+		if len(attachments) > 0 {
+			if err := setPostAttachments(tx, post.ID, authorUUID, attachments); err != nil {
+				return errors.New("保存附件失败")
+			}
+		}
+		if poll != nil {
+			if err := setPostPoll(tx, post.ID, poll); err != nil {
+				return errors.New("保存投票失败")
+			}
+		}
+		if err := setPostTags(tx, post.ID, tags, title, content); err != nil {
+			return errors.New("保存标签失败")
+		}
+		return nil
+	})
+	if err != nil {
 		return database.Post{}, err
 	}
+	// 定时发布的帖子先保存提及, 发布时再通知
+	updateMentions(post, 0, authorUUID, content)
+	if post.Status == database.PostStatusPublished {
+		if err := recordRevision(global.DB, post, authorUUID); err != nil {
+			log.Println("[Revision] 保存帖子版本失败:", post.ID, err)
+		}
+		indexPost(post)
+		embedPostAsync(post)
+	}
 
 	return post, nil
 }
@@ -46,6 +90,9 @@ func UpdatePost(userUUID string, req request.UpdatePostRequest) error {
 	if post.AuthorUUID != userUUID {
 		return errors.New("无权限修改该帖子")
 	}
+	if isPlainRepost(post) {
+		return errors.New("转发不能修改")
+	}
 
 	updateFields := map[string]interface{}{}
 	if req.Title != "" {
@@ -62,76 +109,120 @@ func UpdatePost(userUUID string, req request.UpdatePostRequest) error {
 		updateFields["image_urls"] = datatypes.JSON(data)
 	}
 
-	if len(updateFields) == 0 {
-		return errors.New("没有需要修改的内容")
-	}
-
-	return global.DB.Model(&post).Updates(updateFields).Error
-}
-
-// ListPosts 分页查询帖子
-func ListPosts(pageNum, pageSize int, sortOrder string, userUUID string) ([]response.PostInfo, int64, error) {
-	var posts []database.Post
-	var total int64
-
-	db := global.DB.Model(&database.Post{})
-
-	// 查询总数
-	if err := db.Count(&total).Error; err != nil {
-		return nil, 0, err
+	if req.Visibility != "" && req.Visibility != post.Visibility {
+		updateFields["visibility"] = req.Visibility
 	}
 
-	// 排序字段
-	order := "create_date desc"
-	if sortOrder == "asc" {
-		order = "create_date asc"
+	if len(updateFields) == 0 && req.Tags == nil && req.Attachments == nil && req.Poll == nil {
+		return errors.New("没有需要修改的内容")
 	}
-
-	// 分页 + 排序
-	if err := db.Order(order).
-		Limit(pageSize).
-		Offset((pageNum - 1) * pageSize).
-		Find(&posts).Error; err != nil {
-		return nil, 0, err
+	// 未传 tags 时沿用原有显式标签, #话题 按新内容重新提取
+	tags := req.Tags
+	if tags == nil {
+		tags = explicitPostTags(post.ID)
 	}
 
-	// 转换为 PostInfo
-	result := make([]response.PostInfo, 0, len(posts))
-	for _, p := range posts {
-		result = append(result, utils.ConvertPostModelWithUser(p, userUUID))
+	// 帖子、附件、投票、标签和修改记录在同一个事务里写入, 任何一步失败都不留下改了一半的帖子
+	// 锁住帖子行让并发修改依次计算版本号
+	err := global.DB.Transaction(func(tx *jgorm.DB) error {
+		if err := tx.Set("gorm:query_option", "FOR UPDATE").First(&post, post.ID).Error; err != nil {
+			return err
+		}
+		// 未传 attachments 时保留原有附件
+		if req.Attachments != nil {
+			if err := setPostAttachments(tx, post.ID, userUUID, req.Attachments); err != nil {
+				return err
+			}
+		}
+		if req.Poll != nil {
+			if err := setPostPoll(tx, post.ID, req.Poll); err != nil {
+				return err
+			}
+		}
+		// 已发布的帖子内容有变化时记录版本并标记修改时间, 草稿不记录
+		revise := post.Status == database.PostStatusPublished && contentChanged(post, req)
+		if revise {
+			if err := ensureBaseRevision(tx, post); err != nil {
+				return errors.New("保存修改记录失败")
+			}
+			updateFields["edited_at"] = time.Now()
+		}
+		if len(updateFields) > 0 {
+			if err := tx.Model(&post).Updates(updateFields).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.First(&post, post.ID).Error; err != nil {
+			return err
+		}
+		if revise {
+			if err := recordRevision(tx, post, userUUID); err != nil {
+				return errors.New("保存修改记录失败")
+			}
+		}
+		if err := setPostTags(tx, post.ID, tags, post.Title, post.Content); err != nil {
+			return errors.New("保存标签失败")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
-	return result, total, nil
+	// 只通知新增的提及; 改为更大的可见范围后, 之前看不到帖子的被提及用户也会收到通知
+	updateMentions(post, 0, userUUID, post.Content)
+	indexPostByID(post.ID)
+	embedPostAsync(post)
+	return nil
+}
 
+// ListPosts 分页查询帖子
+func ListPosts(page request.CursorPage, sortOrder string, userUUID string) ([]response.PostInfo, response.PageInfo, error) {
+	return listPostsWhere(global.DB.Model(&database.Post{}).Scopes(notPlainRepost), page, sortOrder, userUUID)
 }
 
 // ListUserPosts 查询当前用户的帖子
-func ListUserPosts(authorUUID string, pageNum, pageSize int, sortOrder string, userUUID string) ([]response.PostInfo, int64, error) {
-	var posts []database.Post
-	var total int64
+func ListUserPosts(authorUUID string, page request.CursorPage, sortOrder string, userUUID string) ([]response.PostInfo, response.PageInfo, error) {
+	return listPostsWhere(global.DB.Model(&database.Post{}).Where("author_uuid = ?", authorUUID), page, sortOrder, userUUID)
+}
 
-	db := global.DB.Model(&database.Post{}).Where("author_uuid = ?", authorUUID)
+// listPostsWhere 按发帖时间分页查询满足条件、且对 userUUID 可见的已发布帖子
+func listPostsWhere(db *jgorm.DB, page request.CursorPage, sortOrder string, userUUID string) ([]response.PostInfo, response.PageInfo, error) {
+	key := sortKey{Col: "create_date", Time: true, Desc: sortOrder != "asc"}
+	return queryPostPage(db.Scopes(published, visibleTo(userUUID)), page, key, userUUID)
+}
 
-	if err := db.Count(&total).Error; err != nil {
-		return nil, 0, err
+// queryPostPage 按排序键分页查询帖子, key.Col 可以是 create_date、hot_score 或空（按 id）
+func queryPostPage(db *jgorm.DB, page request.CursorPage, key sortKey, userUUID string) ([]response.PostInfo, response.PageInfo, error) {
+	cur, err := decodePageCursor(page)
+	if err != nil {
+		return nil, response.PageInfo{}, err
 	}
 
-	order := "create_date desc"
-	if sortOrder == "asc" {
-		order = "create_date asc"
+	// 页码分页才统计总数
+	var total int64
+	if !page.UseCursor() {
+		if err := db.Count(&total).Error; err != nil {
+			return nil, response.PageInfo{}, err
+		}
 	}
 
-	if err := db.Order(order).
-		Limit(pageSize).
-		Offset((pageNum - 1) * pageSize).
-		Find(&posts).Error; err != nil {
-		return nil, 0, err
-	}
+	var posts []database.Post
+	if err := key.scope(db, page, cur).Find(&posts).Error; err != nil {
+		return nil, response.PageInfo{}, err
+	}
+	n, info := pageResult(len(posts), page, total, func(i int) utils.Cursor {
+		switch {
+		case key.Col == "":
+			return utils.Cursor{ID: posts[i].ID}
+		case key.Time:
+			return timeCursor(posts[i].CreateDate, posts[i].ID)
+		default:
+			return valueCursor(posts[i].HotScore, posts[i].ID)
+		}
+	})
 
-	result := make([]response.PostInfo, 0, len(posts))
-	for _, p := range posts {
-		result = append(result, utils.ConvertPostModelWithUser(p, userUUID))
-	}
-	return result, total, nil
+	// 转换为 PostInfo
+	return utils.ConvertPostModels(posts[:n], userUUID), info, nil
 }
 
 // DeletePost 删除指定帖子（只能删除自己的）
@@ -143,10 +234,30 @@ func DeletePost(userUUID string, postID uint) error {
 	if post.AuthorUUID != userUUID {
 		return errors.New("无权限删除该帖子")
 	}
-	if err := global.DB.Delete(&post).Error; err != nil {
+	return removePost(postID)
+}
+
+// removePost 删除帖子, 并级联删除评论、点赞、收藏和对它的纯转发; 引用它的帖子保留, 展示为原帖已删除
+func removePost(postID uint) error {
+	var post database.Post
+	if err := global.DB.First(&post, postID).Error; err != nil {
+		return errors.New("帖子不存在")
+	}
+	if err := global.DB.Where("id = ?", postID).Delete(&database.Post{}).Error; err != nil {
 		return errors.New("删除失败")
 	}
-	// 级联删除评论和点赞
+	if post.RepostOfID != nil {
+		if err := incrCounter(global.DB, "posts", "repost_number", *post.RepostOfID, -1); err != nil {
+			log.Println("[Repost] 更新转发数失败:", *post.RepostOfID, err)
+		}
+	}
+	var reposts []uint
+	global.DB.Model(&database.Post{}).Where("repost_of_id = ? AND content = ''", postID).Pluck("id", &reposts)
+	for _, id := range reposts {
+		if err := removePost(id); err != nil {
+			return errors.New("删除转发失败")
+		}
+	}
 	if err := global.DB.Where("post_id = ?", postID).Delete(&database.PostComment{}).Error; err != nil {
 		return errors.New("删除评论失败")
 	}
@@ -156,261 +267,199 @@ func DeletePost(userUUID string, postID uint) error {
 	if err := global.DB.Where("post_id = ?", postID).Delete(&database.UserPostFavorite{}).Error; err != nil {
 		return errors.New("删除收藏失败")
 	}
+	if err := global.DB.Where("post_id = ?", postID).Delete(&database.PostTag{}).Error; err != nil {
+		return errors.New("删除标签失败")
+	}
+	if err := global.DB.Where("post_id = ?", postID).Delete(&database.PostRevision{}).Error; err != nil {
+		return errors.New("删除修改记录失败")
+	}
+	if err := global.DB.Where("post_id = ?", postID).Delete(&database.Attachment{}).Error; err != nil {
+		return errors.New("删除附件失败")
+	}
+	if err := global.DB.Where("post_id = ?", postID).Delete(&database.PostView{}).Error; err != nil {
+		return errors.New("删除浏览记录失败")
+	}
+	if err := deletePostPolls(global.DB, postID); err != nil {
+		return errors.New("删除投票失败")
+	}
+	if err := global.DB.Where("post_id = ?", postID).Delete(&database.PostDailyStat{}).Error; err != nil {
+		return errors.New("删除帖子统计失败")
+	}
+	if err := removeMentions(global.DB, postID); err != nil {
+		return errors.New("删除提及失败")
+	}
+	unindexPosts(postID)
+	removePostEmbeddings(postID)
 	return nil
 }
 
 // FavoritePost 收藏帖子
 func FavoritePost(userUUID string, postID uint) error {
-	// 检查帖子是否存在
-	var post database.Post
-	if err := global.DB.First(&post, postID).Error; err != nil {
-		return errors.New("帖子不存在")
+	// 检查帖子是否存在且对当前用户可见
+	if _, err := visiblePost(postID, userUUID); err != nil {
+		return err
 	}
 
-	// 查询是否存在记录
-	var fav database.UserPostFavorite
-	err := global.DB.Unscoped().
-		Where("user_id = ? AND post_id = ?", userUUID, postID).
-		First(&fav).Error
-
-	if err == nil {
-		if fav.DeletedAt.Valid {
-			// 恢复已删除记录
-			if err := global.DB.Exec("UPDATE user_post_favorites SET deleted_at = NULL WHERE id = ?", fav.ID).Error; err != nil {
-				return errors.New("恢复收藏失败")
-			}
-		} else {
-			return errors.New("不能重复收藏")
+	return global.DB.Transaction(func(tx *jgorm.DB) error {
+		ok, err := activateRelation(tx, "user_post_favorites", "post_id", userUUID, postID, true)
+		if err != nil {
+			return errors.New("收藏失败")
 		}
-	} else {
-		newFav := database.UserPostFavorite{
-			UserID: userUUID,
-			PostID: postID,
+		if !ok {
+			return errors.New("不能重复收藏")
 		}
-		if err := global.DB.Create(&newFav).Error; err != nil {
-			return err
+		if err := incrCounter(tx, "posts", "favorite_number", postID, 1); err != nil {
+			return errors.New("更新收藏数失败")
 		}
-	}
-
-	fmt.Println("fav:", fav)
-
-	// 更新 favorite_number
-	err = global.DB.Model(&database.Post{}).
-		Where("id = ?", postID).
-		Select("favorite_number").
-		Updates(database.Post{FavoriteNumber: post.FavoriteNumber + 1}).Error
-	if err != nil {
-		return errors.New("更新收藏数失败")
-	}
-	return nil
+		return nil
+	})
 }
 
 // UnfavoritePost 取消收藏帖子
 func UnfavoritePost(userUUID string, postID uint) error {
-	var fav database.UserPostFavorite
-	err := global.DB.
-		Where("user_id = ? AND post_id = ? AND deleted_at IS NULL", userUUID, postID).
-		First(&fav).Error
+	return global.DB.Transaction(func(tx *jgorm.DB) error {
+		ok, err := deactivateRelation(tx, "user_post_favorites", "post_id", userUUID, postID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("尚未收藏该帖子")
+		}
+		// 收藏数 -1（最小为 0）
+		return incrCounter(tx, "posts", "favorite_number", postID, -1)
+	})
+}
 
+// ListFavoritePosts 查询用户收藏的帖子, 按收藏先后排序
+func ListFavoritePosts(userUUID string, page request.CursorPage, sortOrder string) ([]response.PostInfo, response.PageInfo, error) {
+	cur, err := decodePageCursor(page)
 	if err != nil {
-		return errors.New("尚未收藏该帖子")
-	}
-
-	if err := global.DB.Delete(&fav).Error; err != nil {
-		return err
+		return nil, response.PageInfo{}, err
 	}
 
-	// 收藏数 -1（最小为 0）
-	return global.DB.Exec(`
-		UPDATE posts
-		SET favorite_number = GREATEST(favorite_number - 1, 0)
-		WHERE id = ?
-	`, postID).Error
-}
-
-// ListFavoritePosts 查询用户收藏的帖子
-func ListFavoritePosts(userUUID string, pageNum, pageSize int, sortOrder string) ([]response.PostInfo, int64, error) {
-	var favorites []database.UserPostFavorite
+	db := global.DB.Model(&database.UserPostFavorite{}).Where("user_id = ? AND deleted_at IS NULL", userUUID)
 	var total int64
-
-	// 先查总数
-	if err := global.DB.Model(&database.UserPostFavorite{}).
-		Where("user_id = ? AND deleted_at IS NULL", userUUID).
-		Count(&total).Error; err != nil {
-		return nil, 0, err
+	if !page.UseCursor() {
+		if err := db.Count(&total).Error; err != nil {
+			return nil, response.PageInfo{}, err
+		}
 	}
 
-	// 查收藏记录
-	if err := global.DB.Where("user_id = ? AND deleted_at IS NULL", userUUID).
-		Order("id desc").
-		Limit(pageSize).
-		Offset((pageNum - 1) * pageSize).
-		Find(&favorites).Error; err != nil {
-		return nil, 0, err
+	// 查收藏记录, 收藏表自增 id 即收藏先后
+	var favorites []database.UserPostFavorite
+	key := sortKey{Desc: sortOrder != "asc"}
+	if err := key.scope(db, page, cur).Find(&favorites).Error; err != nil {
+		return nil, response.PageInfo{}, err
 	}
+	n, info := pageResult(len(favorites), page, total, func(i int) utils.Cursor {
+		return utils.Cursor{ID: favorites[i].ID}
+	})
+	favorites = favorites[:n]
 
 	// 拿到 post_id 列表
 	postIDs := make([]uint, 0, len(favorites))
 	for _, fav := range favorites {
 		postIDs = append(postIDs, fav.PostID)
 	}
-
-	// 查帖子
-	var posts []database.Post
 	if len(postIDs) == 0 {
-		return []response.PostInfo{}, total, nil
-	}
-
-	order := "create_date desc"
-	if sortOrder == "asc" {
-		order = "create_date asc"
+		return []response.PostInfo{}, info, nil
 	}
 
-	if err := global.DB.
-		Where("id IN (?)", postIDs).
-		Order(order).
-		Find(&posts).Error; err != nil {
-		return nil, 0, err
+	// 查帖子
+	var posts []database.Post
+	if err := global.DB.Scopes(published, visibleTo(userUUID)).Where("id IN (?)", postIDs).Find(&posts).Error; err != nil {
+		return nil, response.PageInfo{}, err
 	}
-
-	// 转换
-	list := make([]response.PostInfo, 0, len(posts))
+	postMap := make(map[uint]database.Post, len(posts))
 	for _, p := range posts {
-		list = append(list, utils.ConvertPostModelWithUser(p, userUUID))
+		postMap[p.ID] = p
 	}
 
-	return list, total, nil
+	// 按收藏顺序转换, 已删除或不再可见的帖子跳过
+	ordered := make([]database.Post, 0, len(posts))
+	for _, id := range postIDs {
+		if p, ok := postMap[id]; ok {
+			ordered = append(ordered, p)
+		}
+	}
+	return utils.ConvertPostModels(ordered, userUUID), info, nil
 }
 
 // LikePost 点赞帖子
 func LikePost(userUUID string, postID uint) error {
-	// 检查帖子是否存在
-	var post database.Post
-	if err := global.DB.First(&post, postID).Error; err != nil {
-		return errors.New("帖子不存在")
+	// 检查帖子是否存在且对当前用户可见
+	if _, err := visiblePost(postID, userUUID); err != nil {
+		return err
 	}
 
-	var like database.UserPostLike
-	err := global.DB.
-		Where("user_id = ? AND post_id = ?", userUUID, postID).
-		First(&like).Error
-
-	if err == nil {
-		if like.DeletedAt.Valid {
-			// 恢复点赞
-			if err := global.DB.Model(&like).Update("deleted_at", nil).Error; err != nil {
-				return err
-			}
-		} else {
-			return errors.New("请勿重复点赞")
+	return global.DB.Transaction(func(tx *jgorm.DB) error {
+		ok, err := activateRelation(tx, "user_post_likes", "post_id", userUUID, postID, true)
+		if err != nil {
+			return errors.New("点赞失败")
 		}
-	} else {
-		newLike := database.UserPostLike{
-			UserID: userUUID,
-			PostID: postID,
+		if !ok {
+			return errors.New("请勿重复点赞")
 		}
-		if err := global.DB.Create(&newLike).Error; err != nil {
-			return err
+		if err := incrCounter(tx, "posts", "star_number", postID, 1); err != nil {
+			return errors.New("更新点赞数失败")
 		}
-	}
-
-	err = global.DB.Model(&database.Post{}).
-		Where("id = ?", postID).
-		Select("star_number").
-		Updates(database.Post{StarNumber: post.StarNumber + 1}).Error
-	if err != nil {
-		return errors.New("更新点赞数失败")
-	}
-	return nil
+		return nil
+	})
 }
 
 // UnLikePost 取消点赞
 func UnLikePost(userUUID string, postID uint) error {
-	var like database.UserPostLike
-	post := database.Post{}
-	if err := global.DB.First(&post, postID).Error; err != nil {
-		return errors.New("帖子不存在")
-	}
-	err := global.DB.
-		Where("user_id = ? AND post_id = ? AND deleted_at IS NULL", userUUID, postID).
-		First(&like).Error
-
-	if err != nil {
-		return errors.New("尚未点赞该帖子")
-	}
-
-	if err := global.DB.Delete(&like).Error; err != nil {
-		return err
-	}
-
-	// 同步更新 star_number
-	new_starNumber := post.StarNumber - 1
-	if new_starNumber < 0 {
-		new_starNumber = 0
-	}
-	err = global.DB.Model(&database.Post{}).
-		Where("id = ?", postID).
-		Select("star_number").
-		Updates(database.Post{StarNumber: new_starNumber}).Error
-	if err != nil {
-		return errors.New("更新点赞数失败")
-	}
-
-	return nil
+	return global.DB.Transaction(func(tx *jgorm.DB) error {
+		ok, err := deactivateRelation(tx, "user_post_likes", "post_id", userUUID, postID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("尚未点赞该帖子")
+		}
+		if err := incrCounter(tx, "posts", "star_number", postID, -1); err != nil {
+			return errors.New("更新点赞数失败")
+		}
+		return nil
+	})
 }
 
-// UpdatePostInfo 查询数据库信息，根据查询的表的结果对帖子信息进行更正 例如点赞数、收藏数、评论数
+// UpdatePostInfo 按关系表重新统计一篇帖子的点赞数、收藏数、评论数及其评论的点赞数
 func UpdatePostInfo(postID uint) error {
-	var post database.Post
-	if err := global.DB.First(&post, postID).Error; err != nil {
-		return errors.New("帖子不存在")
-	}
-	updateFields := map[string]interface{}{}
-	var likeCount int
-	if err := global.DB.Model(&database.UserPostLike{}).
-		Where("post_id = ? AND deleted_at IS NULL", postID).
-		Count(&likeCount).Error; err != nil {
-		return errors.New("查询点赞数失败")
-	}
-	updateFields["star_number"] = likeCount
-	// 更新收藏数
-	var favoriteCount int
-	if err := global.DB.Model(&database.UserPostFavorite{}).
-		Where("post_id = ? AND deleted_at IS NULL", postID).
-		Count(&favoriteCount).Error; err != nil {
-		return errors.New("查询收藏数失败")
-	}
-	updateFields["favorite_number"] = favoriteCount
-	// 更新评论数
-	var commentCount int
-	if err := global.DB.Model(&database.PostComment{}).
-		Where("post_id = ? AND comment_id IS NULL", postID).
-		Count(&commentCount).Error; err != nil {
-		return errors.New("查询评论数失败")
-	}
-	updateFields["comment_number"] = commentCount
-	// 更新数据库
-	if err := global.DB.Model(&post).Updates(updateFields).Error; err != nil {
+	if _, err := reconcileCounters([]uint{postID}); err != nil {
 		return errors.New("更新帖子信息失败")
 	}
 	return nil
 }
 
-// GetPostDetail 获取帖子详情并更新浏览数
+// GetPostDetail 获取帖子详情并记录浏览
 func GetPostDetailWithUser(postID uint, userUUID string) (response.PostDetailResponse, error) {
-	UpdatePostInfo(postID)
+	return GetPostDetail(postID, userUUID, userUUID)
+}
+
+// GetPostDetail 帖子详情, userUUID 为空表示未登录访问; viewerKey 用于浏览去重, 登录用户为 UUID, 匿名访客由 IP 和 UA 生成
+func GetPostDetail(postID uint, userUUID, viewerKey string) (response.PostDetailResponse, error) {
 	var post database.Post
 	if err := global.DB.First(&post, postID).Error; err != nil {
 		return response.PostDetailResponse{}, errors.New("帖子不存在")
 	}
-
-	// 浏览数 +1（不影响逻辑）
-	if err := global.DB.Model(&database.Post{}).
-		Where("id = ?", postID).
-		Select("view_number").
-		Updates(database.Post{ViewNumber: post.ViewNumber + 1}).Error; err != nil {
-		return response.PostDetailResponse{}, errors.New("更新浏览数失败")
+	// 草稿和定时发布的帖子只有作者能看到, 也不计浏览数; 已发布的帖子按可见范围判断
+	if post.Status == database.PostStatusPublished && !utils.CanViewPost(post, userUUID) {
+		return response.PostDetailResponse{}, errors.New("帖子不存在")
 	}
+	if post.Status != database.PostStatusPublished {
+		if post.AuthorUUID != userUUID {
+			return response.PostDetailResponse{}, errors.New("帖子不存在")
+		}
+		return response.PostDetailResponse{
+			PostInfo:     utils.ConvertPostModelWithUser(post, userUUID),
+			Poll:         getPollInfo(post.ID, userUUID),
+			RelatedPosts: []response.RelatedPost{},
+		}, nil
+	}
+
+	// 浏览记录去重后异步写入, 由定时任务汇总到浏览数
+	recordPostViewAsync(post, viewerKey)
 
 	postInfo := utils.ConvertPostModelWithUser(post, userUUID)
 
@@ -434,8 +483,11 @@ func GetPostDetailWithUser(postID uint, userUUID string) (response.PostDetailRes
 	}
 
 	return response.PostDetailResponse{
-		PostInfo:    postInfo,
-		IsLiked:     isLiked,
-		IsFavorited: isFavorited,
+		PostInfo:     postInfo,
+		IsLiked:      utils.ViewerFlag(userUUID, isLiked),
+		IsFavorited:  utils.ViewerFlag(userUUID, isFavorited),
+		IsReposted:   utils.ViewerFlag(userUUID, userUUID != "" && isReposted(userUUID, postID)),
+		Poll:         getPollInfo(postID, userUUID),
+		RelatedPosts: relatedPosts(postID, userUUID),
 	}, nil
 }
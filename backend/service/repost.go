@@ -0,0 +1,108 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	jgorm "github.com/jinzhu/gorm"
+)
+
+// isPlainRepost 纯转发: 引用了其他帖子且没有自己的正文
+func isPlainRepost(post database.Post) bool {
+	return post.RepostOfID != nil && post.Content == ""
+}
+
+// notPlainRepost 全站列表、热门、推荐、搜索中排除纯转发, 只在关注流和个人主页出现
+func notPlainRepost(db *jgorm.DB) *jgorm.DB {
+	return db.Where("posts.repost_of_id IS NULL OR posts.content <> ''")
+}
+
+// RepostPost 转发帖子; content 不为空时发布为引用帖, 可以带标签
+// 转发纯转发时实际转发的是它的原帖, 引用帖则按引用帖本身转发
+func RepostPost(userUUID string, postID uint, content string, tags []string) (database.Post, error) {
+	target, err := visiblePost(postID, userUUID)
+	if err != nil {
+		return database.Post{}, err
+	}
+	if isPlainRepost(target) {
+		if err := global.DB.Scopes(published).First(&target, *target.RepostOfID).Error; err != nil {
+			return database.Post{}, errors.New("原帖已删除")
+		}
+	}
+	// 转发会把帖子带给自己的关注者, 只允许转发公开的帖子
+	if target.Visibility != database.PostVisibilityPublic {
+		return database.Post{}, errors.New("只能转发公开的帖子")
+	}
+
+	content = strings.TrimSpace(content)
+	if content == "" {
+		var n int
+		global.DB.Model(&database.Post{}).
+			Where("author_uuid = ? AND repost_of_id = ? AND content = ''", userUUID, target.ID).
+			Count(&n)
+		if n > 0 {
+			return database.Post{}, errors.New("已经转发过该帖子")
+		}
+	}
+
+	now := time.Now()
+	post := database.Post{
+		AuthorUUID: userUUID,
+		Content:    content,
+		ImageURLs:  []byte("[]"),
+		CreateDate: now,
+		Status:     database.PostStatusPublished,
+		Visibility: database.PostVisibilityPublic,
+		RepostOfID: &target.ID,
+	}
+	post.HotScore = hotScore(post, now)
+	err = global.DB.Transaction(func(tx *jgorm.DB) error {
+		if err := tx.Create(&post).Error; err != nil {
+			return err
+		}
+		// 引用帖和普通帖子一样有标签
+		if !isPlainRepost(post) {
+			if err := setPostTags(tx, post.ID, tags, "", content); err != nil {
+				return err
+			}
+		}
+		return incrCounter(tx, "posts", "repost_number", target.ID, 1)
+	})
+	if err != nil {
+		return database.Post{}, errors.New("转发失败")
+	}
+
+	// 引用帖和普通帖子一样有版本和索引
+	if !isPlainRepost(post) {
+		if err := recordRevision(global.DB, post, userUUID); err != nil {
+			log.Println("[Revision] 保存帖子版本失败:", post.ID, err)
+		}
+		updateMentions(post, 0, userUUID, content)
+		indexPost(post)
+		embedPostAsync(post)
+	}
+	return post, nil
+}
+
+// UndoRepost 取消对某个帖子的纯转发
+func UndoRepost(userUUID string, postID uint) error {
+	var repost database.Post
+	if err := global.DB.Where("author_uuid = ? AND repost_of_id = ? AND content = ''", userUUID, postID).
+		First(&repost).Error; err != nil {
+		return errors.New("尚未转发该帖子")
+	}
+	return removePost(repost.ID)
+}
+
+// isReposted 用户是否已纯转发该帖子
+func isReposted(userUUID string, postID uint) bool {
+	var n int
+	global.DB.Model(&database.Post{}).
+		Where("author_uuid = ? AND repost_of_id = ? AND content = ''", userUUID, postID).
+		Count(&n)
+	return n > 0
+}
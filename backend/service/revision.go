@@ -0,0 +1,203 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/utils"
+	"encoding/json"
+	"errors"
+
+	jgorm "github.com/jinzhu/gorm"
+)
+
+// contentChanged 修改请求是否真的改动了标题、正文或图片
+func contentChanged(post database.Post, req request.UpdatePostRequest) bool {
+	if req.Title != "" && req.Title != post.Title {
+		return true
+	}
+	if req.Content != "" && req.Content != post.Content {
+		return true
+	}
+	if len(req.ImageURLs) > 0 {
+		old := revisionImages(post.ImageURLs)
+		if len(old) != len(req.ImageURLs) {
+			return true
+		}
+		for i := range old {
+			if old[i] != req.ImageURLs[i] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// recordRevision 把帖子当前内容保存为下一个版本
+// 修改帖子时要在锁住帖子行的事务里调用, 否则并发修改会算出相同的版本号
+func recordRevision(db *jgorm.DB, post database.Post, editorUUID string) error {
+	var last database.PostRevision
+	version := 1
+	if err := db.Where("post_id = ?", post.ID).Order("version desc").First(&last).Error; err == nil {
+		version = last.Version + 1
+	}
+	return db.Create(&database.PostRevision{
+		PostID:     post.ID,
+		Version:    version,
+		Title:      post.Title,
+		Content:    post.Content,
+		ImageURLs:  post.ImageURLs,
+		EditorUUID: editorUUID,
+	}).Error
+}
+
+// ensureBaseRevision 为上线修改记录之前发布的帖子补一条原始版本, 时间记为发帖时间
+func ensureBaseRevision(db *jgorm.DB, post database.Post) error {
+	var n int64
+	if err := db.Model(&database.PostRevision{}).Where("post_id = ?", post.ID).Count(&n).Error; err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	return db.Create(&database.PostRevision{
+		PostID:     post.ID,
+		Version:    1,
+		Title:      post.Title,
+		Content:    post.Content,
+		ImageURLs:  post.ImageURLs,
+		EditorUUID: post.AuthorUUID,
+		CreatedAt:  post.CreateDate,
+	}).Error
+}
+
+// visibleRevisionPost 查询可以查看修改记录的帖子, 未发布的帖子只有作者能看
+func visibleRevisionPost(postID uint, userUUID string) (database.Post, error) {
+	var post database.Post
+	if err := global.DB.First(&post, postID).Error; err != nil {
+		return post, errors.New("帖子不存在")
+	}
+	if post.Status != database.PostStatusPublished && post.AuthorUUID != userUUID {
+		return post, errors.New("帖子不存在")
+	}
+	if !utils.CanViewPost(post, userUUID) {
+		return post, errors.New("帖子不存在")
+	}
+	return post, nil
+}
+
+// postRevisions 帖子的全部版本, 按版本号升序; 没有记录的帖子视为只有当前这一个版本
+func postRevisions(post database.Post) ([]database.PostRevision, error) {
+	var revisions []database.PostRevision
+	if err := global.DB.Where("post_id = ?", post.ID).Order("version").Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		revisions = append(revisions, database.PostRevision{
+			PostID:     post.ID,
+			Version:    1,
+			Title:      post.Title,
+			Content:    post.Content,
+			ImageURLs:  post.ImageURLs,
+			EditorUUID: post.AuthorUUID,
+			CreatedAt:  post.CreateDate,
+		})
+	}
+	return revisions, nil
+}
+
+// ListPostRevisions 帖子的修改历史, 最新版本在前
+func ListPostRevisions(postID uint, userUUID string) ([]response.PostRevisionInfo, error) {
+	post, err := visibleRevisionPost(postID, userUUID)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := postRevisions(post)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]response.PostRevisionInfo, 0, len(revisions))
+	for i := len(revisions) - 1; i >= 0; i-- {
+		r := revisions[i]
+		result = append(result, response.PostRevisionInfo{
+			Version:    r.Version,
+			Title:      r.Title,
+			Content:    r.Content,
+			ImageURLs:  revisionImages(r.ImageURLs),
+			EditorUUID: r.EditorUUID,
+			CreatedAt:  r.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+// DiffPostRevisions 比较帖子的两个版本, 标题和正文按行比较, 图片给出增删
+func DiffPostRevisions(postID uint, from, to int, userUUID string) (response.RevisionDiff, error) {
+	post, err := visibleRevisionPost(postID, userUUID)
+	if err != nil {
+		return response.RevisionDiff{}, err
+	}
+	revisions, err := postRevisions(post)
+	if err != nil {
+		return response.RevisionDiff{}, err
+	}
+
+	var a, b *database.PostRevision
+	for i := range revisions {
+		if revisions[i].Version == from {
+			a = &revisions[i]
+		}
+		if revisions[i].Version == to {
+			b = &revisions[i]
+		}
+	}
+	if a == nil || b == nil {
+		return response.RevisionDiff{}, errors.New("版本不存在")
+	}
+
+	oldImages, newImages := revisionImages(a.ImageURLs), revisionImages(b.ImageURLs)
+	return response.RevisionDiff{
+		PostID:        postID,
+		From:          from,
+		To:            to,
+		Title:         convertDiff(utils.DiffLines(a.Title, b.Title)),
+		Content:       convertDiff(utils.DiffLines(a.Content, b.Content)),
+		ImagesAdded:   missingFrom(newImages, oldImages),
+		ImagesRemoved: missingFrom(oldImages, newImages),
+		FromTime:      a.CreatedAt,
+		ToTime:        b.CreatedAt,
+	}, nil
+}
+
+func revisionImages(data []byte) []string {
+	images := []string{}
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &images)
+	}
+	return images
+}
+
+// missingFrom 在 list 中但不在 other 中的元素
+func missingFrom(list, other []string) []string {
+	seen := make(map[string]bool, len(other))
+	for _, s := range other {
+		seen[s] = true
+	}
+	result := []string{}
+	for _, s := range list {
+		if !seen[s] {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func convertDiff(lines []utils.DiffLine) []response.DiffLine {
+	result := make([]response.DiffLine, 0, len(lines))
+	for _, l := range lines {
+		result = append(result, response.DiffLine{Op: l.Op, Text: l.Text})
+	}
+	return result
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"OpenHouse/model/response"
+	"OpenHouse/search"
+	"OpenHouse/utils"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// rebuildBatchSize 重建索引时每批读取的行数
+const rebuildBatchSize = 500
+
+func postDocument(post database.Post) search.Document {
+	return search.Document{
+		Type:       search.TypePost,
+		ID:         fmt.Sprint(post.ID),
+		Title:      post.Title,
+		Body:       post.Content,
+		Tags:       postTagNames(post.ID),
+		AuthorUUID: post.AuthorUUID,
+		PostID:     post.ID,
+		CreatedAt:  post.CreateDate,
+	}
+}
+
+func userDocument(user database.User) search.Document {
+	return search.Document{
+		Type:       search.TypeUser,
+		ID:         user.UUID,
+		Title:      user.Username,
+		Body:       strings.TrimSpace(user.ResearchArea + "\n" + user.IntroShort),
+		Tags:       utils.ParseTags(user.Tags),
+		AuthorUUID: user.UUID,
+		CreatedAt:  user.CreatedAt,
+	}
+}
+
+func commentDocument(comment database.PostComment) search.Document {
+	return search.Document{
+		Type:       search.TypeComment,
+		ID:         fmt.Sprint(comment.ID),
+		Body:       comment.Content,
+		AuthorUUID: comment.AuthorUUID,
+		PostID:     comment.PostID,
+		CreatedAt:  comment.CreateTime,
+	}
+}
+
+// 索引更新失败只记录日志, 不影响主流程, 可通过重建索引修复
+
+func indexPost(post database.Post) {
+	// 草稿 / 定时发布的帖子不进入索引（修改未发布的帖子时也可能走到这里）, 纯转发没有自己的内容
+	if post.Status != database.PostStatusPublished || isPlainRepost(post) {
+		return
+	}
+	if err := search.Default().Index(postDocument(post)); err != nil {
+		log.Println("[Search] 索引帖子失败:", post.ID, err)
+	}
+}
+
+func indexPostByID(postID uint) {
+	var post database.Post
+	if err := global.DB.First(&post, postID).Error; err != nil {
+		return
+	}
+	indexPost(post)
+}
+
+func indexUser(uuid string) {
+	var user database.User
+	if err := global.DB.Where("uuid = ?", uuid).First(&user).Error; err != nil {
+		return
+	}
+	if err := search.Default().Index(userDocument(user)); err != nil {
+		log.Println("[Search] 索引用户失败:", uuid, err)
+	}
+}
+
+func indexComment(comment database.PostComment) {
+	if err := search.Default().Index(commentDocument(comment)); err != nil {
+		log.Println("[Search] 索引评论失败:", comment.ID, err)
+	}
+}
+
+// unindexPosts 删除帖子及其下全部评论的索引
+func unindexPosts(postIDs ...uint) {
+	if len(postIDs) == 0 {
+		return
+	}
+	var commentIDs []uint
+	global.DB.Unscoped().Model(&database.PostComment{}).Where("post_id IN (?)", postIDs).Pluck("id", &commentIDs)
+	unindexComments(commentIDs...)
+	if err := search.Default().Delete(search.TypePost, uintIDs(postIDs)...); err != nil {
+		log.Println("[Search] 删除帖子索引失败:", err)
+	}
+}
+
+func unindexComments(commentIDs ...uint) {
+	if len(commentIDs) == 0 {
+		return
+	}
+	if err := search.Default().Delete(search.TypeComment, uintIDs(commentIDs)...); err != nil {
+		log.Println("[Search] 删除评论索引失败:", err)
+	}
+}
+
+func unindexUser(uuid string) {
+	if err := search.Default().Delete(search.TypeUser, uuid); err != nil {
+		log.Println("[Search] 删除用户索引失败:", uuid, err)
+	}
+}
+
+// reindexAuthor 重新索引某个用户的资料、帖子与评论, 用于账号合并等批量迁移之后
+func reindexAuthor(uuid string) {
+	indexUser(uuid)
+	var posts []database.Post
+	global.DB.Scopes(published, notPlainRepost).Where("author_uuid = ?", uuid).Find(&posts)
+	for _, p := range posts {
+		indexPost(p)
+	}
+	var comments []database.PostComment
+	global.DB.Where("author_uuid = ?", uuid).Find(&comments)
+	for _, c := range comments {
+		indexComment(c)
+	}
+}
+
+// visibleHitCondition 搜索结果中 viewer 能看到的帖子的条件, 交给索引在分页之前过滤, 总数同样准确
+// 条件用于 posts 表的子查询, 需要自己排除软删除的帖子
+func visibleHitCondition(viewer string) (string, []interface{}) {
+	cond, args := utils.VisiblePostCondition(viewer)
+	return "posts.deleted_at IS NULL AND posts.status = ? AND (" + cond + ")",
+		append([]interface{}{database.PostStatusPublished}, args...)
+}
+
+func uintIDs(ids []uint) []string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, fmt.Sprint(id))
+	}
+	return out
+}
+
+// RebuildSearchIndex 从数据库全量重建搜索索引
+// 使用进程内索引时在启动时调用; 使用 MySQL 索引时可由管理员手动触发
+func RebuildSearchIndex() error {
+	idx := search.Default()
+	if err := idx.Reset(); err != nil {
+		return err
+	}
+
+	var lastUUID string
+	for {
+		var users []database.User
+		if err := global.DB.Where("uuid > ?", lastUUID).Order("uuid").Limit(rebuildBatchSize).Find(&users).Error; err != nil {
+			return err
+		}
+		for _, u := range users {
+			if err := idx.Index(userDocument(u)); err != nil {
+				return err
+			}
+		}
+		if len(users) < rebuildBatchSize {
+			break
+		}
+		lastUUID = users[len(users)-1].UUID
+	}
+
+	var lastPostID uint
+	for {
+		var posts []database.Post
+		if err := global.DB.Scopes(published, notPlainRepost).Where("id > ?", lastPostID).Order("id").Limit(rebuildBatchSize).Find(&posts).Error; err != nil {
+			return err
+		}
+		for _, p := range posts {
+			if err := idx.Index(postDocument(p)); err != nil {
+				return err
+			}
+		}
+		if len(posts) < rebuildBatchSize {
+			break
+		}
+		lastPostID = posts[len(posts)-1].ID
+	}
+
+	var lastCommentID uint
+	for {
+		var comments []database.PostComment
+		if err := global.DB.Where("id > ? AND author_uuid <> ?", lastCommentID, DeletedUserUUID).
+			Order("id").Limit(rebuildBatchSize).Find(&comments).Error; err != nil {
+			return err
+		}
+		for _, c := range comments {
+			if err := idx.Index(commentDocument(c)); err != nil {
+				return err
+			}
+		}
+		if len(comments) < rebuildBatchSize {
+			break
+		}
+		lastCommentID = comments[len(comments)-1].ID
+	}
+	return nil
+}
+
+// Search 搜索帖子 / 用户 / 评论, 结果附带作者信息
+// 索引中包含所有已发布的帖子, 帖子和评论结果只保留 viewer 能看到的
+func Search(q search.Query, viewer string) ([]response.SearchHit, int64, error) {
+	q.PostCondition, q.PostArgs = visibleHitCondition(viewer)
+	hits, total, err := search.Default().Search(q)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	authorUUIDs := make([]string, 0, len(hits))
+	for _, h := range hits {
+		authorUUIDs = append(authorUUIDs, h.AuthorUUID)
+	}
+	userMap := make(map[string]database.User)
+	if len(authorUUIDs) > 0 {
+		var users []database.User
+		_ = global.DB.Where("uuid IN (?)", authorUUIDs).Find(&users)
+		for _, u := range users {
+			userMap[u.UUID] = u
+		}
+	}
+
+	result := make([]response.SearchHit, 0, len(hits))
+	for _, h := range hits {
+		author := userMap[h.AuthorUUID]
+		hit := response.SearchHit{
+			Type:       h.Type,
+			ID:         h.ID,
+			Title:      h.Title,
+			Snippet:    h.Snippet,
+			Score:      h.Score,
+			AuthorUUID: h.AuthorUUID,
+			Username:   author.Username,
+			AvatarURL:  author.AvatarURL,
+			IsVerified: author.IsVerified,
+			CreatedAt:  h.CreatedAt,
+		}
+		if h.Type != search.TypeUser {
+			hit.PostID = h.PostID
+		}
+		result = append(result, hit)
+	}
+	return result, total, nil
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/utils"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// feedItemCount 每个订阅源包含的最新帖子数
+const feedItemCount = 20
+
+// 订阅源供站外的阅读器使用, 一律按未登录访问: 只包含公开的已发布帖子, 不含纯转发
+
+// UserFeed 某个用户最新的公开帖子
+func UserFeed(uuid string) (utils.Feed, error) {
+	site, err := FeedSiteURL()
+	if err != nil {
+		return utils.Feed{}, err
+	}
+	var user database.User
+	if err := global.DB.Where("uuid = ?", uuid).First(&user).Error; err != nil || uuid == DeletedUserUUID {
+		return utils.Feed{}, errors.New("用户不存在")
+	}
+	db := global.DB.Model(&database.Post{}).Scopes(notPlainRepost).Where("author_uuid = ?", uuid)
+	posts, _, err := listPostsWhere(db, feedPage(), "desc", "")
+	if err != nil {
+		return utils.Feed{}, err
+	}
+	return buildFeed(site, utils.Feed{
+		Title:       user.Username + " - OpenHouse",
+		Description: user.IntroShort,
+		Link:        site + "/user/" + url.PathEscape(uuid),
+	}, posts), nil
+}
+
+// TagFeed 带有某个标签的最新公开帖子
+func TagFeed(name string) (utils.Feed, error) {
+	site, err := FeedSiteURL()
+	if err != nil {
+		return utils.Feed{}, err
+	}
+	tag, err := findTag(name)
+	if err != nil {
+		return utils.Feed{}, err
+	}
+	db := global.DB.Model(&database.Post{}).Scopes(notPlainRepost).
+		Where("id IN (SELECT post_id FROM post_tags WHERE tag_id = ?)", tag.ID)
+	posts, _, err := listPostsWhere(db, feedPage(), "desc", "")
+	if err != nil {
+		return utils.Feed{}, err
+	}
+	return buildFeed(site, utils.Feed{
+		Title:       "#" + tag.Name + " - OpenHouse",
+		Description: "OpenHouse 上带有 #" + tag.Name + " 标签的帖子",
+		Link:        site + "/tags/" + url.PathEscape(tag.Slug),
+	}, posts), nil
+}
+
+// HotFeed 全站热门帖子, 顺序与首页热门流一致
+func HotFeed() (utils.Feed, error) {
+	site, err := FeedSiteURL()
+	if err != nil {
+		return utils.Feed{}, err
+	}
+	posts, _, err := listHotPosts(feedPage(), "")
+	if err != nil {
+		return utils.Feed{}, err
+	}
+	return buildFeed(site, utils.Feed{
+		Title:       "OpenHouse 热门",
+		Description: "OpenHouse 全站热门帖子",
+		Link:        site + "/",
+	}, posts), nil
+}
+
+func feedPage() request.CursorPage {
+	return request.CursorPage{PageSize: feedItemCount}
+}
+
+// FeedSiteURL 订阅源中帖子、用户链接指向的前端地址, 来自配置 feed.site_url
+// 订阅源会被阅读器和代理缓存, 链接不能取自请求头中客户端可以伪造的 Host
+func FeedSiteURL() (string, error) {
+	return feedConfigURL("feed.site_url")
+}
+
+// FeedAPIURL 订阅源自身所在的 API 地址, 来自配置 feed.api_url, 未配置时与 feed.site_url 相同
+func FeedAPIURL() (string, error) {
+	if global.VP.GetString("feed.api_url") == "" {
+		return FeedSiteURL()
+	}
+	return feedConfigURL("feed.api_url")
+}
+
+func feedConfigURL(key string) (string, error) {
+	raw := strings.TrimRight(global.VP.GetString(key), "/")
+	u, err := url.Parse(raw)
+	if raw == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", errors.New("未配置订阅源地址")
+	}
+	return raw, nil
+}
+
+// buildFeed 把帖子列表转换为订阅条目, 订阅源的更新时间取最近一次发帖或修改的时间
+func buildFeed(site string, feed utils.Feed, posts []response.PostInfo) utils.Feed {
+	feed.Items = make([]utils.FeedItem, 0, len(posts))
+	for _, p := range posts {
+		item := feedItem(site, p)
+		if item.Updated.After(feed.Updated) {
+			feed.Updated = item.Updated
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	if feed.Updated.IsZero() {
+		feed.Updated = time.Now()
+	}
+	return feed
+}
+
+func feedItem(site string, p response.PostInfo) utils.FeedItem {
+	link := fmt.Sprintf("%s/posts/%d", site, p.PostID)
+	title := p.Title
+	if title == "" {
+		title = utils.Excerpt(p.Excerpt, 60)
+	}
+	updated := p.CreateDate
+	if p.EditedAt != nil {
+		updated = *p.EditedAt
+	}
+	return utils.FeedItem{
+		ID:          link,
+		Title:       title,
+		Link:        link,
+		Summary:     p.Excerpt,
+		ContentHTML: feedContentHTML(site, p),
+		AuthorName:  p.Username,
+		AuthorURL:   site + "/user/" + url.PathEscape(p.AuthorUUID),
+		Tags:        p.Tags,
+		Published:   p.CreateDate,
+		Updated:     updated,
+	}
+}
+
+// feedContentHTML 正文 HTML 之后附上图片; 引用帖附上被引用帖子的正文
+func feedContentHTML(site string, p response.PostInfo) string {
+	var sb strings.Builder
+	sb.WriteString(p.ContentHTML)
+	for _, src := range p.ImageURLs {
+		fmt.Fprintf(&sb, `<p><img src="%s" alt=""></p>`, html.EscapeString(src))
+	}
+	if origin := p.RepostOf; origin != nil {
+		fmt.Fprintf(&sb, `<blockquote><p><a href="%s/posts/%d">%s</a></p>%s</blockquote>`,
+			site, origin.PostID, html.EscapeString("@"+origin.Username), origin.ContentHTML)
+	}
+	return sb.String()
+}
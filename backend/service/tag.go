@@ -0,0 +1,294 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"OpenHouse/model/request"
+	"OpenHouse/model/response"
+	"OpenHouse/utils"
+	"errors"
+	"log"
+	"time"
+
+	jgorm "github.com/jinzhu/gorm"
+)
+
+const (
+	maxPostTags         = 10 // 每个帖子的标签上限（显式标签 + #话题）
+	defaultTrendingDays = 7
+	defaultTrendingSize = 10
+)
+
+// postTagInput 待保存的帖子标签
+type postTagInput struct {
+	Name     string
+	Slug     string
+	Explicit bool
+}
+
+// collectPostTags 合并显式标签与标题、正文中的 #话题, 按规范化名称去重, 显式标签优先
+func collectPostTags(explicit []string, title, content string) []postTagInput {
+	seen := make(map[string]bool)
+	tags := make([]postTagInput, 0, len(explicit))
+	add := func(name string, isExplicit bool) {
+		slug := utils.NormalizeTag(name)
+		if slug == "" || seen[slug] || len(tags) >= maxPostTags {
+			return
+		}
+		seen[slug] = true
+		tags = append(tags, postTagInput{Name: utils.TagDisplayName(name), Slug: slug, Explicit: isExplicit})
+	}
+	for _, name := range explicit {
+		add(name, true)
+	}
+	for _, name := range utils.ExtractHashtags(title + "\n" + content) {
+		add(name, false)
+	}
+	return tags
+}
+
+// ensureTags 按规范化名称查找标签, 不存在的新建, 返回顺序与 names 一致
+func ensureTags(tx *jgorm.DB, names []string) ([]database.Tag, error) {
+	slugs := make([]string, 0, len(names))
+	seen := make(map[string]bool)
+	now := time.Now()
+	for _, name := range names {
+		slug := utils.NormalizeTag(name)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		slugs = append(slugs, slug)
+		// 并发创建同名标签时以先插入的为准
+		if err := tx.Exec("INSERT IGNORE INTO tags (name, slug, created_at) VALUES (?, ?, ?)",
+			utils.TagDisplayName(name), slug, now).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(slugs) == 0 {
+		return []database.Tag{}, nil
+	}
+
+	var tags []database.Tag
+	if err := tx.Where("slug IN (?)", slugs).Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	bySlug := make(map[string]database.Tag, len(tags))
+	for _, t := range tags {
+		bySlug[t.Slug] = t
+	}
+	result := make([]database.Tag, 0, len(slugs))
+	for _, slug := range slugs {
+		if t, ok := bySlug[slug]; ok {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+// setPostTags 用显式标签和正文 #话题 覆盖帖子的标签; 在调用方的事务 tx 中执行
+func setPostTags(tx *jgorm.DB, postID uint, explicit []string, title, content string) error {
+	inputs := collectPostTags(explicit, title, content)
+	names := make([]string, 0, len(inputs))
+	explicitSlugs := make(map[string]bool, len(inputs))
+	for _, in := range inputs {
+		names = append(names, in.Name)
+		if in.Explicit {
+			explicitSlugs[in.Slug] = true
+		}
+	}
+	tags, err := ensureTags(tx, names)
+	if err != nil {
+		return err
+	}
+	if err := tx.Where("post_id = ?", postID).Delete(&database.PostTag{}).Error; err != nil {
+		return err
+	}
+	// 按规范化名称对应回输入, 不依赖 ensureTags 返回的顺序和条数
+	for _, t := range tags {
+		if err := tx.Create(&database.PostTag{PostID: postID, TagID: t.ID, Explicit: explicitSlugs[t.Slug]}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// explicitPostTags 帖子当前的显式标签, 修改帖子时未传 tags 则沿用
+func explicitPostTags(postID uint) []string {
+	names := []string{}
+	global.DB.Table("post_tags").
+		Joins("JOIN tags ON tags.id = post_tags.tag_id").
+		Where("post_tags.post_id = ? AND post_tags.explicit = ?", postID, true).
+		Order("post_tags.id").
+		Pluck("tags.name", &names)
+	return names
+}
+
+// setUserTags 把用户资料标签同步到标签表
+func setUserTags(uuid string, names []string) error {
+	return global.DB.Transaction(func(tx *jgorm.DB) error {
+		tags, err := ensureTags(tx, names)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("user_uuid = ?", uuid).Delete(&database.UserTag{}).Error; err != nil {
+			return err
+		}
+		for _, t := range tags {
+			if err := tx.Create(&database.UserTag{UserUUID: uuid, TagID: t.ID}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SyncUserTags 为还没有同步到标签表的用户补建用户标签, 启动时调用
+func SyncUserTags() {
+	var users []database.User
+	if err := global.DB.Where("tags IS NOT NULL AND uuid NOT IN (SELECT user_uuid FROM user_tags)").
+		Find(&users).Error; err != nil {
+		log.Println("[Tag] 查询待同步用户失败:", err)
+		return
+	}
+	for _, u := range users {
+		if tags := utils.ParseTags(u.Tags); len(tags) > 0 {
+			if err := setUserTags(u.UUID, tags); err != nil {
+				log.Println("[Tag] 同步用户标签失败:", u.UUID, err)
+			}
+		}
+	}
+}
+
+// postTagNames 帖子的标签展示名
+func postTagNames(postID uint) []string {
+	names := []string{}
+	global.DB.Table("post_tags").
+		Joins("JOIN tags ON tags.id = post_tags.tag_id").
+		Where("post_tags.post_id = ?", postID).
+		Order("post_tags.id").
+		Pluck("tags.name", &names)
+	return names
+}
+
+// findTag 按任意写法查找标签
+func findTag(name string) (database.Tag, error) {
+	var tag database.Tag
+	slug := utils.NormalizeTag(name)
+	if slug == "" {
+		return tag, errors.New("标签不能为空")
+	}
+	if err := global.DB.Where("slug = ?", slug).First(&tag).Error; err != nil {
+		return tag, errors.New("标签不存在")
+	}
+	return tag, nil
+}
+
+// followedTagIDs 用户关注的标签
+func followedTagIDs(userUUID string) []uint {
+	var ids []uint
+	global.DB.Model(&database.TagFollow{}).Where("user_uuid = ?", userUUID).Pluck("tag_id", &ids)
+	return ids
+}
+
+// GetTag 标签详情: 帖子数、用户数、关注数
+func GetTag(name, userUUID string) (response.TagInfo, error) {
+	tag, err := findTag(name)
+	if err != nil {
+		return response.TagInfo{}, err
+	}
+
+	info := response.TagInfo{Name: tag.Name, Slug: tag.Slug}
+	global.DB.Model(&database.Post{}).Scopes(published, visibleTo(userUUID)).
+		Where("id IN (SELECT post_id FROM post_tags WHERE tag_id = ?)", tag.ID).
+		Count(&info.PostCount)
+	global.DB.Model(&database.UserTag{}).Where("tag_id = ?", tag.ID).Count(&info.UserCount)
+	global.DB.Model(&database.TagFollow{}).Where("tag_id = ?", tag.ID).Count(&info.FollowerCount)
+	var n int64
+	global.DB.Model(&database.TagFollow{}).Where("tag_id = ? AND user_uuid = ?", tag.ID, userUUID).Count(&n)
+	info.IsFollowing = n > 0
+	return info, nil
+}
+
+// ListTagPosts 标签页: 带有该标签的帖子
+func ListTagPosts(name string, page request.CursorPage, sortOrder string, userUUID string) ([]response.PostInfo, response.PageInfo, error) {
+	tag, err := findTag(name)
+	if err != nil {
+		return nil, response.PageInfo{}, err
+	}
+	db := global.DB.Model(&database.Post{}).Where("id IN (SELECT post_id FROM post_tags WHERE tag_id = ?)", tag.ID)
+	return listPostsWhere(db, page, sortOrder, userUUID)
+}
+
+// TrendingTags 最近 days 天内发帖最多的标签, 只统计公开帖子, 同一作者的多篇帖子只算一次参与人数
+func TrendingTags(days, limit int) ([]response.TrendingTag, error) {
+	if days <= 0 {
+		days = defaultTrendingDays
+	}
+	if limit <= 0 {
+		limit = defaultTrendingSize
+	}
+	since := time.Now().AddDate(0, 0, -days)
+
+	result := []response.TrendingTag{}
+	if err := global.DB.Raw(`SELECT tags.name, tags.slug, COUNT(*) AS post_count, COUNT(DISTINCT posts.author_uuid) AS author_count
+		FROM post_tags
+		JOIN tags ON tags.id = post_tags.tag_id
+		JOIN posts ON posts.id = post_tags.post_id AND posts.deleted_at IS NULL
+		WHERE posts.status = ? AND posts.visibility = ? AND posts.create_date >= ?
+		GROUP BY tags.id, tags.name, tags.slug
+		ORDER BY author_count DESC, post_count DESC, tags.id
+		LIMIT ?`, database.PostStatusPublished, database.PostVisibilityPublic, since, limit).Scan(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FollowTag 关注标签, 之后该标签下的帖子会出现在关注流中
+func FollowTag(userUUID, name string) error {
+	tag, err := findTag(name)
+	if err != nil {
+		return err
+	}
+
+	var follow database.TagFollow
+	err = global.DB.Unscoped().Where("user_uuid = ? AND tag_id = ?", userUUID, tag.ID).First(&follow).Error
+	if err == nil {
+		if !follow.DeletedAt.Valid {
+			return errors.New("已关注该标签")
+		}
+		// 恢复已删除记录
+		return global.DB.Exec("UPDATE tag_follows SET deleted_at = NULL WHERE id = ?", follow.ID).Error
+	}
+	return global.DB.Create(&database.TagFollow{UserUUID: userUUID, TagID: tag.ID}).Error
+}
+
+// UnfollowTag 取消关注标签
+func UnfollowTag(userUUID, name string) error {
+	tag, err := findTag(name)
+	if err != nil {
+		return err
+	}
+	var follow database.TagFollow
+	if err := global.DB.Where("user_uuid = ? AND tag_id = ?", userUUID, tag.ID).First(&follow).Error; err != nil {
+		return errors.New("尚未关注该标签")
+	}
+	return global.DB.Delete(&follow).Error
+}
+
+// ListFollowedTags 我关注的标签
+func ListFollowedTags(userUUID string) ([]response.TagInfo, error) {
+	var tags []database.Tag
+	if err := global.DB.
+		Joins("JOIN tag_follows ON tag_follows.tag_id = tags.id AND tag_follows.deleted_at IS NULL").
+		Where("tag_follows.user_uuid = ?", userUUID).
+		Order("tag_follows.id desc").
+		Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	result := make([]response.TagInfo, 0, len(tags))
+	for _, t := range tags {
+		result = append(result, response.TagInfo{Name: t.Name, Slug: t.Slug, IsFollowing: true})
+	}
+	return result, nil
+}
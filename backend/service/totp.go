@@ -0,0 +1,332 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"OpenHouse/utils"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	jgorm "github.com/jinzhu/gorm"
+)
+
+const (
+	preAuthTokenPurpose = "2fa"
+	preAuthTokenTTL     = 5 * time.Minute
+	totpMaxAttempts     = 5                // 连续输错这么多次后锁定
+	totpLockout         = 15 * time.Minute // 锁定时长
+	recoveryCodeCount   = 10               // 每次生成的恢复码数量
+)
+
+// TOTPSetupResult 开启两步验证第一步返回的密钥与二维码地址
+type TOTPSetupResult struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+func totpIssuer() string {
+	if issuer := global.VP.GetString("totp.issuer"); issuer != "" {
+		return issuer
+	}
+	return "OpenHouse"
+}
+
+// SetupTOTP 生成新的 TOTP 密钥, 在 EnableTOTP 校验通过前不生效
+func SetupTOTP(uuid string) (TOTPSetupResult, error) {
+	var user database.User
+	if err := global.DB.Where("uuid = ?", uuid).First(&user).Error; err != nil {
+		return TOTPSetupResult{}, errors.New("用户不存在")
+	}
+	if user.TOTPEnabled {
+		return TOTPSetupResult{}, errors.New("已开启两步验证")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return TOTPSetupResult{}, errors.New("生成密钥失败")
+	}
+	if err := global.DB.Model(&database.User{}).Where("uuid = ?", uuid).Updates(map[string]interface{}{
+		"totp_secret":    secret,
+		"totp_last_step": 0,
+	}).Error; err != nil {
+		return TOTPSetupResult{}, errors.New("保存密钥失败")
+	}
+
+	account := user.Email
+	if account == "" {
+		account = user.Username
+	}
+	if account == "" {
+		account = user.UUID
+	}
+	return TOTPSetupResult{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(secret, account, totpIssuer()),
+	}, nil
+}
+
+// EnableTOTP 用验证器生成的验证码确认绑定, 成功后开启两步验证并返回恢复码
+func EnableTOTP(uuid, code string) ([]string, error) {
+	var user database.User
+	if err := global.DB.Where("uuid = ?", uuid).First(&user).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	if user.TOTPEnabled {
+		return nil, errors.New("已开启两步验证")
+	}
+	if user.TOTPSecret == "" {
+		return nil, errors.New("请先获取两步验证密钥")
+	}
+	step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return nil, errors.New("验证码错误")
+	}
+
+	var codes []string
+	err := global.DB.Transaction(func(tx *jgorm.DB) error {
+		if err := tx.Model(&database.User{}).Where("uuid = ?", uuid).Updates(map[string]interface{}{
+			"totp_enabled":   true,
+			"totp_last_step": step,
+		}).Error; err != nil {
+			return errors.New("开启两步验证失败")
+		}
+		var err error
+		codes, err = resetRecoveryCodes(tx, uuid)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTOTP 关闭两步验证, 需要提供验证码或恢复码
+func DisableTOTP(uuid, code string) error {
+	var user database.User
+	if err := global.DB.Where("uuid = ?", uuid).First(&user).Error; err != nil {
+		return errors.New("用户不存在")
+	}
+	if !user.TOTPEnabled {
+		return errors.New("尚未开启两步验证")
+	}
+	if err := verifyTwoFactorCode(user, code); err != nil {
+		return err
+	}
+
+	return global.DB.Transaction(func(tx *jgorm.DB) error {
+		if err := tx.Model(&database.User{}).Where("uuid = ?", uuid).Updates(map[string]interface{}{
+			"totp_enabled":   false,
+			"totp_secret":    "",
+			"totp_last_step": 0,
+		}).Error; err != nil {
+			return errors.New("关闭两步验证失败")
+		}
+		if err := tx.Where("user_uuid = ?", uuid).Delete(&database.RecoveryCode{}).Error; err != nil {
+			return errors.New("清理恢复码失败")
+		}
+		return nil
+	})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码, 旧的恢复码全部作废
+func RegenerateRecoveryCodes(uuid, code string) ([]string, error) {
+	var user database.User
+	if err := global.DB.Where("uuid = ?", uuid).First(&user).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	if !user.TOTPEnabled {
+		return nil, errors.New("尚未开启两步验证")
+	}
+	if err := verifyTwoFactorCode(user, code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err := global.DB.Transaction(func(tx *jgorm.DB) error {
+		var err error
+		codes, err = resetRecoveryCodes(tx, uuid)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// resetRecoveryCodes 删除旧恢复码并生成新的一组, 明文只在此处返回一次
+func resetRecoveryCodes(tx *jgorm.DB, uuid string) ([]string, error) {
+	if err := tx.Where("user_uuid = ?", uuid).Delete(&database.RecoveryCode{}).Error; err != nil {
+		return nil, errors.New("清理恢复码失败")
+	}
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, 10)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, errors.New("生成恢复码失败")
+		}
+		for j := range buf {
+			buf[j] = alphabet[int(buf[j])%len(alphabet)]
+		}
+		code := string(buf[:5]) + "-" + string(buf[5:])
+		if err := tx.Create(&database.RecoveryCode{
+			UserUUID:  uuid,
+			CodeHash:  hashRecoveryCode(code),
+			CreatedAt: time.Now(),
+		}).Error; err != nil {
+			return nil, errors.New("保存恢复码失败")
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+var errTwoFactorLocked = errors.New("验证码错误次数过多, 请 15 分钟后再试")
+
+// verifyTwoFactorCode 校验 6 位 TOTP 验证码, 不是验证码格式时按恢复码处理
+// 失败次数按用户记在数据库中, 重新登录或重启服务都不会清零, 连续输错 totpMaxAttempts 次后锁定 totpLockout
+func verifyTwoFactorCode(user database.User, code string) error {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return errors.New("请输入验证码")
+	}
+	if err := reserveTwoFactorAttempt(user.UUID); err != nil {
+		return err
+	}
+	if err := checkTwoFactorCode(user, code); err != nil {
+		// 用完次数后锁定, 计数清零; 锁定期内 reserveTwoFactorAttempt 会直接拒绝
+		global.DB.Model(&database.User{}).
+			Where("uuid = ? AND totp_failed_attempts >= ?", user.UUID, totpMaxAttempts).
+			UpdateColumns(map[string]interface{}{
+				"totp_failed_attempts": 0,
+				"totp_locked_until":    time.Now().Add(totpLockout),
+			})
+		return err
+	}
+	global.DB.Model(&database.User{}).Where("uuid = ?", user.UUID).
+		UpdateColumns(map[string]interface{}{"totp_failed_attempts": 0, "totp_locked_until": nil})
+	return nil
+}
+
+// reserveTwoFactorAttempt 校验前先占用一次机会, 并发请求也不会超过次数上限; 锁定中或次数用完时返回错误
+func reserveTwoFactorAttempt(uuid string) error {
+	res := global.DB.Model(&database.User{}).
+		Where("uuid = ? AND totp_failed_attempts < ? AND (totp_locked_until IS NULL OR totp_locked_until <= ?)",
+			uuid, totpMaxAttempts, time.Now()).
+		UpdateColumn("totp_failed_attempts", jgorm.Expr("totp_failed_attempts + 1"))
+	if res.Error != nil {
+		return errors.New("校验验证码失败")
+	}
+	if res.RowsAffected == 0 {
+		return errTwoFactorLocked
+	}
+	return nil
+}
+
+// checkTwoFactorCode 比对验证码或恢复码, 通过后标记已使用
+func checkTwoFactorCode(user database.User, code string) error {
+
+	if step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now()); ok {
+		// 同一时间窗口的验证码只能用一次
+		res := global.DB.Model(&database.User{}).
+			Where("uuid = ? AND totp_last_step < ?", user.UUID, step).
+			UpdateColumn("totp_last_step", step)
+		if res.Error != nil {
+			return errors.New("校验验证码失败")
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("验证码已使用, 请等待下一个验证码")
+		}
+		return nil
+	}
+
+	res := global.DB.Model(&database.RecoveryCode{}).
+		Where("user_uuid = ? AND code_hash = ? AND used_at IS NULL", user.UUID, hashRecoveryCode(code)).
+		UpdateColumn("used_at", time.Now())
+	if res.Error != nil {
+		return errors.New("校验恢复码失败")
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("验证码错误")
+	}
+	return nil
+}
+
+// GeneratePreAuthToken 生成两步验证用的临时凭证
+// 凭证中没有 uuid 声明, 不能用于访问需要登录的接口
+func GeneratePreAuthToken(uuid string) (string, error) {
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+	claims := jwt.MapClaims{
+		"purpose":       preAuthTokenPurpose,
+		"pre_auth_uuid": uuid,
+		"jti":           hex.EncodeToString(jti),
+		"exp":           time.Now().Add(preAuthTokenTTL).Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(global.VP.GetString("jwt.secret")))
+}
+
+// parsePreAuthToken 解析临时凭证, 返回用户 UUID
+func parsePreAuthToken(tokenString string) (string, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(global.VP.GetString("jwt.secret")), nil
+	})
+	if err != nil || !token.Valid {
+		return "", errors.New("登录凭证无效或已过期, 请重新登录")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != preAuthTokenPurpose {
+		return "", errors.New("登录凭证无效")
+	}
+	uuid, _ := claims["pre_auth_uuid"].(string)
+	if uuid == "" {
+		return "", errors.New("登录凭证无效")
+	}
+	return uuid, nil
+}
+
+// CompleteTwoFactorLogin 校验临时凭证与验证码 (或恢复码), 通过后签发正式登录 token
+func CompleteTwoFactorLogin(preAuthToken, code string) (AuthResult, error) {
+	uuid, err := parsePreAuthToken(preAuthToken)
+	if err != nil {
+		return AuthResult{}, err
+	}
+
+	var user database.User
+	if err := global.DB.Where("uuid = ?", uuid).First(&user).Error; err != nil {
+		return AuthResult{}, errors.New("Error: user not found")
+	}
+	if user.IsBanned {
+		return AuthResult{}, errors.New("Error: this account has been banned")
+	}
+	if !user.TOTPEnabled {
+		return AuthResult{}, errors.New("该账号未开启两步验证")
+	}
+
+	if err := verifyTwoFactorCode(user, code); err != nil {
+		return AuthResult{}, err
+	}
+
+	token, err := GenerateJWT(user.UUID)
+	if err != nil {
+		return AuthResult{}, errors.New("生成登录凭证失败")
+	}
+	return AuthResult{Token: token}, nil
+}
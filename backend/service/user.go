@@ -4,8 +4,16 @@ import (
 	"OpenHouse/global"
 	"OpenHouse/model/database"
 	"OpenHouse/model/request"
+	"OpenHouse/utils"
 	"encoding/json"
 	"errors"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-sql-driver/mysql"
+	jgorm "github.com/jinzhu/gorm"
 )
 
 type ProfileResponse struct {
@@ -24,6 +32,7 @@ type ProfileResponse struct {
 	IsGitHubBound bool     `json:"is_github_bound"`
 	IsGoogleBound bool     `json:"is_google_bound"`
 	MatchStatus   string   `json:"match_status"` // "available" or "matching" or "matched"
+	Institution   string   `json:"institution"`  // 学术认证的机构名称
 }
 
 // GetProfile 查询用户Profile
@@ -54,23 +63,86 @@ func GetProfile(uuid string) (ProfileResponse, error) {
 		IsGitHubBound: user.IsGitHubBound,
 		IsGoogleBound: user.IsGoogleBound,
 		MatchStatus:   user.MatchStatus,
+		Institution:   user.Institution,
 	}, nil
 }
 
-// UpdateProfile 更新用户Profile（支持部分字段）
+var errUsernameTaken = errors.New("用户名已被占用")
+
+// usernameKey 用户名的唯一键, 不区分大小写
+func usernameKey(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// usernameTaken 用户名是否已被其他账号占用, exceptUUID 为空表示新注册
+func usernameTaken(username, exceptUUID string) (bool, error) {
+	var count int
+	err := global.DB.Model(&database.User{}).
+		Where("username_key = ? AND uuid <> ?", usernameKey(username), exceptUUID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// SyncUsernameKeys 为还没有唯一键的用户补上, 启动时调用
+// 按注册时间先后处理, 与已有账号重名（不区分大小写）的保持为空, 用户改名后再补上
+func SyncUsernameKeys() {
+	var users []database.User
+	if err := global.DB.Where("username_key IS NULL AND username <> '' AND uuid <> ?", DeletedUserUUID).
+		Order("created_at, uuid").Find(&users).Error; err != nil {
+		log.Println("[User] 查询待补用户名唯一键的用户失败:", err)
+		return
+	}
+	skipped := 0
+	for _, u := range users {
+		if err := global.DB.Model(&database.User{}).Where("uuid = ?", u.UUID).
+			UpdateColumn("username_key", usernameKey(u.Username)).Error; err != nil {
+			if !isDuplicateKey(err) {
+				log.Println("[User] 补用户名唯一键失败:", u.UUID, err)
+			}
+			skipped++
+		}
+	}
+	if skipped > 0 {
+		log.Println("[User] 与他人重名、暂未设置用户名唯一键的用户数:", skipped)
+	}
+}
+
+// isDuplicateKey 是否为违反唯一索引的错误
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
+// genderValues 允许的性别取值, key 为小写输入, value 为存储值
+var genderValues = map[string]string{
+	"male":   "male",
+	"female": "female",
+	"other":  "Other",
+}
+
+// UpdateProfile 更新用户Profile（支持部分字段, 仅限用户可编辑字段）
 func UpdateProfile(uuid string, input request.UpdateProfileInput) error {
 	updates := make(map[string]interface{})
 
 	if input.Username != nil {
-		updates["username"] = *input.Username
-	}
-	if input.Email != nil {
-		updates["email"] = *input.Email
-	}
-	if input.IsVerified != nil {
-		updates["is_verified"] = *input.IsVerified
+		username := strings.TrimSpace(*input.Username)
+		if n := utf8.RuneCountInString(username); n < 2 || n > 32 {
+			return errors.New("用户名长度需在2到32个字符之间")
+		}
+		taken, err := usernameTaken(username, uuid)
+		if err != nil {
+			return errors.New("校验用户名失败")
+		}
+		if taken {
+			return errUsernameTaken
+		}
+		updates["username"] = username
+		updates["username_key"] = usernameKey(username)
 	}
 	if input.AvatarURL != nil {
+		if !utils.IsOwnMediaURL(*input.AvatarURL) {
+			return errors.New("头像必须通过本站上传")
+		}
 		updates["avatar_url"] = *input.AvatarURL
 	}
 	if input.IntroShort != nil {
@@ -80,14 +152,95 @@ func UpdateProfile(uuid string, input request.UpdateProfileInput) error {
 		updates["intro_long"] = *input.IntroLong
 	}
 	if input.Gender != nil {
-		updates["gender"] = *input.Gender
+		gender, ok := genderValues[strings.ToLower(strings.TrimSpace(*input.Gender))]
+		if !ok {
+			return errors.New("性别取值无效")
+		}
+		updates["gender"] = gender
 	}
 	if input.ResearchArea != nil {
-		updates["research_area"] = *input.ResearchArea
+		updates["research_area"] = strings.TrimSpace(*input.ResearchArea)
+	}
+
+	if input.Tags != nil {
+		tags := make([]string, 0, len(*input.Tags))
+		seen := make(map[string]bool)
+		for _, tag := range *input.Tags {
+			tag = strings.TrimSpace(tag)
+			if tag == "" || seen[strings.ToLower(tag)] {
+				continue
+			}
+			seen[strings.ToLower(tag)] = true
+			tags = append(tags, tag)
+		}
+		tagsJSON, err := json.Marshal(tags)
+		if err != nil {
+			return errors.New("标签序列化失败")
+		}
+		updates["tags"] = tagsJSON
 	}
+
+	if len(updates) == 0 {
+		return errors.New("没有需要更新的字段")
+	}
+
+	if err := global.DB.Model(&database.User{}).Where("uuid = ?", uuid).Updates(updates).Error; err != nil {
+		if input.Username != nil && isDuplicateKey(err) {
+			// 并发改成同一个用户名时由唯一索引兜底
+			return errUsernameTaken
+		}
+		return err
+	}
+	if input.Tags != nil {
+		if err := setUserTags(uuid, *input.Tags); err != nil {
+			return errors.New("保存标签失败")
+		}
+	}
+	indexUser(uuid)
+	return nil
+}
+
+// ProfileSystemUpdate 由服务端维护的用户字段, 只能由匹配、绑定、认证等内部流程修改
+type ProfileSystemUpdate struct {
+	Coin          *int
+	IsVerified    *bool
+	Institution   *string
+	AcademicEmail *string
+	VerifiedAt    *time.Time
+	Email         *string // 只在绑定邮箱登录方式、合并账号时设置
+	IsEmailBound  *bool
+	IsGitHubBound *bool
+	IsGoogleBound *bool
+	MatchStatus   *string // "available" or "matching" or "matched"
+}
+
+// UpdateProfileSystem 内部更新用户的系统字段
+func UpdateProfileSystem(uuid string, input ProfileSystemUpdate) error {
+	return updateProfileSystem(global.DB, uuid, input)
+}
+
+// updateProfileSystem 同 UpdateProfileSystem, 可传入事务
+func updateProfileSystem(db *jgorm.DB, uuid string, input ProfileSystemUpdate) error {
+	updates := make(map[string]interface{})
+
 	if input.Coin != nil {
 		updates["coin"] = *input.Coin
 	}
+	if input.IsVerified != nil {
+		updates["is_verified"] = *input.IsVerified
+	}
+	if input.Institution != nil {
+		updates["institution"] = *input.Institution
+	}
+	if input.AcademicEmail != nil {
+		updates["academic_email"] = *input.AcademicEmail
+	}
+	if input.VerifiedAt != nil {
+		updates["verified_at"] = *input.VerifiedAt
+	}
+	if input.Email != nil {
+		updates["email"] = *input.Email
+	}
 	if input.IsEmailBound != nil {
 		updates["is_email_bound"] = *input.IsEmailBound
 	}
@@ -98,20 +251,30 @@ func UpdateProfile(uuid string, input request.UpdateProfileInput) error {
 		updates["is_google_bound"] = *input.IsGoogleBound
 	}
 	if input.MatchStatus != nil {
-		updates["match_status"] = *input.MatchStatus
-	}
-
-	if input.Tags != nil {
-		tagsJSON, err := json.Marshal(input.Tags)
-		if err != nil {
-			return errors.New("标签序列化失败")
+		switch *input.MatchStatus {
+		case "available", "matching", "matched":
+			updates["match_status"] = *input.MatchStatus
+		default:
+			return errors.New("匹配状态无效")
 		}
-		updates["tags"] = tagsJSON
 	}
 
 	if len(updates) == 0 {
 		return errors.New("没有需要更新的字段")
 	}
 
-	return global.DB.Model(&database.User{}).Where("uuid = ?", uuid).Updates(updates).Error
+	return db.Model(&database.User{}).Where("uuid = ?", uuid).Updates(updates).Error
+}
+
+// providerBoundUpdate 构造某种登录方式的绑定标志位更新
+func providerBoundUpdate(provider AuthProvider, bound bool) ProfileSystemUpdate {
+	switch provider {
+	case ProviderEmail:
+		return ProfileSystemUpdate{IsEmailBound: &bound}
+	case ProviderGitHub:
+		return ProfileSystemUpdate{IsGitHubBound: &bound}
+	case ProviderGoogle:
+		return ProfileSystemUpdate{IsGoogleBound: &bound}
+	}
+	return ProfileSystemUpdate{}
 }
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	swot "github.com/Mathpix/swot"
+	jgorm "github.com/jinzhu/gorm"
+)
+
+const (
+	academicCodeTTL         = 10 * time.Minute // 学术认证验证码有效期
+	academicCodeMaxAttempts = 5                // 输错这么多次后验证码作废, 需要重新获取
+	academicSendInterval    = time.Minute      // 同一账号两次发送的最短间隔
+	academicSendHourlyLimit = 5                // 同一账号每小时最多发送次数
+)
+
+var errAcademicEmailTaken = errors.New("该邮箱已用于认证其他账号")
+
+// generateVerifyCode 生成6位数字验证码
+func generateVerifyCode() (string, error) {
+	code := ""
+	for i := 0; i < 6; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		code += n.String()
+	}
+	return code, nil
+}
+
+// SendAcademicVerifyCode 向机构邮箱发送学术认证验证码
+func SendAcademicVerifyCode(userUUID string, email string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if !swot.IsAcademic(email) || swot.GetSchoolName(email) == "" {
+		return errors.New("该邮箱不属于学术机构")
+	}
+
+	// 一个机构邮箱只能认证一个账号
+	var count int
+	if err := global.DB.Model(&database.User{}).
+		Where("academic_email = ? AND uuid <> ?", email, userUUID).
+		Count(&count).Error; err != nil {
+		return errors.New("查询认证信息失败")
+	}
+	if count > 0 {
+		return errAcademicEmailTaken
+	}
+
+	// 限制发送频率, 防止刷邮件
+	var last database.VerifyCode
+	if err := global.DB.Where("user_uuid = ? AND purpose = ?", userUUID, database.VerifyPurposeAcademic).
+		Order("gen_time desc").First(&last).Error; err == nil && time.Since(last.GenTime) < academicSendInterval {
+		return errors.New("发送过于频繁, 请稍后再试")
+	}
+	var sent int
+	global.DB.Model(&database.VerifyCode{}).
+		Where("user_uuid = ? AND purpose = ? AND gen_time > ?", userUUID, database.VerifyPurposeAcademic, time.Now().Add(-time.Hour)).
+		Count(&sent)
+	if sent >= academicSendHourlyLimit {
+		return errors.New("发送次数过多, 请一小时后再试")
+	}
+
+	code, err := generateVerifyCode()
+	if err != nil {
+		return errors.New("生成验证码失败")
+	}
+	// 只有最新的验证码有效; 旧验证码保留到过期, 用于统计发送次数
+	rec := database.VerifyCode{
+		Code:     code,
+		Email:    email,
+		GenTime:  time.Now(),
+		Purpose:  database.VerifyPurposeAcademic,
+		UserUUID: userUUID,
+	}
+	if err := global.DB.Create(&rec).Error; err != nil {
+		return errors.New("验证码存储失败")
+	}
+
+	body := "Hi,\n\n"
+	body += "Your OpenHouse academic verification code is: " + code + "\n\n"
+	body += "This code will expire in 10min. Please enter it in the application to verify your institution.\n\n"
+	body += "If you did not request this code, please ignore this email.\n\n"
+	if err := SendMail([]string{email}, "Verify your academic email on OpenHouse", body); err != nil {
+		log.Println("[Verify] 发送学术认证邮件失败:", email, err)
+		return errors.New("发送邮件失败")
+	}
+	return nil
+}
+
+// ConfirmAcademicVerify 校验学术认证验证码, 成功后标记用户为已认证并记录机构名称
+func ConfirmAcademicVerify(userUUID string, email string, code string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	// 只认本账号为该邮箱申请的最新一条验证码
+	var rec database.VerifyCode
+	if err := global.DB.
+		Where("user_uuid = ? AND email = ? AND purpose = ?", userUUID, email, database.VerifyPurposeAcademic).
+		Order("gen_time desc").
+		First(&rec).Error; err != nil {
+		return "", errors.New("验证码错误")
+	}
+	if time.Since(rec.GenTime) > academicCodeTTL {
+		return "", errors.New("验证码已过期, 请重新获取")
+	}
+	// 先占用一次尝试机会再比对, 并发提交也不会超过次数上限; VerifyCode 没有主键, 按 (用户, 验证码, 生成时间) 定位
+	res := global.DB.Model(&database.VerifyCode{}).
+		Where("user_uuid = ? AND purpose = ? AND code = ? AND gen_time = ? AND attempts < ?",
+			userUUID, database.VerifyPurposeAcademic, rec.Code, rec.GenTime, academicCodeMaxAttempts).
+		UpdateColumn("attempts", jgorm.Expr("attempts + 1"))
+	if res.Error != nil {
+		return "", errors.New("校验验证码失败")
+	}
+	if res.RowsAffected == 0 {
+		return "", errors.New("验证码错误次数过多, 请重新获取")
+	}
+	if subtle.ConstantTimeCompare([]byte(rec.Code), []byte(code)) != 1 {
+		return "", errors.New("验证码错误")
+	}
+
+	school := swot.GetSchoolName(email)
+	if school == "" {
+		return "", errors.New("该邮箱不属于学术机构")
+	}
+
+	now := time.Now()
+	err := global.DB.Transaction(func(tx *jgorm.DB) error {
+		// 发送时检查过邮箱是否被占用, 确认时再查一次; 两个账号同时认证同一个邮箱时由唯一索引兜底
+		var taken int
+		if err := tx.Model(&database.User{}).
+			Where("academic_email = ? AND uuid <> ?", email, userUUID).
+			Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return errAcademicEmailTaken
+		}
+		verified := true
+		if err := updateProfileSystem(tx, userUUID, ProfileSystemUpdate{
+			IsVerified:    &verified,
+			Institution:   &school,
+			AcademicEmail: &email,
+			VerifiedAt:    &now,
+		}); err != nil {
+			return err
+		}
+		// 验证码用后即删
+		return tx.Where("user_uuid = ? AND purpose = ?", userUUID, database.VerifyPurposeAcademic).
+			Delete(&database.VerifyCode{}).Error
+	})
+	if err == errAcademicEmailTaken || isDuplicateKey(err) {
+		return "", errAcademicEmailTaken
+	}
+	if err != nil {
+		return "", errors.New("更新认证状态失败")
+	}
+	return school, nil
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"OpenHouse/model/response"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	jgorm "github.com/jinzhu/gorm"
+)
+
+const (
+	viewDedupWindow       = 30 * time.Minute // 同一访客在该时间内重复打开同一帖子只算一次
+	defaultViewStatsDays  = 30
+	recentViewsLimit      = 100000 // 去重缓存的条数上限, 超出后不再缓存, 只靠数据库去重
+	postViewRetentionDays = 90     // 已汇总的浏览记录保留天数, 不小于统计接口可查询的最长天数
+	postViewPruneBatch    = 5000
+)
+
+// recentViews 进程内的去重缓存, 避免并发请求同时写入; 重启或缓存已满时由数据库中的记录兜底
+var recentViews = struct {
+	sync.Mutex
+	seen map[string]time.Time
+}{seen: make(map[string]time.Time)}
+
+// recordPostViewAsync 异步记录一次浏览, 不影响详情接口的耗时; 作者自己浏览不计数
+func recordPostViewAsync(post database.Post, viewerKey string) {
+	if viewerKey == "" || viewerKey == post.AuthorUUID {
+		return
+	}
+	go func() {
+		if err := recordPostView(post.ID, viewerKey, time.Now()); err != nil {
+			log.Println("[View] 记录浏览失败:", post.ID, err)
+		}
+	}()
+}
+
+func recordPostView(postID uint, viewerKey string, at time.Time) error {
+	key := fmt.Sprintf("%d:%s", postID, viewerKey)
+	recentViews.Lock()
+	if last, ok := recentViews.seen[key]; ok && at.Sub(last) < viewDedupWindow {
+		recentViews.Unlock()
+		return nil
+	}
+	if len(recentViews.seen) >= recentViewsLimit {
+		pruneRecentViewsLocked(at)
+	}
+	if len(recentViews.seen) < recentViewsLimit {
+		recentViews.seen[key] = at
+	}
+	recentViews.Unlock()
+
+	var n int64
+	if err := global.DB.Model(&database.PostView{}).
+		Where("post_id = ? AND viewer_key = ? AND created_at > ?", postID, viewerKey, at.Add(-viewDedupWindow)).
+		Count(&n).Error; err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	return global.DB.Create(&database.PostView{PostID: postID, ViewerKey: viewerKey, CreatedAt: at}).Error
+}
+
+// AggregatePostViews 把尚未汇总的浏览记录累加到帖子浏览数, 由定时任务调用
+func AggregatePostViews() error {
+	pruneRecentViews(time.Now())
+
+	var last []uint
+	if err := global.DB.Model(&database.PostView{}).Where("counted = ?", false).
+		Order("id desc").Limit(1).Pluck("id", &last).Error; err != nil {
+		return err
+	}
+	if len(last) == 0 {
+		return nil
+	}
+	maxID := last[0]
+
+	return global.DB.Transaction(func(tx *jgorm.DB) error {
+		var rows []struct {
+			PostID uint
+			Views  int
+		}
+		if err := tx.Raw("SELECT post_id, COUNT(*) AS views FROM post_views WHERE counted = ? AND id <= ? GROUP BY post_id",
+			false, maxID).Scan(&rows).Error; err != nil {
+			return err
+		}
+		for _, r := range rows {
+			if err := tx.Model(&database.Post{}).Where("id = ?", r.PostID).
+				UpdateColumn("view_number", jgorm.Expr("view_number + ?", r.Views)).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&database.PostView{}).Where("counted = ? AND id <= ?", false, maxID).
+			UpdateColumn("counted", true).Error
+	})
+}
+
+// pruneRecentViews 清理已过去重窗口的缓存
+func pruneRecentViews(now time.Time) {
+	recentViews.Lock()
+	defer recentViews.Unlock()
+	pruneRecentViewsLocked(now)
+}
+
+func pruneRecentViewsLocked(now time.Time) {
+	for key, at := range recentViews.seen {
+		if now.Sub(at) >= viewDedupWindow {
+			delete(recentViews.seen, key)
+		}
+	}
+}
+
+// PrunePostViews 删除超过保留期且已汇总到浏览数的浏览记录, 由定时任务每天调用
+// 分批删除, 避免长时间锁表; 每日统计已汇总到 post_daily_stats, 不受影响
+func PrunePostViews() (int64, error) {
+	before := time.Now().AddDate(0, 0, -postViewRetentionDays)
+	var deleted int64
+	for {
+		res := global.DB.Exec("DELETE FROM post_views WHERE counted = ? AND created_at < ? LIMIT ?",
+			true, before, postViewPruneBatch)
+		if res.Error != nil {
+			return deleted, res.Error
+		}
+		deleted += res.RowsAffected
+		if res.RowsAffected < postViewPruneBatch {
+			return deleted, nil
+		}
+	}
+}
+
+// GetPostViewStats 作者查看帖子的浏览统计: 累计浏览数、最近 days 天的独立访客数和每日浏览
+func GetPostViewStats(postID uint, authorUUID string, days int) (response.PostViewStats, error) {
+	var post database.Post
+	if err := global.DB.First(&post, postID).Error; err != nil {
+		return response.PostViewStats{}, errors.New("帖子不存在")
+	}
+	if post.AuthorUUID != authorUUID {
+		return response.PostViewStats{}, errors.New("只有作者可以查看浏览统计")
+	}
+	if days <= 0 {
+		days = defaultViewStatsDays
+	}
+
+	today := time.Now()
+	start := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location()).AddDate(0, 0, -(days - 1))
+
+	// 浏览记录只保留 postViewRetentionDays 天, 独立访客数只统计与 daily 相同的区间, 不会随清理变少
+	stats := response.PostViewStats{PostID: post.ID, TotalViews: post.ViewNumber}
+	if err := global.DB.Model(&database.PostView{}).Where("post_id = ? AND created_at >= ?", post.ID, start).
+		Select("COUNT(DISTINCT viewer_key)").Row().Scan(&stats.RecentUniqueViewers); err != nil {
+		return response.PostViewStats{}, err
+	}
+	// 尚未汇总的浏览也算进累计数, 作者刷新统计页时能立即看到
+	var pending int
+	global.DB.Model(&database.PostView{}).Where("post_id = ? AND counted = ?", post.ID, false).Count(&pending)
+	stats.TotalViews += pending
+
+	var rows []response.DailyViews
+	if err := global.DB.Raw(`SELECT DATE_FORMAT(created_at, '%Y-%m-%d') AS date, COUNT(*) AS views, COUNT(DISTINCT viewer_key) AS viewers
+		FROM post_views WHERE post_id = ? AND created_at >= ?
+		GROUP BY date ORDER BY date`, post.ID, start).Scan(&rows).Error; err != nil {
+		return response.PostViewStats{}, err
+	}
+	byDate := make(map[string]response.DailyViews, len(rows))
+	for _, r := range rows {
+		byDate[r.Date] = r
+	}
+	stats.Daily = make([]response.DailyViews, 0, days)
+	for d := start; !d.After(today); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		day, ok := byDate[date]
+		if !ok {
+			day = response.DailyViews{Date: date}
+		}
+		stats.Daily = append(stats.Daily, day)
+	}
+	return stats, nil
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"OpenHouse/utils"
+	"errors"
+
+	jgorm "github.com/jinzhu/gorm"
+)
+
+// visibleTo 只查询 viewer 能看到的帖子, 与 published 一起用于所有面向读者的帖子查询
+func visibleTo(viewer string) func(db *jgorm.DB) *jgorm.DB {
+	cond, args := utils.VisiblePostCondition(viewer)
+	return func(db *jgorm.DB) *jgorm.DB {
+		return db.Where(cond, args...)
+	}
+}
+
+// visiblePost 查询 viewer 能看到的已发布帖子; 看不到和不存在返回同样的错误, 不暴露帖子是否存在
+func visiblePost(postID uint, viewer string) (database.Post, error) {
+	var post database.Post
+	if err := global.DB.Scopes(published, visibleTo(viewer)).First(&post, postID).Error; err != nil {
+		return post, errors.New("帖子不存在")
+	}
+	return post, nil
+}
+
+// normalizeVisibility 未指定可见范围时为公开
+func normalizeVisibility(visibility string) string {
+	if visibility == "" {
+		return database.PostVisibilityPublic
+	}
+	return visibility
+}
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// APIKeyPrefix 个人访问令牌前缀, 中间件据此区分 API Key 与 JWT
+const APIKeyPrefix = "oh_"
+
+// API Key 可授予的权限范围
+const (
+	ScopePostsRead    = "posts:read"    // 浏览帖子、评论
+	ScopePostsWrite   = "posts:write"   // 发帖、评论、点赞、收藏、上传图片
+	ScopeChatRead     = "chat:read"     // 读取私信
+	ScopeChatWrite    = "chat:write"    // 发送私信
+	ScopeProfileRead  = "profile:read"  // 读取自己的资料与关注关系
+	ScopeFollowsWrite = "follows:write" // 关注 / 取消关注
+	ScopeMatchRead    = "match:read"    // 查看匹配结果
+)
+
+// AllScopes 所有可授予的 API Key 权限范围
+var AllScopes = []string{
+	ScopePostsRead, ScopePostsWrite, ScopeChatRead, ScopeChatWrite,
+	ScopeProfileRead, ScopeFollowsWrite, ScopeMatchRead,
+}
+
+// routeScopes 允许 API Key 访问的接口及所需权限范围, 以 gin 注册的完整路径为键
+// 未列出的接口（修改资料、账号绑定、两步验证、API Key 管理、管理后台等）一律不允许 API Key 访问
+var routeScopes = map[string]string{
+	"/api/v1/posts/list":           ScopePostsRead,
+	"/api/v1/posts/detail":         ScopePostsRead,
+	"/api/v1/posts/mypostlist":     ScopePostsRead,
+	"/api/v1/posts/favorites_list": ScopePostsRead,
+	"/api/v1/posts/drafts":         ScopePostsRead,
+	"/api/v1/posts/revisions":      ScopePostsRead,
+	"/api/v1/posts/revisions/diff": ScopePostsRead,
+	"/api/v1/posts/download":       ScopePostsRead,
+	"/api/v1/posts/views/stats":    ScopePostsRead,
+	"/api/v1/user/following/posts": ScopePostsRead,
+	"/api/v1/user/analytics":       ScopePostsRead,
+	"/api/v1/notifications/list":   ScopePostsRead,
+	"/api/v1/comments/list":        ScopePostsRead,
+	"/api/v1/comments/replies":     ScopePostsRead,
+	"/api/v1/search":               ScopePostsRead,
+	"/api/v1/search/semantic":      ScopePostsRead,
+	"/api/v1/tags/detail":          ScopePostsRead,
+	"/api/v1/tags/posts":           ScopePostsRead,
+	"/api/v1/tags/trending":        ScopePostsRead,
+	"/api/v1/tags/following":       ScopePostsRead,
+	"/api/v1/public/posts":         ScopePostsRead,
+	"/api/v1/public/posts/detail":  ScopePostsRead,
+	"/api/v1/public/user/posts":    ScopePostsRead,
+	"/api/v1/public/comments":      ScopePostsRead,
+	"/api/v1/public/replies":       ScopePostsRead,
+
+	"/api/v1/posts/create":          ScopePostsWrite,
+	"/api/v1/posts/update":          ScopePostsWrite,
+	"/api/v1/posts/delete":          ScopePostsWrite,
+	"/api/v1/posts/draft/save":      ScopePostsWrite,
+	"/api/v1/posts/publish":         ScopePostsWrite,
+	"/api/v1/posts/schedule/cancel": ScopePostsWrite,
+	"/api/v1/posts/favorite":        ScopePostsWrite,
+	"/api/v1/posts/unfavorite":      ScopePostsWrite,
+	"/api/v1/posts/star":            ScopePostsWrite,
+	"/api/v1/posts/unstar":          ScopePostsWrite,
+	"/api/v1/posts/repost":          ScopePostsWrite,
+	"/api/v1/posts/unrepost":        ScopePostsWrite,
+	"/api/v1/posts/poll/vote":       ScopePostsWrite,
+	"/api/v1/comments/create":       ScopePostsWrite,
+	"/api/v1/comments/like":         ScopePostsWrite,
+	"/api/v1/comments/unlike":       ScopePostsWrite,
+	"/api/v1/notifications/read":    ScopePostsWrite,
+	"/api/v1/media/upload":          ScopePostsWrite,
+	"/api/v1/media/attachment":      ScopePostsWrite,
+
+	"/api/v1/chat/recent":  ScopeChatRead,
+	"/api/v1/chat/more":    ScopeChatRead,
+	"/api/v1/chat/poll":    ScopeChatRead,
+	"/api/v1/chat/history": ScopeChatRead,
+	"/api/v1/chat/send":    ScopeChatWrite,
+
+	"/api/v1/user/profile":       ScopeProfileRead, // 仅 GET, 见 RequiredScope
+	"/api/v1/user/following":     ScopeProfileRead,
+	"/api/v1/user/followers":     ScopeProfileRead,
+	"/api/v1/user/follow/count":  ScopeProfileRead,
+	"/api/v1/user/follow/status": ScopeProfileRead,
+
+	"/api/v1/user/follow":   ScopeFollowsWrite,
+	"/api/v1/user/unfollow": ScopeFollowsWrite,
+	"/api/v1/tags/follow":   ScopeFollowsWrite,
+	"/api/v1/tags/unfollow": ScopeFollowsWrite,
+
+	"/api/v1/match/today":   ScopeMatchRead,
+	"/api/v1/match/history": ScopeMatchRead,
+}
+
+// routeScopeMethods 同一路径注册了多个方法时, 只允许这里列出的方法
+var routeScopeMethods = map[string]string{
+	"/api/v1/user/profile": "GET",
+}
+
+// RequiredScope 返回 API Key 访问该接口所需的权限范围, ok 为 false 表示不允许 API Key 访问
+func RequiredScope(method, fullPath string) (scope string, ok bool) {
+	if m, limited := routeScopeMethods[fullPath]; limited && m != method {
+		return "", false
+	}
+	scope, ok = routeScopes[fullPath]
+	return scope, ok
+}
+
+// IsValidScope 判断权限范围是否存在
+func IsValidScope(scope string) bool {
+	for _, s := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope 判断 API Key 的权限范围（JSON 数组）是否包含 scope
+func HasScope(scopes []byte, scope string) bool {
+	for _, s := range ParseTags(scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HashAPIKey 计算 API Key 的存储哈希, 明文不落库
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(key)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const mb = 1 << 20
+
+// attachmentRule 每类附件的大小上限、允许的扩展名和内容类型（按文件头识别）
+type attachmentRule struct {
+	maxBytes int64
+	exts     []string
+	mimes    []string
+}
+
+var attachmentRules = map[string]attachmentRule{
+	database.AttachmentImage: {
+		maxBytes: 10 * mb,
+		exts:     []string{".jpg", ".jpeg", ".png", ".gif", ".webp"},
+		mimes:    []string{"image/jpeg", "image/png", "image/gif", "image/webp"},
+	},
+	database.AttachmentPDF: {
+		maxBytes: 30 * mb,
+		exts:     []string{".pdf"},
+		mimes:    []string{"application/pdf"},
+	},
+	database.AttachmentArchive: {
+		maxBytes: 100 * mb,
+		exts:     []string{".zip", ".gz", ".tgz", ".tar", ".7z", ".csv", ".tsv"},
+		// tar / 7z 识别为 octet-stream, CSV / TSV 识别为纯文本
+		mimes: []string{"application/zip", "application/x-gzip", "application/octet-stream", "text/plain"},
+	},
+}
+
+// CheckAttachment 校验上传的附件, head 为文件开头的若干字节, 返回识别出的内容类型
+func CheckAttachment(kind, filename string, size int64, head []byte) (string, error) {
+	rule, ok := attachmentRules[kind]
+	if !ok {
+		return "", errors.New("不支持的附件类型")
+	}
+	if size <= 0 {
+		return "", errors.New("文件为空")
+	}
+	if size > rule.maxBytes {
+		return "", fmt.Errorf("文件不能超过 %dMB", rule.maxBytes/mb)
+	}
+	ext := strings.ToLower(filepath.Ext(filename))
+	if strings.HasSuffix(strings.ToLower(filename), ".tar.gz") {
+		ext = ".tgz"
+	}
+	if !containsString(rule.exts, ext) {
+		return "", fmt.Errorf("仅支持 %s 格式", strings.Join(rule.exts, " "))
+	}
+	mime := http.DetectContentType(head)
+	if i := strings.IndexByte(mime, ';'); i >= 0 {
+		mime = mime[:i]
+	}
+	if !containsString(rule.mimes, mime) {
+		return "", errors.New("文件内容与格式不符")
+	}
+	return mime, nil
+}
+
+var (
+	pdfPagePattern  = regexp.MustCompile(`/Type\s*/Page[^s]`)
+	pdfCountPattern = regexp.MustCompile(`/Type\s*/Pages\b[^>]*?/Count\s+(\d+)|/Count\s+(\d+)[^>]*?/Type\s*/Pages\b`)
+)
+
+// PDFPageCount 粗略统计 PDF 页数: 优先取页面树根节点的 /Count, 否则数 /Type /Page 对象
+// 页面树放在压缩对象流中时无法识别, 返回 0
+func PDFPageCount(data []byte) int {
+	count := 0
+	for _, m := range pdfCountPattern.FindAllSubmatch(data, -1) {
+		for _, g := range m[1:] {
+			if n, err := strconv.Atoi(string(g)); err == nil && n > count {
+				count = n
+			}
+		}
+	}
+	if count > 0 {
+		return count
+	}
+	return len(pdfPagePattern.FindAll(data, -1))
+}
+
+// PDFThumbnail 用 pdftoppm 把 input 路径下 PDF 的第一页渲染为 PNG, 返回图片内容
+// 命令可通过 attachment.pdf_thumbnail_cmd 配置, 未安装时返回错误, 调用方跳过缩略图即可
+func PDFThumbnail(input string) ([]byte, error) {
+	cmd := global.VP.GetString("attachment.pdf_thumbnail_cmd")
+	if cmd == "" {
+		cmd = "pdftoppm"
+	}
+	bin, err := exec.LookPath(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "pdf-thumb-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	output := filepath.Join(dir, "thumb")
+	if out, err := exec.CommandContext(ctx, bin, "-png", "-f", "1", "-l", "1", "-scale-to", "480", "-singlefile", input, output).
+		CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return os.ReadFile(output + ".png")
+}
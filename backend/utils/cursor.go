@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// Cursor 列表游标, 记录上一页最后一条记录的排序键, 编码后对客户端不透明
+type Cursor struct {
+	Time  int64   `json:"t,omitempty"` // 时间排序键（UnixNano）
+	Value float64 `json:"v,omitempty"` // 数值排序键（点赞数、热度分）
+	ID    uint    `json:"i,omitempty"` // 同排序键时按 id 区分
+}
+
+// EncodeCursor 游标编码为 URL 安全的字符串
+func EncodeCursor(c Cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor 解析客户端传回的游标
+func DecodeCursor(s string) (Cursor, error) {
+	var c Cursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, errors.New("游标无效")
+	}
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return c, errors.New("游标无效")
+	}
+	return c, nil
+}
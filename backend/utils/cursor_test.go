@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cases := []struct {
+		name string
+		in   Cursor
+	}{
+		{"zero", Cursor{}},
+		{"time key", Cursor{Time: 1760000000123456789, ID: 42}},
+		{"value key", Cursor{Value: 12, ID: 7}},
+		{"fractional score", Cursor{Value: 0.1 + 0.2, ID: 1}},
+		{"tiny score", Cursor{Value: 1.234567890123e-9, ID: 3}},
+		{"large id", Cursor{ID: 1<<32 + 5}},
+	}
+	for _, c := range cases {
+		s := EncodeCursor(c.in)
+		if strings.ContainsAny(s, "+/=") {
+			t.Errorf("%s: EncodeCursor = %q, want URL-safe unpadded base64", c.name, s)
+		}
+		got, err := DecodeCursor(s)
+		if err != nil {
+			t.Errorf("%s: DecodeCursor(%q) error: %v", c.name, s, err)
+			continue
+		}
+		// 分数要原样往返, 否则按 (分数, id) 翻页会重复或漏掉
+		if got != c.in {
+			t.Errorf("%s: round trip = %+v, want %+v", c.name, got, c.in)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+	}{
+		{"not base64", "!!!"},
+		{"padded std base64", base64.StdEncoding.EncodeToString([]byte(`{"i":1}`))},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("hello"))},
+		{"wrong field type", base64.RawURLEncoding.EncodeToString([]byte(`{"i":"1"}`))},
+		{"negative id", base64.RawURLEncoding.EncodeToString([]byte(`{"i":-1}`))},
+	}
+	for _, c := range cases {
+		if _, err := DecodeCursor(c.in); err == nil {
+			t.Errorf("%s: DecodeCursor(%q) succeeded, want error", c.name, c.in)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package utils
+
+import "strings"
+
+// 差异类型
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// maxDiffCells 行级 LCS 表的规模上限, 超过后直接视为整体替换
+const maxDiffCells = 4000000
+
+// DiffLine 一行差异
+type DiffLine struct {
+	Op   string `json:"op"` // equal / insert / delete
+	Text string `json:"text"`
+}
+
+// DiffLines 按行比较两段文本（最长公共子序列）, 返回从 a 变为 b 的逐行差异
+func DiffLines(a, b string) []DiffLine {
+	la, lb := splitLines(a), splitLines(b)
+	n, m := len(la), len(lb)
+
+	if n*m > maxDiffCells {
+		result := make([]DiffLine, 0, n+m)
+		for _, l := range la {
+			result = append(result, DiffLine{Op: DiffDelete, Text: l})
+		}
+		for _, l := range lb {
+			result = append(result, DiffLine{Op: DiffInsert, Text: l})
+		}
+		return result
+	}
+
+	// lcs[i][j] = la[i:] 与 lb[j:] 的最长公共子序列长度
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if la[i] == lb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	result := make([]DiffLine, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case la[i] == lb[j]:
+			result = append(result, DiffLine{Op: DiffEqual, Text: la[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, DiffLine{Op: DiffDelete, Text: la[i]})
+			i++
+		default:
+			result = append(result, DiffLine{Op: DiffInsert, Text: lb[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		result = append(result, DiffLine{Op: DiffDelete, Text: la[i]})
+	}
+	for ; j < m; j++ {
+		result = append(result, DiffLine{Op: DiffInsert, Text: lb[j]})
+	}
+	return result
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
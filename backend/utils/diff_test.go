@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	eq := func(s string) DiffLine { return DiffLine{Op: DiffEqual, Text: s} }
+	ins := func(s string) DiffLine { return DiffLine{Op: DiffInsert, Text: s} }
+	del := func(s string) DiffLine { return DiffLine{Op: DiffDelete, Text: s} }
+
+	cases := []struct {
+		name string
+		a, b string
+		want []DiffLine
+	}{
+		{"both empty", "", "", []DiffLine{}},
+		{"identical", "a\nb", "a\nb", []DiffLine{eq("a"), eq("b")}},
+		{"from empty", "", "a\nb", []DiffLine{ins("a"), ins("b")}},
+		{"to empty", "a\nb", "", []DiffLine{del("a"), del("b")}},
+		{"line changed", "a\nb\nc", "a\nx\nc", []DiffLine{eq("a"), del("b"), ins("x"), eq("c")}},
+		{"line inserted", "a\nc", "a\nb\nc", []DiffLine{eq("a"), ins("b"), eq("c")}},
+		{"line deleted", "a\nb\nc", "a\nc", []DiffLine{eq("a"), del("b"), eq("c")}},
+		{"appended", "a", "a\nb", []DiffLine{eq("a"), ins("b")}},
+		{"crlf treated as lf", "a\r\nb", "a\nb", []DiffLine{eq("a"), eq("b")}},
+		{"trailing newline adds an empty line", "a", "a\n", []DiffLine{eq("a"), ins("")}},
+		{"moved line", "a\nb\nc", "b\nc\na", []DiffLine{del("a"), eq("b"), eq("c"), ins("a")}},
+	}
+	for _, c := range cases {
+		if got := DiffLines(c.a, c.b); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: DiffLines(%q, %q) = %v, want %v", c.name, c.a, c.b, got, c.want)
+		}
+	}
+}
+
+// 超过 LCS 表规模上限时整体替换: 先删除全部旧行, 再插入全部新行
+func TestDiffLinesTooLarge(t *testing.T) {
+	a := strings.Repeat("a\n", 2500) + "x"
+	b := strings.Repeat("b\n", 2500) + "x"
+	got := DiffLines(a, b)
+	if len(got) != 2*2501 {
+		t.Fatalf("len(DiffLines) = %d, want %d", len(got), 2*2501)
+	}
+	for i, l := range got {
+		want := DiffDelete
+		if i >= 2501 {
+			want = DiffInsert
+		}
+		if l.Op != want {
+			t.Fatalf("line %d op = %s, want %s", i, l.Op, want)
+		}
+	}
+}
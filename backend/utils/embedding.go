@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"OpenHouse/global"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Embedder 文本向量化接口, 同一个 Model() 产生的向量才能互相比较
+type Embedder interface {
+	Model() string
+	Embed(text string) ([]float32, error)
+}
+
+// NewEmbedder 根据配置选择向量化实现
+// embedding.provider = openai 时调用 OpenAI Embeddings 接口, 否则（或未配置 openai.api_key）使用本地哈希向量
+func NewEmbedder() Embedder {
+	provider := global.VP.GetString("embedding.provider")
+	apiKey := global.VP.GetString("openai.api_key")
+	if provider == "openai" && apiKey != "" {
+		model := global.VP.GetString("embedding.model")
+		if model == "" {
+			model = "text-embedding-3-small"
+		}
+		return &OpenAIEmbedder{APIKey: apiKey, ModelName: model}
+	}
+	return &LocalEmbedder{Dim: localEmbeddingDim}
+}
+
+// maxEmbeddingRunes 送去向量化的文本长度上限
+const maxEmbeddingRunes = 4000
+
+func truncateRunes(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) > n {
+		return string(runes[:n])
+	}
+	return text
+}
+
+// OpenAIEmbedder 调用 OpenAI Embeddings 接口
+type OpenAIEmbedder struct {
+	APIKey    string
+	ModelName string
+}
+
+func (e *OpenAIEmbedder) Model() string {
+	return "openai/" + e.ModelName
+}
+
+func (e *OpenAIEmbedder) Embed(text string) ([]float32, error) {
+	payload := map[string]interface{}{
+		"model": e.ModelName,
+		"input": truncateRunes(text, maxEmbeddingRunes),
+	}
+	bodyBytes, _ := json.Marshal(payload)
+	httpReq, _ := http.NewRequest("POST", "https://api.openai.com/v1/embeddings", bytes.NewBuffer(bodyBytes))
+	httpReq.Header.Set("Authorization", "Bearer "+e.APIKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		raw, _ := io.ReadAll(resp.Body)
+		return nil, errors.New("调用 Embedding 接口失败：" + string(raw))
+	}
+
+	var res struct {
+		Data []struct {
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+	if len(res.Data) == 0 || len(res.Data[0].Embedding) == 0 {
+		return nil, errors.New("Embedding 无返回结果")
+	}
+	return NormalizeVector(res.Data[0].Embedding), nil
+}
+
+// localEmbeddingDim 本地哈希向量维度
+const localEmbeddingDim = 512
+
+// LocalEmbedder 本地特征哈希向量: 英文按单词, 中日韩文字按相邻二字切分, 哈希到固定维度后归一化
+// 不理解语义, 但不依赖外部服务, 能找出用词相近的帖子
+type LocalEmbedder struct {
+	Dim int
+}
+
+func (e *LocalEmbedder) Model() string {
+	return "local/hash-512"
+}
+
+func (e *LocalEmbedder) Embed(text string) ([]float32, error) {
+	vec := make([]float32, e.Dim)
+	for _, token := range embeddingTokens(truncateRunes(text, maxEmbeddingRunes)) {
+		h := fnv.New32a()
+		h.Write([]byte(token))
+		sum := h.Sum32()
+		// 用哈希的最高位决定符号, 减少碰撞带来的偏差
+		sign := float32(1)
+		if sum&0x80000000 != 0 {
+			sign = -1
+		}
+		vec[int(sum%uint32(e.Dim))] += sign
+	}
+	return NormalizeVector(vec), nil
+}
+
+// embeddingTokens 切词: 连续的字母数字组成一个词, 中日韩文字取相邻二字
+func embeddingTokens(text string) []string {
+	var tokens []string
+	var word []rune
+	var cjk []rune
+	flushWord := func() {
+		if len(word) > 1 {
+			tokens = append(tokens, string(word))
+		}
+		word = word[:0]
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			tokens = append(tokens, string(cjk))
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			tokens = append(tokens, string(cjk[i:i+2]))
+		}
+		cjk = cjk[:0]
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+			unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
+
+// NormalizeVector L2 归一化, 归一化后余弦相似度等于点积
+func NormalizeVector(vec []float32) []float32 {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return vec
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range vec {
+		vec[i] /= norm
+	}
+	return vec
+}
+
+// CosineSimilarity 计算两个已归一化向量的相似度, 维度不同返回 0
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
+
+// EncodeVector / DecodeVector 向量与数据库 BLOB 之间的转换（float32 小端序）
+func EncodeVector(vec []float32) []byte {
+	buf := make([]byte, 4*len(vec))
+	for i, v := range vec {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return buf
+}
+
+func DecodeVector(buf []byte) []float32 {
+	vec := make([]float32, len(buf)/4)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return vec
+}
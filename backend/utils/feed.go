@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"time"
+)
+
+// 订阅源格式
+const (
+	FeedFormatAtom = "atom"
+	FeedFormatRSS  = "rss"
+	FeedFormatJSON = "json"
+)
+
+// Feed 与格式无关的订阅源, 由 RenderFeed 输出为 Atom / RSS 2.0 / JSON Feed 1.1
+type Feed struct {
+	Title       string
+	Description string
+	Link        string // 对应的网页地址
+	FeedURL     string // 订阅源自身的地址
+	Updated     time.Time
+	Items       []FeedItem
+}
+
+// FeedItem 订阅源中的一篇帖子, ContentHTML 为已过滤的 HTML
+type FeedItem struct {
+	ID          string
+	Title       string
+	Link        string
+	Summary     string
+	ContentHTML string
+	AuthorName  string
+	AuthorURL   string
+	Tags        []string
+	Published   time.Time
+	Updated     time.Time
+}
+
+// RenderFeed 按格式输出订阅源, 返回内容和 Content-Type
+func RenderFeed(feed Feed, format string) ([]byte, string, error) {
+	switch format {
+	case FeedFormatAtom, "":
+		body, err := renderAtom(feed)
+		return body, "application/atom+xml; charset=utf-8", err
+	case FeedFormatRSS:
+		body, err := renderRSS(feed)
+		return body, "application/rss+xml; charset=utf-8", err
+	case FeedFormatJSON:
+		body, err := renderJSONFeed(feed)
+		return body, "application/feed+json; charset=utf-8", err
+	default:
+		return nil, "", errors.New("不支持的订阅格式")
+	}
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr,omitempty"`
+	Body string `xml:",chardata"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Links      []atomLink     `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     atomPerson     `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Links    []atomLink  `xml:"link"`
+	Updated  string      `xml:"updated"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+func renderAtom(feed Feed) ([]byte, error) {
+	out := atomFeed{
+		ID:       feed.FeedURL,
+		Title:    feed.Title,
+		Subtitle: feed.Description,
+		Links: []atomLink{
+			{Href: feed.FeedURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: feed.Link, Rel: "alternate", Type: "text/html"},
+		},
+		Updated: feed.Updated.Format(time.RFC3339),
+		Entries: make([]atomEntry, 0, len(feed.Items)),
+	}
+	for _, item := range feed.Items {
+		entry := atomEntry{
+			ID:        item.ID,
+			Title:     item.Title,
+			Links:     []atomLink{{Href: item.Link, Rel: "alternate", Type: "text/html"}},
+			Published: item.Published.Format(time.RFC3339),
+			Updated:   item.Updated.Format(time.RFC3339),
+			Author:    atomPerson{Name: item.AuthorName, URI: item.AuthorURL},
+		}
+		for _, tag := range item.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		if item.Summary != "" {
+			entry.Summary = &atomText{Type: "text", Body: item.Summary}
+		}
+		if item.ContentHTML != "" {
+			entry.Content = &atomText{Type: "html", Body: item.ContentHTML}
+		}
+		out.Entries = append(out.Entries, entry)
+	}
+	return marshalXML(out)
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Creator     string   `xml:"dc:creator,omitempty"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Self          atomLink  `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	DCNS    string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+func renderRSS(feed Feed) ([]byte, error) {
+	out := rssFeed{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		DCNS:    "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         feed.Title,
+			Link:          feed.Link,
+			Description:   feed.Description,
+			Self:          atomLink{Href: feed.FeedURL, Rel: "self", Type: "application/rss+xml"},
+			LastBuildDate: feed.Updated.Format(time.RFC1123Z),
+			Items:         make([]rssItem, 0, len(feed.Items)),
+		},
+	}
+	for _, item := range feed.Items {
+		// RSS 的 description 放完整 HTML, 没有正文时退回摘要
+		description := item.ContentHTML
+		if description == "" {
+			description = item.Summary
+		}
+		out.Channel.Items = append(out.Channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        rssGUID{IsPermaLink: item.ID == item.Link, Value: item.ID},
+			PubDate:     item.Published.Format(time.RFC1123Z),
+			Creator:     item.AuthorName,
+			Categories:  item.Tags,
+			Description: description,
+		})
+	}
+	return marshalXML(out)
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html,omitempty"`
+	Summary       string           `json:"summary,omitempty"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Authors       []jsonFeedAuthor `json:"authors"`
+	Tags          []string         `json:"tags,omitempty"`
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+func renderJSONFeed(feed Feed) ([]byte, error) {
+	out := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       feed.Title,
+		HomePageURL: feed.Link,
+		FeedURL:     feed.FeedURL,
+		Description: feed.Description,
+		Items:       make([]jsonFeedItem, 0, len(feed.Items)),
+	}
+	for _, item := range feed.Items {
+		out.Items = append(out.Items, jsonFeedItem{
+			ID:            item.ID,
+			URL:           item.Link,
+			Title:         item.Title,
+			ContentHTML:   item.ContentHTML,
+			Summary:       item.Summary,
+			DatePublished: item.Published.Format(time.RFC3339),
+			DateModified:  item.Updated.Format(time.RFC3339),
+			Authors:       []jsonFeedAuthor{{Name: item.AuthorName, URL: item.AuthorURL}},
+			Tags:          item.Tags,
+		})
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+)
+
+// ExcerptRunes 列表页摘要的最大字数
+const ExcerptRunes = 160
+
+// markdown 帖子正文渲染器: GFM（表格、删除线、任务列表、自动链接）+ LaTeX 公式 + 围栏代码块
+// 不开启 html.WithUnsafe, 正文里的原始 HTML 不会输出
+var markdown = goldmark.New(
+	goldmark.WithExtensions(
+		extension.NewTable(extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute)),
+		extension.Strikethrough,
+		extension.TaskList,
+		extension.Linkify,
+		&mathExtension{},
+	),
+	goldmark.WithRendererOptions(html.WithHardWraps()),
+)
+
+// RenderMarkdown 把帖子正文渲染为 HTML 并按白名单过滤
+// 代码块输出为 <pre><code class="language-xx">, 公式输出为带 math class 的 \( \) / \[ \], 由前端负责高亮和排版
+func RenderMarkdown(content string) string {
+	html, _ := RenderContent(content)
+	return html
+}
+
+// renderCacheSize 渲染结果缓存的条数上限, 列表页反复展示的帖子不必每次重新解析
+const renderCacheSize = 4096
+
+type renderedContent struct {
+	html    string
+	excerpt string
+}
+
+// renderCache 以正文的哈希为键, 正文修改后哈希变化, 不需要主动失效
+var renderCache = struct {
+	sync.Mutex
+	entries map[[sha256.Size]byte]renderedContent
+}{entries: make(map[[sha256.Size]byte]renderedContent)}
+
+// RenderContent 一次解析同时得到渲染后的 HTML 和纯文本摘要, 结果按正文缓存
+func RenderContent(content string) (string, string) {
+	if strings.TrimSpace(content) == "" {
+		return "", ""
+	}
+	key := sha256.Sum256([]byte(content))
+	renderCache.Lock()
+	r, ok := renderCache.entries[key]
+	renderCache.Unlock()
+	if ok {
+		return r.html, r.excerpt
+	}
+
+	r.html, r.excerpt = renderContent(content)
+	renderCache.Lock()
+	if len(renderCache.entries) >= renderCacheSize {
+		// 缓存已满时随机淘汰一条
+		for k := range renderCache.entries {
+			delete(renderCache.entries, k)
+			break
+		}
+	}
+	renderCache.entries[key] = r
+	renderCache.Unlock()
+	return r.html, r.excerpt
+}
+
+func renderContent(content string) (string, string) {
+	source := []byte(content)
+	doc := markdown.Parser().Parse(text.NewReader(source))
+
+	var buf bytes.Buffer
+	if err := markdown.Renderer().Render(&buf, source, doc); err != nil {
+		return "<p>" + escapeText(content) + "</p>", Excerpt(content, ExcerptRunes)
+	}
+	return SanitizeHTML(buf.String()), Excerpt(plainText(doc, source), ExcerptRunes)
+}
+
+// Excerpt 合并空白后截取前 maxRunes 个字符, 截断时以省略号结尾
+func Excerpt(s string, maxRunes int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= maxRunes {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:maxRunes])) + "…"
+}
+
+// plainText 提取文档中的文字, 块与块之间以空格分隔; 代码块不进入摘要, 公式保留 TeX 源码
+func plainText(doc ast.Node, source []byte) string {
+	var sb strings.Builder
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			if n.Type() == ast.TypeBlock {
+				sb.WriteByte(' ')
+			}
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.FencedCodeBlock, *ast.CodeBlock, *ast.HTMLBlock, *ast.RawHTML, *ast.Image:
+			return ast.WalkSkipChildren, nil
+		case *mathInline:
+			sb.Write(node.Segment.Value(source))
+			return ast.WalkSkipChildren, nil
+		case *mathBlock:
+			sb.Write(mathSource(node, source))
+			return ast.WalkSkipChildren, nil
+		case *ast.AutoLink:
+			sb.Write(node.Label(source))
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			sb.Write(node.Segment.Value(source))
+			if node.SoftLineBreak() || node.HardLineBreak() {
+				sb.WriteByte(' ')
+			}
+		case *ast.String:
+			sb.Write(node.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return sb.String()
+}
+
+func escapeText(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&#39;").Replace(s)
+}
@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// LaTeX 公式: 行内 $...$, 独占一段的 $$...$$
+// 服务端只负责识别并原样输出 TeX 源码, 由前端 KaTeX / MathJax 排版
+
+var (
+	kindMathInline = ast.NewNodeKind("MathInline")
+	kindMathBlock  = ast.NewNodeKind("MathBlock")
+)
+
+// mathInline 行内公式, TeX 源码保存在 Segment 中
+type mathInline struct {
+	ast.BaseInline
+	Segment text.Segment
+	Display bool // $$...$$ 写在段落中间
+}
+
+func (n *mathInline) Kind() ast.NodeKind { return kindMathInline }
+
+func (n *mathInline) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"TeX": string(n.Segment.Value(source))}, nil)
+}
+
+// mathBlock 公式块, TeX 源码保存在 Lines 中
+type mathBlock struct {
+	ast.BaseBlock
+	closed bool // 单行公式块在开始行就已结束
+}
+
+func (n *mathBlock) Kind() ast.NodeKind { return kindMathBlock }
+
+func (n *mathBlock) IsRaw() bool { return true }
+
+func (n *mathBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+type mathInlineParser struct{}
+
+func (p *mathInlineParser) Trigger() []byte { return []byte{'$'} }
+
+func (p *mathInlineParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, segment := block.PeekLine()
+	opener := 1
+	if len(line) > 1 && line[1] == '$' {
+		opener = 2
+	}
+	body := line[opener:]
+	closer := bytes.Index(body, line[:opener])
+	if closer <= 0 {
+		return nil
+	}
+	tex := body[:closer]
+	// 单个 $ 按 Pandoc 规则: 内侧不能是空白, 结尾 $ 后不能紧跟数字, 避免把 "$5 和 $10" 当成公式
+	if opener == 1 {
+		if isSpaceByte(tex[0]) || isSpaceByte(tex[len(tex)-1]) {
+			return nil
+		}
+		if next := opener + closer + 1; next < len(line) && line[next] >= '0' && line[next] <= '9' {
+			return nil
+		}
+	}
+
+	start := segment.Start + opener
+	node := &mathInline{Segment: text.NewSegment(start, start+closer), Display: opener == 2}
+	block.Advance(opener*2 + closer)
+	return node
+}
+
+type mathBlockParser struct{}
+
+func (p *mathBlockParser) Trigger() []byte { return []byte{'$'} }
+
+func (p *mathBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.HasPrefix(line[pos:], []byte("$$")) {
+		return nil, parser.NoChildren
+	}
+	node := &mathBlock{}
+	rest := bytes.TrimSpace(line[pos+2:])
+	if len(rest) == 0 {
+		reader.Advance(segment.Len() - 1)
+		return node, parser.NoChildren
+	}
+	// $$ 与公式写在同一行, 如 "$$ E = mc^2 $$"
+	start := segment.Start + pos + 2
+	stop := segment.Stop
+	if bytes.HasSuffix(rest, []byte("$$")) {
+		stop = start + bytes.LastIndex(line[pos+2:], []byte("$$"))
+		node.Lines().Append(text.NewSegment(start, stop))
+		node.closed = true
+		reader.Advance(segment.Len() - 1)
+		return node, parser.NoChildren
+	}
+	node.Lines().Append(text.NewSegment(start, stop))
+	reader.Advance(segment.Len() - 1)
+	return node, parser.NoChildren
+}
+
+func (p *mathBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	line, segment := reader.PeekLine()
+	if line == nil || node.(*mathBlock).closed {
+		return parser.Close
+	}
+	trimmed := bytes.TrimSpace(line)
+	if bytes.HasSuffix(trimmed, []byte("$$")) {
+		if idx := bytes.LastIndex(line, []byte("$$")); idx > 0 {
+			node.Lines().Append(text.NewSegment(segment.Start, segment.Start+idx))
+		}
+		reader.Advance(segment.Len())
+		return parser.Close
+	}
+	node.Lines().Append(segment)
+	reader.Advance(segment.Len() - 1)
+	return parser.Continue | parser.NoChildren
+}
+
+func (p *mathBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (p *mathBlockParser) CanInterruptParagraph() bool { return true }
+
+func (p *mathBlockParser) CanAcceptIndentedLine() bool { return false }
+
+type mathRenderer struct{}
+
+func (r *mathRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindMathInline, r.renderInline)
+	reg.Register(kindMathBlock, r.renderBlock)
+}
+
+func (r *mathRenderer) renderInline(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*mathInline)
+	if n.Display {
+		_, _ = w.WriteString(`<span class="math math-display">\[`)
+		_, _ = w.Write(util.EscapeHTML(n.Segment.Value(source)))
+		_, _ = w.WriteString(`\]</span>`)
+	} else {
+		_, _ = w.WriteString(`<span class="math math-inline">\(`)
+		_, _ = w.Write(util.EscapeHTML(n.Segment.Value(source)))
+		_, _ = w.WriteString(`\)</span>`)
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *mathRenderer) renderBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	_, _ = w.WriteString(`<div class="math math-display">\[`)
+	_, _ = w.Write(util.EscapeHTML(mathSource(node, source)))
+	_, _ = w.WriteString("\\]</div>\n")
+	return ast.WalkSkipChildren, nil
+}
+
+// mathSource 公式块的 TeX 源码
+func mathSource(node ast.Node, source []byte) []byte {
+	var buf bytes.Buffer
+	lines := node.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		buf.Write(seg.Value(source))
+	}
+	return bytes.TrimSpace(buf.Bytes())
+}
+
+type mathExtension struct{}
+
+func (e *mathExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(util.Prioritized(&mathBlockParser{}, 150)),
+		parser.WithInlineParsers(util.Prioritized(&mathInlineParser{}, 150)),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(&mathRenderer{}, 150)))
+}
+
+func isSpaceByte(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+	"OpenHouse/model/response"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxMentions 一段正文最多提及的用户数, 超出的部分忽略
+const MaxMentions = 20
+
+// mentionPattern 正文中的 @用户名: @ 前不能紧跟字母数字或 .、/（排除邮箱地址和网址）
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_./@])@([\p{L}\p{N}_][\p{L}\p{N}_.\-]*)`)
+
+// ExtractMentions 提取正文中 @ 的用户名, 按出现顺序返回, 大小写不同的写法只保留第一次出现的
+// 句末的 . 和 - 不算作用户名的一部分; 长度不在 2 到 32 之间的不可能是用户名, 直接跳过
+func ExtractMentions(text string) []string {
+	matches := mentionPattern.FindAllStringSubmatch(text, -1)
+	seen := make(map[string]bool, len(matches))
+	names := make([]string, 0, len(matches))
+	for _, m := range matches {
+		name := strings.TrimRight(m[1], ".-")
+		if n := utf8.RuneCountInString(name); n < 2 || n > 32 {
+			continue
+		}
+		key := strings.ToLower(name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, name)
+		if len(names) == MaxMentions {
+			break
+		}
+	}
+	return names
+}
+
+// MentionEntities 帖子正文（commentID 为 0）或某条评论中的提及, 附带被提及用户的当前资料
+func MentionEntities(postID, commentID uint) []response.MentionEntity {
+	var mentions []database.Mention
+	global.DB.Where("post_id = ? AND comment_id = ?", postID, commentID).Order("id").Find(&mentions)
+	if len(mentions) == 0 {
+		return []response.MentionEntity{}
+	}
+
+	uuids := make([]string, 0, len(mentions))
+	for _, m := range mentions {
+		uuids = append(uuids, m.UserUUID)
+	}
+	var users []database.User
+	global.DB.Where("uuid IN (?)", uuids).Find(&users)
+	userMap := make(map[string]database.User, len(users))
+	for _, u := range users {
+		userMap[u.UUID] = u
+	}
+	return mentionEntities(mentions, userMap)
+}
+
+// mentionEntities 用已查出的用户转换提及记录, 找不到的用户（已注销）跳过
+func mentionEntities(mentions []database.Mention, userMap map[string]database.User) []response.MentionEntity {
+	result := make([]response.MentionEntity, 0, len(mentions))
+	for _, m := range mentions {
+		u, ok := userMap[m.UserUUID]
+		if !ok {
+			continue
+		}
+		result = append(result, response.MentionEntity{
+			UserUUID:  u.UUID,
+			Username:  u.Username,
+			AvatarURL: u.AvatarURL,
+			Text:      m.Text,
+		})
+	}
+	return result
+}
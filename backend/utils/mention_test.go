@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractMentions(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"plain", "hi @alice", []string{"alice"}},
+		{"start of text", "@alice hi", []string{"alice"}},
+		{"in parentheses", "(@carol), @dave!", []string{"carol", "dave"}},
+		{"dots and hyphens inside kept", "@john.doe @mary-jane", []string{"john.doe", "mary-jane"}},
+		{"trailing dot dropped", "thanks @bob.", []string{"bob"}},
+		{"trailing hyphens and dots dropped", "@bob-.-", []string{"bob"}},
+		{"unicode", "你好 @张三", []string{"张三"}},
+		{"case-insensitive dedupe keeps first spelling", "@Alice and @alice and @ALICE", []string{"Alice"}},
+		{"email address", "mail a@example.com", []string{}},
+		{"url path", "see https://x.com/@alice and site.com/@bob", []string{}},
+		{"after a dot", "end.@carol", []string{}},
+		{"double at", "@@dave", []string{}},
+		{"too short", "@a", []string{}},
+		{"too short after trimming", "@a.", []string{}},
+		{"32 runes allowed", "@" + strings.Repeat("x", 32), []string{strings.Repeat("x", 32)}},
+		{"33 runes skipped", "@" + strings.Repeat("x", 33), []string{}},
+		{"bare at", "@ nobody", []string{}},
+	}
+	for _, c := range cases {
+		if got := ExtractMentions(c.in); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: ExtractMentions(%q) = %q, want %q", c.name, c.in, got, c.want)
+		}
+	}
+}
+
+func TestExtractMentionsCap(t *testing.T) {
+	var parts, want []string
+	for i := 0; i < MaxMentions+5; i++ {
+		name := fmt.Sprintf("user%02d", i)
+		parts = append(parts, "@"+name)
+		if i < MaxMentions {
+			want = append(want, name)
+		}
+	}
+	// 重复的名字不占名额
+	text := "@user00 " + strings.Join(parts, " ")
+	if got := ExtractMentions(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractMentions with %d names = %q, want first %d: %q", len(parts), got, MaxMentions, want)
+	}
+}
@@ -2,9 +2,12 @@ package utils
 
 import (
 	"OpenHouse/global"
+	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
@@ -12,12 +15,7 @@ import (
 
 // UploadToOSS 上传文件到 OSS 并返回 URL
 func UploadToOSS(reader io.Reader, filename string) (string, error) {
-	client, err := oss.New(global.OSSConfig.Endpoint, global.OSSConfig.AccessKeyID, global.OSSConfig.AccessKeySecret)
-	if err != nil {
-		return "", err
-	}
-
-	bucket, err := client.Bucket(global.OSSConfig.Bucket)
+	bucket, err := ossBucket()
 	if err != nil {
 		return "", err
 	}
@@ -30,6 +28,50 @@ func UploadToOSS(reader io.Reader, filename string) (string, error) {
 		return "", err
 	}
 
-	url := fmt.Sprintf("https://%s.%s/%s", global.OSSConfig.Bucket, global.OSSConfig.Endpoint, objectKey)
+	url := ossURLPrefix() + objectKey
 	return url, nil
 }
+
+func ossBucket() (*oss.Bucket, error) {
+	client, err := oss.New(global.OSSConfig.Endpoint, global.OSSConfig.AccessKeyID, global.OSSConfig.AccessKeySecret)
+	if err != nil {
+		return nil, err
+	}
+	return client.Bucket(global.OSSConfig.Bucket)
+}
+
+// ossURLPrefix UploadToOSS 返回的地址中对象名之前的部分
+func ossURLPrefix() string {
+	return fmt.Sprintf("https://%s.%s/", global.OSSConfig.Bucket, global.OSSConfig.Endpoint)
+}
+
+// SignOSSURL 为 UploadToOSS 返回的地址生成限时访问的签名地址
+func SignOSSURL(raw string, ttl time.Duration) (string, error) {
+	objectKey := strings.TrimPrefix(raw, ossURLPrefix())
+	if objectKey == raw || objectKey == "" {
+		return "", errors.New("不是本站上传的文件")
+	}
+	bucket, err := ossBucket()
+	if err != nil {
+		return "", err
+	}
+	return bucket.SignURL(objectKey, oss.HTTPGet, int64(ttl/time.Second))
+}
+
+// IsOwnMediaURL 判断 URL 是否来自本站的媒体域名（OSS bucket 域名或 media.allowed_hosts 中配置的 CDN 域名）
+func IsOwnMediaURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return false
+	}
+	host := strings.ToLower(u.Host)
+	if global.OSSConfig.Bucket != "" && host == strings.ToLower(global.OSSConfig.Bucket+"."+global.OSSConfig.Endpoint) {
+		return true
+	}
+	for _, allowed := range global.VP.GetStringSlice("media.allowed_hosts") {
+		if host == strings.ToLower(allowed) {
+			return true
+		}
+	}
+	return false
+}
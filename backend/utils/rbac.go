@@ -0,0 +1,96 @@
+package utils
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// 权限点
+const (
+	PermUserRead        = "user:read"        // 查询用户
+	PermUserBan         = "user:ban"         // 封禁 / 解封用户
+	PermUserRole        = "user:role"        // 修改用户角色与权限
+	PermMatchTrigger    = "match:trigger"    // 手动触发全量匹配
+	PermContentModerate = "content:moderate" // 下架帖子 / 评论
+	PermConfigRead      = "config:read"      // 查看运行配置
+	PermSearchReindex   = "search:reindex"   // 重建搜索索引
+)
+
+// rolePermissions 各角色默认拥有的权限, "*" 表示全部权限
+var rolePermissions = map[string][]string{
+	RoleUser:      {},
+	RoleModerator: {PermUserRead, PermUserBan, PermContentModerate},
+	RoleAdmin:     {"*"},
+}
+
+// AllPermissions 所有可授予的权限点
+var AllPermissions = []string{
+	PermUserRead, PermUserBan, PermUserRole, PermMatchTrigger, PermContentModerate, PermConfigRead,
+	PermSearchReindex,
+}
+
+// IsValidRole 判断角色是否存在
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// IsValidPermission 判断权限点是否存在
+func IsValidPermission(perm string) bool {
+	for _, p := range AllPermissions {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission 判断角色 + 额外授予的权限（JSON 数组）是否包含 perm
+func HasPermission(role string, extra []byte, perm string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == "*" || p == perm {
+			return true
+		}
+	}
+	for _, p := range ParseTags(extra) {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// CanGrant 判断操作人能否把 role + 额外权限 perms 授予别人
+// 只有管理员能授予管理员角色; 其他人只能授予自己已有的权限, 包括目标角色自带的权限, 防止给自己控制的另一个账号提权
+func CanGrant(actorRole string, actorExtra []byte, role string, perms []string) bool {
+	if actorRole == RoleAdmin {
+		return true
+	}
+	if role == RoleAdmin {
+		return false
+	}
+	for _, p := range append(append([]string{}, rolePermissions[role]...), perms...) {
+		if p == "*" || !HasPermission(actorRole, actorExtra, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// CanManage 判断操作人能否封禁、解封或修改目标账号的角色
+// 管理员可以操作任何人; 其他人必须拥有目标账号的全部权限, 防止版主互相封禁或撤掉自己没有的权限
+func CanManage(actorRole string, actorExtra []byte, targetRole string, targetExtra []byte) bool {
+	if actorRole == RoleAdmin {
+		return true
+	}
+	if targetRole == RoleAdmin {
+		return false
+	}
+	for _, p := range append(append([]string{}, rolePermissions[targetRole]...), ParseTags(targetExtra)...) {
+		if p == "*" || !HasPermission(actorRole, actorExtra, p) {
+			return false
+		}
+	}
+	return true
+}
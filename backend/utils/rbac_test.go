@@ -0,0 +1,58 @@
+package utils
+
+import "testing"
+
+func TestCanGrant(t *testing.T) {
+	roleGranter := []byte(`["user:role"]`)
+	cases := []struct {
+		name      string
+		actorRole string
+		actorPerm []byte
+		role      string
+		perms     []string
+		want      bool
+	}{
+		{"admin grants admin", RoleAdmin, nil, RoleAdmin, nil, true},
+		{"admin grants any permission", RoleAdmin, nil, RoleUser, []string{PermUserRole, PermConfigRead}, true},
+		{"non-admin cannot grant admin role", RoleUser, roleGranter, RoleAdmin, nil, false},
+		{"moderator cannot grant admin role", RoleModerator, roleGranter, RoleAdmin, nil, false},
+		{"non-admin cannot grant permission it lacks", RoleUser, roleGranter, RoleUser, []string{PermConfigRead}, false},
+		{"non-admin re-granting user:role is allowed only because it holds it", RoleUser, roleGranter, RoleUser, []string{PermUserRole}, true},
+		{"non-admin cannot grant moderator role without its permissions", RoleUser, roleGranter, RoleModerator, nil, false},
+		{"moderator with user:role can grant moderator", RoleModerator, roleGranter, RoleModerator, nil, true},
+		{"moderator cannot add search reindex", RoleModerator, roleGranter, RoleModerator, []string{PermSearchReindex}, false},
+		{"demoting to plain user needs nothing extra", RoleUser, roleGranter, RoleUser, nil, true},
+	}
+	for _, c := range cases {
+		if got := CanGrant(c.actorRole, c.actorPerm, c.role, c.perms); got != c.want {
+			t.Errorf("%s: CanGrant(%q, %s, %q, %v) = %v, want %v", c.name, c.actorRole, c.actorPerm, c.role, c.perms, got, c.want)
+		}
+	}
+}
+
+func TestCanManage(t *testing.T) {
+	roleGranter := []byte(`["user:role"]`)
+	cases := []struct {
+		name       string
+		actorRole  string
+		actorPerm  []byte
+		targetRole string
+		targetPerm []byte
+		want       bool
+	}{
+		{"admin manages admin", RoleAdmin, nil, RoleAdmin, nil, true},
+		{"admin manages moderator with extras", RoleAdmin, nil, RoleModerator, []byte(`["config:read"]`), true},
+		{"moderator cannot manage admin", RoleModerator, roleGranter, RoleAdmin, nil, false},
+		{"moderator manages plain user", RoleModerator, nil, RoleUser, nil, true},
+		{"moderator manages moderator with the same permissions", RoleModerator, nil, RoleModerator, nil, true},
+		{"moderator cannot ban moderator holding more", RoleModerator, nil, RoleModerator, roleGranter, false},
+		{"role holder cannot demote target with a permission it lacks", RoleUser, roleGranter, RoleUser, []byte(`["config:read"]`), false},
+		{"role holder cannot demote moderator", RoleUser, roleGranter, RoleModerator, nil, false},
+		{"role holder manages user with a subset", RoleUser, roleGranter, RoleUser, roleGranter, true},
+	}
+	for _, c := range cases {
+		if got := CanManage(c.actorRole, c.actorPerm, c.targetRole, c.targetPerm); got != c.want {
+			t.Errorf("%s: CanManage(%q, %s, %q, %s) = %v, want %v", c.name, c.actorRole, c.actorPerm, c.targetRole, c.targetPerm, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// 允许出现在渲染结果中的标签及其属性, 其余标签去掉（保留文字）, 其余属性丢弃
+var allowedTags = map[atom.Atom][]string{
+	atom.P: nil, atom.Br: nil, atom.Hr: nil,
+	atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil, atom.H5: nil, atom.H6: nil,
+	atom.Strong: nil, atom.Em: nil, atom.Del: nil, atom.Blockquote: nil,
+	atom.Ul: nil, atom.Ol: {"start"}, atom.Li: nil,
+	atom.Pre: nil, atom.Code: {"class"},
+	atom.A:     {"href", "title"},
+	atom.Img:   {"src", "alt", "title"},
+	atom.Table: nil, atom.Thead: nil, atom.Tbody: nil, atom.Tr: nil,
+	atom.Th: {"align"}, atom.Td: {"align"},
+	atom.Span: {"class"}, atom.Div: {"class"},
+	atom.Input: {"type", "checked", "disabled"},
+}
+
+// 连同内容一起丢弃的标签
+var droppedTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Object: true,
+	atom.Embed: true, atom.Textarea: true, atom.Select: true, atom.Title: true,
+	atom.Noscript: true, atom.Template: true, atom.Svg: true, atom.Math: true,
+}
+
+// SanitizeHTML 按白名单过滤 HTML 片段, 防止 XSS
+// 只保留 Markdown 渲染会产生的标签; 链接只允许 http / https / mailto 和站内相对地址, 图片只允许 http / https
+func SanitizeHTML(fragment string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "div",
+		DataAtom: atom.Div,
+	})
+	if err != nil {
+		return html.EscapeString(fragment)
+	}
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		writeSanitized(&buf, n)
+	}
+	return buf.String()
+}
+
+func writeSanitized(buf *bytes.Buffer, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		buf.WriteString(html.EscapeString(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		// 注释、doctype 等一律丢弃
+		return
+	}
+
+	if droppedTags[n.DataAtom] {
+		return
+	}
+	attrs, ok := allowedTags[n.DataAtom]
+	if !ok || n.DataAtom == 0 {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			writeSanitized(buf, c)
+		}
+		return
+	}
+	if n.DataAtom == atom.Input && !isCheckbox(n) {
+		return
+	}
+	if n.DataAtom == atom.Img && !hasSafeSrc(n) {
+		return
+	}
+
+	buf.WriteByte('<')
+	buf.WriteString(n.Data)
+	for _, a := range n.Attr {
+		if a.Namespace != "" || !containsString(attrs, a.Key) {
+			continue
+		}
+		val, ok := sanitizeAttr(n.DataAtom, a.Key, a.Val)
+		if !ok {
+			continue
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(a.Key)
+		buf.WriteString(`="`)
+		buf.WriteString(html.EscapeString(val))
+		buf.WriteByte('"')
+	}
+	if n.DataAtom == atom.A {
+		buf.WriteString(` rel="nofollow noopener noreferrer" target="_blank"`)
+	}
+	buf.WriteByte('>')
+
+	switch n.DataAtom {
+	case atom.Br, atom.Hr, atom.Img, atom.Input:
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeSanitized(buf, c)
+	}
+	buf.WriteString("</")
+	buf.WriteString(n.Data)
+	buf.WriteByte('>')
+}
+
+// sanitizeAttr 检查属性值, 返回 false 表示丢弃该属性
+func sanitizeAttr(tag atom.Atom, key, val string) (string, bool) {
+	switch key {
+	case "href":
+		return val, safeURL(val, "http", "https", "mailto")
+	case "src":
+		return val, safeURL(val, "http", "https")
+	case "class":
+		// 只保留代码语言和公式的 class, 避免借用站内样式伪装界面
+		var kept []string
+		for _, c := range strings.Fields(val) {
+			if strings.HasPrefix(c, "language-") || c == "math" || c == "math-inline" || c == "math-display" {
+				kept = append(kept, c)
+			}
+		}
+		return strings.Join(kept, " "), len(kept) > 0
+	case "align":
+		return val, val == "left" || val == "right" || val == "center"
+	case "start":
+		for _, r := range val {
+			if r < '0' || r > '9' {
+				return "", false
+			}
+		}
+		return val, val != ""
+	case "type":
+		return val, val == "checkbox"
+	}
+	return val, true
+}
+
+// safeURL 绝对地址只允许给定协议; 没有协议的站内相对地址放行
+func safeURL(raw string, schemes ...string) bool {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return false
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" {
+		// 以 // 开头的地址会继承当前页面协议访问外站, 这里按外链处理
+		// 浏览器把反斜杠当作斜杠, 多个斜杠也会被合并, /\host 和 ///host 同样指向外站
+		if strings.Contains(raw, `\`) {
+			return false
+		}
+		return (u.Host == "" && !strings.HasPrefix(raw, "//")) || containsString(schemes, "https")
+	}
+	return containsString(schemes, strings.ToLower(u.Scheme))
+}
+
+func hasSafeSrc(n *html.Node) bool {
+	for _, a := range n.Attr {
+		if a.Key == "src" {
+			return safeURL(a.Val, "http", "https")
+		}
+	}
+	return false
+}
+
+func isCheckbox(n *html.Node) bool {
+	for _, a := range n.Attr {
+		if a.Key == "type" {
+			return a.Val == "checkbox"
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
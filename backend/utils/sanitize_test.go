@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"javascript href", `<a href="javascript:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer" target="_blank">x</a>`},
+		{"mixed case scheme", `<a href="JaVaScRiPt:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer" target="_blank">x</a>`},
+		{"entity encoded scheme", `<a href="java&#115;cript:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer" target="_blank">x</a>`},
+		{"entity encoded first letter", `<a href="&#106;avascript:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer" target="_blank">x</a>`},
+		{"entity encoded tab in scheme", `<a href="jav&#x09;ascript:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer" target="_blank">x</a>`},
+		{"leading space before scheme", `<a href=" javascript:alert(1)">x</a>`, `<a rel="nofollow noopener noreferrer" target="_blank">x</a>`},
+		{"data href", `<a href="data:text/html;base64,PHNjcmlwdD4=">x</a>`, `<a rel="nofollow noopener noreferrer" target="_blank">x</a>`},
+		{"https href", `<a href="https://example.com/a?b=1&amp;c=2">x</a>`, `<a href="https://example.com/a?b=1&amp;c=2" rel="nofollow noopener noreferrer" target="_blank">x</a>`},
+		{"mailto href", `<a href="mailto:a@example.com">x</a>`, `<a href="mailto:a@example.com" rel="nofollow noopener noreferrer" target="_blank">x</a>`},
+		{"site relative href", `<a href="/posts/1">x</a>`, `<a href="/posts/1" rel="nofollow noopener noreferrer" target="_blank">x</a>`},
+		{"protocol relative href is an external https link", `<a href="//example.com/x">x</a>`, `<a href="//example.com/x" rel="nofollow noopener noreferrer" target="_blank">x</a>`},
+		{"triple slash href is an external https link", `<a href="///example.com">x</a>`, `<a href="///example.com" rel="nofollow noopener noreferrer" target="_blank">x</a>`},
+		{"backslash host href", `<a href="/\example.com">x</a>`, `<a rel="nofollow noopener noreferrer" target="_blank">x</a>`},
+		{"double backslash href", `<a href="\\example.com">x</a>`, `<a rel="nofollow noopener noreferrer" target="_blank">x</a>`},
+		{"img without src", `<img alt="x">`, ``},
+		{"img with empty src", `<img src="" alt="x">`, ``},
+		{"img with javascript src", `<img src="javascript:alert(1)">`, ``},
+		{"img with data src", `<img src="data:image/png;base64,AAAA">`, ``},
+		{"img with mailto src", `<img src="mailto:a@example.com">`, ``},
+		{"img event handler dropped", `<img src="https://example.com/x.png" onerror="alert(1)">`, `<img src="https://example.com/x.png">`},
+		{"script dropped with content", `a<script>alert(1)</script>b`, `ab`},
+		{"svg dropped with content", `<svg><script>alert(1)</script></svg>ok`, `ok`},
+		{"math tag dropped with content", `<math><mi>x</mi></math>ok`, `ok`},
+		{"unknown tag keeps text", `<marquee>hi</marquee>`, `hi`},
+		{"comment dropped", `a<!-- x -->b`, `ab`},
+		{"style attribute dropped", `<p style="color:red">x</p>`, `<p>x</p>`},
+		{"class not allowed on p", `<p class="language-go">x</p>`, `<p>x</p>`},
+		{"site class stripped from div", `<div class="alert">x</div>`, `<div>x</div>`},
+		{"site class stripped next to math", `<span class="math btn-primary">x</span>`, `<span class="math">x</span>`},
+		{"unknown class stripped from code", `<code class="language-go evil">x</code>`, `<code class="language-go">x</code>`},
+		{"bad align dropped", `<table><tr><td align="justify">x</td></tr></table>`, `<table><tbody><tr><td>x</td></tr></tbody></table>`},
+		{"non numeric start dropped", `<ol start="1;x"><li>a</li></ol>`, `<ol><li>a</li></ol>`},
+		{"non checkbox input dropped", `<input type="text" value="x">`, ``},
+		{"checkbox input kept", `<input type="checkbox" checked="" disabled="">`, `<input type="checkbox" checked="" disabled="">`},
+		{"text escaped", `a &lt; b &amp; "c"`, `a &lt; b &amp; &#34;c&#34;`},
+	}
+	for _, c := range cases {
+		if got := SanitizeHTML(c.in); got != c.want {
+			t.Errorf("%s: SanitizeHTML(%q)\n got %q\nwant %q", c.name, c.in, got, c.want)
+		}
+	}
+}
+
+func TestRenderContent(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      string
+		html    string
+		excerpt string
+	}{
+		{
+			"raw html in inline math is escaped",
+			"$<img src=x onerror=alert(1)>$",
+			`<p><span class="math math-inline">\(&lt;img src=x onerror=alert(1)&gt;\)</span></p>`,
+			"<img src=x onerror=alert(1)>",
+		},
+		{
+			"raw html in display math is escaped",
+			"$$\n<script>alert(1)</script>\n$$",
+			`<div class="math math-display">\[&lt;script&gt;alert(1)&lt;/script&gt;\]</div>`,
+			"<script>alert(1)</script>",
+		},
+		{
+			"markdown javascript link loses href",
+			"[x](javascript:alert(1))",
+			`<p><a rel="nofollow noopener noreferrer" target="_blank">x</a></p>`,
+			"x",
+		},
+		{
+			"markdown data link loses href",
+			"[x](data:text/html,hi)",
+			`<p><a rel="nofollow noopener noreferrer" target="_blank">x</a></p>`,
+			"x",
+		},
+		{
+			"markdown protocol relative link",
+			"[x](//example.com)",
+			`<p><a href="//example.com" rel="nofollow noopener noreferrer" target="_blank">x</a></p>`,
+			"x",
+		},
+		{
+			"markdown backslash is percent-encoded and stays on site",
+			"[x](/\\example.com)",
+			`<p><a href="/%5Cexample.com" rel="nofollow noopener noreferrer" target="_blank">x</a></p>`,
+			"x",
+		},
+		{
+			"markdown image with javascript src dropped",
+			"![x](javascript:alert(1))",
+			`<p></p>`,
+			"",
+		},
+		{
+			"raw html block not rendered",
+			"<script>alert(1)</script>hi",
+			``,
+			"",
+		},
+		{
+			"inline raw html not rendered",
+			`a <img src="https://example.com/x.png" onerror="alert(1)"> b`,
+			`<p>a  b</p>`,
+			"a b",
+		},
+		{
+			"fenced code keeps language class",
+			"```go\nfmt.Println(1)\n```",
+			"<pre><code class=\"language-go\">fmt.Println(1)\n</code></pre>",
+			"",
+		},
+		{
+			"autolink",
+			"<https://example.com>",
+			`<p><a href="https://example.com" rel="nofollow noopener noreferrer" target="_blank">https://example.com</a></p>`,
+			"https://example.com",
+		},
+		{"blank content", " \n\t", "", ""},
+	}
+	for _, c := range cases {
+		html, excerpt := RenderContent(c.in)
+		if got := strings.TrimSpace(html); got != c.html {
+			t.Errorf("%s: RenderContent(%q) html\n got %q\nwant %q", c.name, c.in, got, c.html)
+		}
+		if excerpt != c.excerpt {
+			t.Errorf("%s: RenderContent(%q) excerpt = %q, want %q", c.name, c.in, excerpt, c.excerpt)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// MaxTagRunes 单个标签的最大长度
+const MaxTagRunes = 30
+
+// hashtagPattern 正文中的 #话题: # 前不能紧跟字母数字或 /、&（排除网址锚点、HTML 实体）, # 后不能是空格（排除 Markdown 标题）
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_/&#])#([\p{L}\p{N}_][\p{L}\p{N}_\-]*)`)
+
+// TagDisplayName 标签展示名: 去掉首尾空白和开头的 #（包括 "# #foo" 这样夹着空白的多段）, 连续空白合并为一个空格, 超长截断
+// 结果再次传入时不变, 因此 NormalizeTag(TagDisplayName(x)) == NormalizeTag(x)
+func TagDisplayName(name string) string {
+	for {
+		name = strings.Join(strings.Fields(name), " ")
+		trimmed := strings.TrimLeft(name, "#")
+		if trimmed == name {
+			break
+		}
+		name = trimmed
+	}
+	// 截断处可能正好是空格
+	return strings.TrimRight(truncateRunes(name, MaxTagRunes), " ")
+}
+
+// NormalizeTag 标签规范化名称, 大小写、空格写法不同的标签视为同一个
+func NormalizeTag(name string) string {
+	name = strings.ToLower(TagDisplayName(name))
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return '-'
+		}
+		return r
+	}, name)
+}
+
+// ExtractHashtags 提取正文中的 #话题, 按出现顺序返回（未去重）
+func ExtractHashtags(text string) []string {
+	matches := hashtagPattern.FindAllStringSubmatch(text, -1)
+	tags := make([]string, 0, len(matches))
+	for _, m := range matches {
+		tags = append(tags, m[1])
+	}
+	return tags
+}
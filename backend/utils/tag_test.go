@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"plain", "#go is fun", []string{"go"}},
+		{"after punctuation", "(#go) and #rust, #zig.", []string{"go", "rust", "zig"}},
+		{"unicode", "#机器学习 和 #AI", []string{"机器学习", "AI"}},
+		{"hyphen and underscore kept", "#go-lang #snake_case", []string{"go-lang", "snake_case"}},
+		{"sentence dot not part of tag", "about #golang.", []string{"golang"}},
+		{"trailing hyphen kept", "#go-", []string{"go-"}},
+		{"duplicates kept in order", "#a1 #b2 #a1", []string{"a1", "b2", "a1"}},
+		{"glued to a word", "x#go", []string{}},
+		{"url anchor", "https://example.com/page#section and /#top", []string{}},
+		{"html entity", "it&#39;s", []string{}},
+		{"markdown heading", "# Heading\n## Sub", []string{}},
+		{"double hash", "##go", []string{}},
+		{"empty hash", "# and #", []string{}},
+		{"heading line then tag", "# Title\n#tagged", []string{"tagged"}},
+	}
+	for _, c := range cases {
+		if got := ExtractHashtags(c.in); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: ExtractHashtags(%q) = %q, want %q", c.name, c.in, got, c.want)
+		}
+	}
+}
+
+func TestNormalizeTag(t *testing.T) {
+	long := strings.Repeat("a", 29) + " b"
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"lowercase", "Go", "go"},
+		{"spaces become hyphens", "Machine Learning", "machine-learning"},
+		{"surrounding space and hash", "  #Go  ", "go"},
+		{"repeated hashes", "###go", "go"},
+		{"hashes separated by space", "# #foo", "foo"},
+		{"inner whitespace collapsed", "a \t\n b", "a-b"},
+		{"only hashes", "###", ""},
+		{"blank", "   ", ""},
+		{"truncated to 30 runes", strings.Repeat("x", 40), strings.Repeat("x", 30)},
+		{"truncation does not leave a trailing hyphen", long, strings.Repeat("a", 29)},
+		{"unicode", "机器 学习", "机器-学习"},
+	}
+	for _, c := range cases {
+		if got := NormalizeTag(c.in); got != c.want {
+			t.Errorf("%s: NormalizeTag(%q) = %q, want %q", c.name, c.in, got, c.want)
+		}
+	}
+}
+
+func TestTagDisplayNameIdempotent(t *testing.T) {
+	inputs := []string{
+		"Go", "  #Go  ", "# #foo", "#  # #bar baz", "a \t b", "###",
+		strings.Repeat("a", 29) + " b", strings.Repeat("长", 35), "#" + strings.Repeat(" x", 20),
+	}
+	for _, in := range inputs {
+		once := TagDisplayName(in)
+		if twice := TagDisplayName(once); twice != once {
+			t.Errorf("TagDisplayName(%q) = %q, applied again = %q", in, once, twice)
+		}
+		if NormalizeTag(once) != NormalizeTag(in) {
+			t.Errorf("NormalizeTag(TagDisplayName(%q)) = %q, want %q", in, NormalizeTag(once), NormalizeTag(in))
+		}
+	}
+}
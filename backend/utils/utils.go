@@ -5,6 +5,7 @@ import (
 	"OpenHouse/model/database"
 	"OpenHouse/model/response"
 	"crypto/md5"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"os"
@@ -35,6 +36,12 @@ func GetMd5(str string) string {
 	return hex.EncodeToString(h.Sum(nil))
 }
 
+// AnonymousViewerKey 未登录访客的浏览去重标识, 由 IP 和 User-Agent 哈希得到, 不保存原始 IP
+func AnonymousViewerKey(ip, userAgent string) string {
+	sum := sha256.Sum256([]byte(ip + "\n" + userAgent))
+	return "anon:" + hex.EncodeToString(sum[:16])
+}
+
 func CloseFile(file *os.File) {
 	err := file.Close()
 	if err != nil {
@@ -43,41 +50,226 @@ func CloseFile(file *os.File) {
 }
 
 func ConvertPostModelWithUser(post database.Post, currentUserUUID string) response.PostInfo {
-	var imageURLs []string
-	_ = json.Unmarshal(post.ImageURLs, &imageURLs)
+	return ConvertPostModels([]database.Post{post}, currentUserUUID)[0]
+}
+
+// ConvertPostModels 批量转换帖子, 作者、标签、附件、提及、关注状态和被转发的原帖每类只查一次
+func ConvertPostModels(posts []database.Post, currentUserUUID string) []response.PostInfo {
+	// 转发的帖子只展开一层, 原帖被删除或对当前用户不可见时只返回标记
+	originIDs := make([]uint, 0)
+	for _, p := range posts {
+		if p.RepostOfID != nil {
+			originIDs = append(originIDs, *p.RepostOfID)
+		}
+	}
+	origins := visibleOrigins(originIDs, currentUserUUID)
+
+	all := make([]database.Post, 0, len(posts)+len(origins))
+	all = append(all, posts...)
+	for _, o := range origins {
+		all = append(all, o)
+	}
+	batch := loadPostBatch(all, currentUserUUID)
+
+	result := make([]response.PostInfo, 0, len(posts))
+	for _, p := range posts {
+		info := batch.convert(p, currentUserUUID)
+		if p.RepostOfID != nil {
+			if origin, ok := origins[*p.RepostOfID]; ok {
+				originInfo := batch.convert(origin, currentUserUUID)
+				info.RepostOf = &originInfo
+			} else {
+				info.RepostDeleted = true
+			}
+		}
+		result = append(result, info)
+	}
+	return result
+}
 
-	// 查作者信息
-	var author database.User
-	_ = global.DB.First(&author, "uuid = ?", post.AuthorUUID)
+// visibleOrigins 查出已发布且当前用户能看到的原帖, 与 CanViewPost 的判断一致
+func visibleOrigins(ids []uint, viewer string) map[uint]database.Post {
+	origins := make(map[uint]database.Post, len(ids))
+	if len(ids) == 0 {
+		return origins
+	}
+	var rows []database.Post
+	global.DB.Where("status = ? AND id IN (?)", database.PostStatusPublished, ids).Find(&rows)
+	var restricted []uint
+	for _, o := range rows {
+		if o.AuthorUUID == viewer || o.Visibility == "" || o.Visibility == database.PostVisibilityPublic {
+			origins[o.ID] = o
+		} else {
+			restricted = append(restricted, o.ID)
+		}
+	}
+	if len(restricted) > 0 {
+		cond, args := VisiblePostCondition(viewer)
+		var visibleIDs []uint
+		global.DB.Model(&database.Post{}).Where("id IN (?)", restricted).Where(cond, args...).Pluck("id", &visibleIDs)
+		visible := make(map[uint]bool, len(visibleIDs))
+		for _, id := range visibleIDs {
+			visible[id] = true
+		}
+		for _, o := range rows {
+			if visible[o.ID] {
+				origins[o.ID] = o
+			}
+		}
+	}
+	return origins
+}
+
+// postBatch 一页帖子转换时用到的关联数据
+type postBatch struct {
+	users       map[string]database.User
+	tags        map[uint][]string
+	attachments map[uint][]database.Attachment
+	mentions    map[uint][]database.Mention
+	following   map[string]bool
+}
+
+func loadPostBatch(posts []database.Post, currentUserUUID string) postBatch {
+	b := postBatch{
+		users:       make(map[string]database.User),
+		tags:        make(map[uint][]string),
+		attachments: make(map[uint][]database.Attachment),
+		mentions:    make(map[uint][]database.Mention),
+		following:   make(map[string]bool),
+	}
+	if len(posts) == 0 {
+		return b
+	}
+	postIDs := make([]uint, 0, len(posts))
+	uuids := make([]string, 0, len(posts))
+	for _, p := range posts {
+		postIDs = append(postIDs, p.ID)
+		uuids = append(uuids, p.AuthorUUID)
+	}
+
+	// 标签
+	var tagRows []struct {
+		PostID uint
+		Name   string
+	}
+	global.DB.Table("post_tags").
+		Select("post_tags.post_id, tags.name").
+		Joins("JOIN tags ON tags.id = post_tags.tag_id").
+		Where("post_tags.post_id IN (?)", postIDs).
+		Order("post_tags.id").
+		Scan(&tagRows)
+	for _, r := range tagRows {
+		b.tags[r.PostID] = append(b.tags[r.PostID], r.Name)
+	}
+
+	var attachments []database.Attachment
+	global.DB.Where("post_id IN (?)", postIDs).Order("position, id").Find(&attachments)
+	for _, a := range attachments {
+		b.attachments[a.PostID] = append(b.attachments[a.PostID], a)
+	}
+
+	var mentions []database.Mention
+	global.DB.Where("post_id IN (?) AND comment_id = 0", postIDs).Order("id").Find(&mentions)
+	for _, m := range mentions {
+		b.mentions[m.PostID] = append(b.mentions[m.PostID], m)
+		uuids = append(uuids, m.UserUUID)
+	}
+
+	// 作者和被提及的用户
+	var users []database.User
+	global.DB.Where("uuid IN (?)", uuids).Find(&users)
+	for _, u := range users {
+		b.users[u.UUID] = u
+	}
 
 	// 是否关注
-	isFollow := false
-	if currentUserUUID != "" && currentUserUUID != post.AuthorUUID {
-		var relation database.UserFollow
-		if err := global.DB.
-			Where("user_id = ? AND follow_id = ? AND deleted_at IS NULL", currentUserUUID, post.AuthorUUID).
-			First(&relation).Error; err == nil {
-			isFollow = true
+	if currentUserUUID != "" {
+		var followIDs []string
+		global.DB.Model(&database.UserFollow{}).
+			Where("user_id = ? AND follow_id IN (?) AND deleted_at IS NULL", currentUserUUID, uuids).
+			Pluck("follow_id", &followIDs)
+		for _, id := range followIDs {
+			b.following[id] = true
 		}
 	}
+	return b
+}
+
+func (b postBatch) convert(post database.Post, currentUserUUID string) response.PostInfo {
+	var imageURLs []string
+	_ = json.Unmarshal(post.ImageURLs, &imageURLs)
+
+	author := b.users[post.AuthorUUID]
+	tags := b.tags[post.ID]
+	if tags == nil {
+		tags = []string{}
+	}
+	attachmentInfos := make([]response.AttachmentInfo, 0, len(b.attachments[post.ID]))
+	for _, a := range b.attachments[post.ID] {
+		attachmentInfos = append(attachmentInfos, ConvertAttachment(a))
+	}
+	isFollow := currentUserUUID != post.AuthorUUID && b.following[post.AuthorUUID]
+
+	contentHTML, excerpt := RenderContent(post.Content)
 
 	return response.PostInfo{
 		PostID:         post.ID,
 		AuthorUUID:     post.AuthorUUID,
 		Title:          post.Title,
 		Content:        post.Content,
+		ContentHTML:    contentHTML,
+		Excerpt:        excerpt,
 		ImageURLs:      imageURLs,
+		Attachments:    attachmentInfos,
+		Mentions:       mentionEntities(b.mentions[post.ID], b.users),
 		CreateDate:     post.CreateDate,
 		StarNumber:     post.StarNumber,
 		FavoriteNumber: post.FavoriteNumber,
 		ViewNumber:     post.ViewNumber,
 		CommentNumber:  post.CommentNumber,
+		RepostNumber:   post.RepostNumber,
+		Tags:           tags,
+		Status:         post.Status,
+		Visibility:     post.Visibility,
+		PublishAt:      post.PublishAt,
+		EditedAt:       post.EditedAt,
+		RepostOfID:     post.RepostOfID,
 
 		// 用户信息字段
 		Username:    author.Username,
 		IntroLong:   author.IntroLong,
 		AvatarURL:   author.AvatarURL,
-		IsFollowing: isFollow,
+		IsFollowing: ViewerFlag(currentUserUUID, isFollow),
+		IsVerified:  author.IsVerified,
+		Institution: author.Institution,
+	}
+}
+
+// ViewerFlag 当前用户相关的标记（是否点赞、是否关注等）, 未登录访问时返回 nil, 序列化时省略
+func ViewerFlag(viewer string, v bool) *bool {
+	if viewer == "" {
+		return nil
+	}
+	return &v
+}
+
+// ConvertAttachment 附件转换为返回结构
+// 上传的文件不返回存储地址, 通过下载接口获取限时地址并计数; 只有外部链接直接返回 URL
+func ConvertAttachment(a database.Attachment) response.AttachmentInfo {
+	var url string
+	if a.Kind == database.AttachmentLink {
+		url = a.URL
+	}
+	return response.AttachmentInfo{
+		ID:            a.ID,
+		Kind:          a.Kind,
+		URL:           url,
+		FileName:      a.FileName,
+		MimeType:      a.MimeType,
+		Bytes:         a.Bytes,
+		PageCount:     a.PageCount,
+		ThumbnailURL:  a.ThumbnailURL,
+		DownloadCount: a.DownloadCount,
 	}
 }
 
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"OpenHouse/global"
+	"OpenHouse/model/database"
+)
+
+// VisiblePostCondition 查看者能看到的帖子的查询条件, 用法 db.Where(cond, args...)
+// 作者本人始终可见; viewer 为空（未登录）时只有公开帖子
+func VisiblePostCondition(viewer string) (string, []interface{}) {
+	if viewer == "" {
+		return "posts.visibility = ?", []interface{}{database.PostVisibilityPublic}
+	}
+	return `posts.author_uuid = ? OR posts.visibility = ?
+		OR (posts.visibility IN (?)
+			AND posts.author_uuid IN (SELECT follow_id FROM user_follows WHERE user_id = ? AND deleted_at IS NULL)
+			AND (posts.visibility = ? OR posts.author_uuid IN (SELECT user_id FROM user_follows WHERE follow_id = ? AND deleted_at IS NULL)))
+		OR (posts.visibility = ? AND posts.author_uuid IN (
+			SELECT match_uuid FROM match_results WHERE user_uuid = ? AND deleted_at IS NULL
+			UNION SELECT user_uuid FROM match_results WHERE match_uuid = ? AND deleted_at IS NULL))`,
+		[]interface{}{
+			viewer, database.PostVisibilityPublic,
+			[]string{database.PostVisibilityFollowers, database.PostVisibilityMutual}, viewer,
+			database.PostVisibilityFollowers, viewer,
+			database.PostVisibilityMatched, viewer, viewer,
+		}
+}
+
+// CanViewPost 查看者能否看到该帖子, 不检查发布状态
+func CanViewPost(post database.Post, viewer string) bool {
+	if post.AuthorUUID == viewer || post.Visibility == "" || post.Visibility == database.PostVisibilityPublic {
+		return true
+	}
+	cond, args := VisiblePostCondition(viewer)
+	var n int
+	global.DB.Model(&database.Post{}).Where("id = ?", post.ID).Where(cond, args...).Count(&n)
+	return n > 0
+}